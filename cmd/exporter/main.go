@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
+
+	"github.com/alan.bermudez/goasync/internal/config"
+	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/internal/services"
+	"github.com/alan.bermudez/goasync/pkg/archive"
+	"github.com/alan.bermudez/goasync/pkg/logger"
+)
+
+// exporter exporta los posts publicados como archivos Markdown para Hugo o Jekyll, en un
+// tar.gz o en un directorio.
+//
+// Uso: go run ./cmd/exporter [-format hugo|jekyll] [-comments] [-out export.tar.gz | -dir ./site]
+func main() {
+	format := flag.String("format", models.ExportFormatHugo, "formato del export: hugo o jekyll")
+	comments := flag.Bool("comments", false, "exporta los comentarios aprobados como archivos de datos")
+	out := flag.String("out", "", "archivo tar.gz de salida, o - para la salida estándar (por defecto goasync-<formato>-<fecha>.tar.gz)")
+	dir := flag.String("dir", "", "directorio de salida; si se indica no se genera tar.gz")
+	flag.Parse()
+
+	if !services.ValidExportFormat(*format) {
+		log.Fatalf("Formato de export inválido: %q (hugo o jekyll)", *format)
+	}
+
+	// Cargar variables de entorno
+	if err := godotenv.Load(); err != nil {
+		log.Println("No se pudo cargar el archivo .env, usando variables del sistema")
+	}
+
+	cfg := config.Load()
+	logger.Init(cfg.Log.Level)
+	logr := logger.GetLogger()
+
+	db, err := sql.Open("postgres", cfg.Database.URL())
+	if err != nil {
+		log.Fatalf("Error conectando a la base de datos: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Ping(); err != nil {
+		log.Fatalf("Error verificando conexión a la base de datos: %v", err)
+	}
+
+	revisionService := services.NewPostRevisionService(db, cfg.Content, logr)
+	postService := services.NewPostService(db, revisionService, cfg.Content.SearchLanguage, logr)
+	exportService := services.NewExportService(postService, services.NewTagService(db, logr),
+		services.NewCommentService(db, logr), logr)
+
+	// Ctrl+C detiene el export entre lotes
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	opts := models.ExportOptions{Format: *format, Comments: *comments}
+
+	var report *models.ExportReport
+	target := *dir
+	if *dir != "" {
+		report, err = exportService.ExportStatic(ctx, archive.Dir(*dir), opts)
+	} else {
+		target = *out
+		if target == "" {
+			target = fmt.Sprintf("goasync-%s-%s.tar.gz", *format, time.Now().UTC().Format("20060102-150405"))
+		}
+		report, err = exportTarGz(ctx, exportService, target, opts)
+	}
+	if err != nil {
+		log.Fatalf("Error exportando: %v", err)
+	}
+
+	// El resumen va a stderr para no mezclarse con el tar.gz si se escribe en stdout
+	fmt.Fprintf(os.Stderr, "Exportados %d posts y %d comentarios en formato %s a %s\n",
+		report.Posts, report.Comments, report.Format, target)
+}
+
+// exportTarGz escribe el export en un tar.gz; el archivo se elimina si el export falla
+func exportTarGz(ctx context.Context, exportService *services.ExportService, target string, opts models.ExportOptions) (*models.ExportReport, error) {
+	file := os.Stdout
+	if target != "-" {
+		var err error
+		file, err = os.Create(target)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+	}
+
+	writer := archive.NewTarGz(file)
+	report, err := exportService.ExportStatic(ctx, writer, opts)
+	if err == nil {
+		err = writer.Close()
+	}
+	if err == nil && target != "-" {
+		err = file.Sync()
+	}
+	if err != nil && target != "-" {
+		os.Remove(target)
+	}
+
+	return report, err
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
+
+	"github.com/alan.bermudez/goasync/internal/config"
+	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/internal/services"
+	"github.com/alan.bermudez/goasync/pkg/logger"
+	"github.com/alan.bermudez/goasync/pkg/password"
+	"github.com/alan.bermudez/goasync/pkg/wxr"
+)
+
+// importer importa un export de WordPress (WXR) en la base de datos configurada.
+//
+// Uso: go run ./cmd/importer -file export.xml [-dry-run] [-create-commenters] [-source blog.example.com] [-json]
+func main() {
+	file := flag.String("file", "", "archivo WXR exportado desde WordPress (Herramientas > Exportar)")
+	dryRun := flag.Bool("dry-run", false, "muestra lo que se importaría sin escribir nada")
+	createCommenters := flag.Bool("create-commenters", false, "crea usuarios commenter para los comentaristas anónimos con email")
+	source := flag.String("source", "", "identificador del sitio de origen (por defecto, la URL del export)")
+	asJSON := flag.Bool("json", false, "muestra el informe en JSON")
+	flag.Parse()
+
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Cargar variables de entorno
+	if err := godotenv.Load(); err != nil {
+		log.Println("No se pudo cargar el archivo .env, usando variables del sistema")
+	}
+
+	cfg := config.Load()
+	logger.Init(cfg.Log.Level)
+	logr := logger.GetLogger()
+
+	// Leer el export antes de conectar para fallar pronto si no es válido
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatalf("Error abriendo el export: %v", err)
+	}
+	export, err := wxr.Parse(f)
+	f.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := sql.Open("postgres", cfg.Database.URL())
+	if err != nil {
+		log.Fatalf("Error conectando a la base de datos: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Ping(); err != nil {
+		log.Fatalf("Error verificando conexión a la base de datos: %v", err)
+	}
+
+	userService := services.NewUserService(db, password.NewHasher(cfg.Auth.BcryptCost), logr)
+	revisionService := services.NewPostRevisionService(db, cfg.Content, logr)
+	postService := services.NewPostService(db, revisionService, cfg.Content.SearchLanguage, logr)
+	importService := services.NewImportService(db, userService, services.NewCategoryService(db, logr),
+		services.NewTagService(db, logr), postService, services.NewCommentService(db, logr), logr)
+
+	report, err := importService.ImportWXR(export, models.ImportOptions{
+		Source:           *source,
+		DryRun:           *dryRun,
+		CreateCommenters: *createCommenters,
+	})
+	if report != nil {
+		if *asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			encoder.Encode(report)
+		} else {
+			printReport(report)
+		}
+	}
+	if err != nil {
+		log.Fatalf("Importación interrumpida: %v", err)
+	}
+}
+
+// printReport muestra el informe de la importación en texto
+func printReport(report *models.ImportReport) {
+	if report.DryRun {
+		fmt.Println("Modo dry-run: no se ha escrito nada")
+	}
+	fmt.Printf("Origen: %s\n\n", report.Source)
+
+	fmt.Printf("%-12s %8s %8s %8s %8s\n", "", "creados", "asociad.", "omitidos", "fallidos")
+	rows := []struct {
+		name   string
+		counts models.ImportCounts
+	}{
+		{"Usuarios", report.Users},
+		{"Categorías", report.Categories},
+		{"Etiquetas", report.Tags},
+		{"Posts", report.Posts},
+		{"Comentarios", report.Comments},
+	}
+	for _, row := range rows {
+		fmt.Printf("%-12s %8d %8d %8d %8d\n", row.name,
+			row.counts.Created, row.counts.Linked, row.counts.Skipped, row.counts.Failed)
+	}
+
+	if len(report.Ignored) > 0 {
+		kinds := make([]string, 0, len(report.Ignored))
+		for kind := range report.Ignored {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+
+		fmt.Println("\nIgnorados:")
+		for _, kind := range kinds {
+			fmt.Printf("  %-20s %d\n", kind, report.Ignored[kind])
+		}
+	}
+
+	if len(report.Warnings) > 0 {
+		fmt.Printf("\nAvisos (%d):\n", len(report.Warnings))
+		for _, warning := range report.Warnings {
+			fmt.Printf("  - %s\n", warning)
+		}
+	}
+}
@@ -10,8 +10,12 @@ import (
 
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
+	"golang.org/x/crypto/bcrypt"
 )
 
+// seedPassword es la contraseña de todos los usuarios de prueba
+const seedPassword = "password123"
+
 // User representa un usuario en el sistema
 type User struct {
 	ID           string
@@ -20,6 +24,7 @@ type User struct {
 	PasswordHash string
 	FirstName    string
 	LastName     string
+	Role         string
 	IsActive     bool
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
@@ -140,6 +145,15 @@ func getSeederMode() string {
 	return "default"
 }
 
+// seedPasswordHash genera el hash bcrypt de la contraseña de los usuarios de prueba
+func seedPasswordHash() (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(seedPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("error generando hash de contraseña: %w", err)
+	}
+	return string(hash), nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -132,6 +132,11 @@ func seedMassiveUsers(db *sql.DB) error {
 		"live.com", "me.com", "mac.com", "msn.com", "rocketmail.com",
 	}
 
+	passwordHash, err := seedPasswordHash()
+	if err != nil {
+		return err
+	}
+
 	// Generar 1000 usuarios
 	userCount := 1000
 	log.Printf("  - Generando %d usuarios...", userCount)
@@ -155,16 +160,17 @@ func seedMassiveUsers(db *sql.DB) error {
 			username := fmt.Sprintf("%s%s%d", strings.ToLower(firstName), strings.ToLower(lastName), j)
 			email := fmt.Sprintf("%s.%s%d@%s", strings.ToLower(firstName), strings.ToLower(lastName), j, emailDomains[rand.Intn(len(emailDomains))])
 
-			values = append(values, username, email, "$2a$10$hashedpassword", firstName, lastName, true, time.Now(), time.Now())
+			values = append(values, username, email, passwordHash, firstName, lastName, true, true, time.Now(), time.Now())
 
-			placeholders = append(placeholders, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			placeholders = append(placeholders, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
 				placeholderIndex, placeholderIndex+1, placeholderIndex+2, placeholderIndex+3,
-				placeholderIndex+4, placeholderIndex+5, placeholderIndex+6, placeholderIndex+7))
-			placeholderIndex += 8
+				placeholderIndex+4, placeholderIndex+5, placeholderIndex+6, placeholderIndex+7,
+				placeholderIndex+8))
+			placeholderIndex += 9
 		}
 
 		query := fmt.Sprintf(`
-			INSERT INTO users (username, email, password_hash, first_name, last_name, is_active, created_at, updated_at)
+			INSERT INTO users (username, email, password_hash, first_name, last_name, is_active, is_verified, created_at, updated_at)
 			VALUES %s
 			RETURNING id`, strings.Join(placeholders, ", "))
 
@@ -85,22 +85,27 @@ func seedBasicCategories(db *sql.DB) error {
 func seedBasicUsers(db *sql.DB) error {
 	log.Println("👥 Insertando usuarios básicos...")
 
+	passwordHash, err := seedPasswordHash()
+	if err != nil {
+		return err
+	}
+
 	users := []User{
-		{Username: "admin", Email: "admin@goasync.com", PasswordHash: "$2a$10$hashedpassword", FirstName: "Admin", LastName: "User"},
-		{Username: "johndoe", Email: "john.doe@example.com", PasswordHash: "$2a$10$hashedpassword", FirstName: "John", LastName: "Doe"},
-		{Username: "janesmith", Email: "jane.smith@example.com", PasswordHash: "$2a$10$hashedpassword", FirstName: "Jane", LastName: "Smith"},
-		{Username: "bobwilson", Email: "bob.wilson@example.com", PasswordHash: "$2a$10$hashedpassword", FirstName: "Bob", LastName: "Wilson"},
-		{Username: "alicebrown", Email: "alice.brown@example.com", PasswordHash: "$2a$10$hashedpassword", FirstName: "Alice", LastName: "Brown"},
+		{Username: "admin", Email: "admin@goasync.com", PasswordHash: passwordHash, FirstName: "Admin", LastName: "User", Role: "admin"},
+		{Username: "johndoe", Email: "john.doe@example.com", PasswordHash: passwordHash, FirstName: "John", LastName: "Doe", Role: "author"},
+		{Username: "janesmith", Email: "jane.smith@example.com", PasswordHash: passwordHash, FirstName: "Jane", LastName: "Smith", Role: "editor"},
+		{Username: "bobwilson", Email: "bob.wilson@example.com", PasswordHash: passwordHash, FirstName: "Bob", LastName: "Wilson", Role: "author"},
+		{Username: "alicebrown", Email: "alice.brown@example.com", PasswordHash: passwordHash, FirstName: "Alice", LastName: "Brown", Role: "author"},
 	}
 
 	for _, user := range users {
 		query := `
-			INSERT INTO users (username, email, password_hash, first_name, last_name, is_active, created_at, updated_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			INSERT INTO users (username, email, password_hash, first_name, last_name, role, is_active, is_verified, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 			RETURNING id`
 
 		var id string
-		err := db.QueryRow(query, user.Username, user.Email, user.PasswordHash, user.FirstName, user.LastName, true, time.Now(), time.Now()).Scan(&id)
+		err := db.QueryRow(query, user.Username, user.Email, user.PasswordHash, user.FirstName, user.LastName, user.Role, true, true, time.Now(), time.Now()).Scan(&id)
 		if err != nil {
 			return fmt.Errorf("error insertando usuario %s: %w", user.Username, err)
 		}
@@ -17,6 +17,13 @@ CREATE TABLE IF NOT EXISTS users (
     first_name VARCHAR(100),
     last_name VARCHAR(100),
     is_active BOOLEAN DEFAULT true,
+    role VARCHAR(20) NOT NULL DEFAULT 'commenter' CHECK (role IN ('admin', 'editor', 'author', 'commenter', 'reader')),
+    is_verified BOOLEAN NOT NULL DEFAULT false,
+    totp_secret VARCHAR(128),
+    totp_enabled BOOLEAN NOT NULL DEFAULT false,
+    totp_last_step BIGINT,
+    -- Versión de los tokens de refresco; se incrementa al cambiar la contraseña para invalidarlos
+    token_version INTEGER NOT NULL DEFAULT 0,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
@@ -42,7 +49,8 @@ CREATE TABLE IF NOT EXISTS categories (
     slug VARCHAR(100) UNIQUE NOT NULL,
     is_active BOOLEAN DEFAULT true,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
 );
 
 -- Tabla de posts/artículos
@@ -54,19 +62,109 @@ CREATE TABLE IF NOT EXISTS posts (
     excerpt TEXT,
     author_id UUID REFERENCES users(id) ON DELETE SET NULL,
     category_id UUID REFERENCES categories(id) ON DELETE SET NULL,
-    status VARCHAR(20) DEFAULT 'draft' CHECK (status IN ('draft', 'published', 'archived')),
+    status VARCHAR(20) DEFAULT 'draft' CHECK (status IN ('draft', 'published', 'archived', 'scheduled')),
     published_at TIMESTAMP WITH TIME ZONE,
+    scheduled_at TIMESTAMP WITH TIME ZONE,
+    search_language REGCONFIG NOT NULL DEFAULT 'spanish',
+    search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector(search_language, COALESCE(title, '')), 'A') ||
+        setweight(to_tsvector(search_language, COALESCE(excerpt, '')), 'B') ||
+        setweight(to_tsvector(search_language, COALESCE(content, '')), 'C')
+    ) STORED,
+    content_html TEXT,
+    toc JSONB,
+    word_count INTEGER NOT NULL DEFAULT 0,
+    reading_time INTEGER NOT NULL DEFAULT 0,
+    render_version SMALLINT NOT NULL DEFAULT 0,
+    featured_image_id UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CHECK (status <> 'scheduled' OR scheduled_at IS NOT NULL)
+);
+
+-- Tabla de la biblioteca de medios. variants guarda las versiones redimensionadas de las
+-- imágenes; las URLs públicas se calculan a partir de storage_key.
+CREATE TABLE IF NOT EXISTS media (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    uploader_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    post_id UUID REFERENCES posts(id) ON DELETE SET NULL,
+    storage_key VARCHAR(500) UNIQUE NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL CHECK (size_bytes > 0),
+    width INTEGER,
+    height INTEGER,
+    alt_text TEXT NOT NULL DEFAULT '',
+    variants JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- La imagen destacada referencia media, que se crea después de posts. Sin ON DELETE: un
+-- archivo no puede eliminarse mientras sea la imagen destacada de un post.
+ALTER TABLE posts DROP CONSTRAINT IF EXISTS posts_featured_image_id_fkey;
+ALTER TABLE posts ADD CONSTRAINT posts_featured_image_id_fkey
+    FOREIGN KEY (featured_image_id) REFERENCES media(id);
+
+-- Tabla de revisiones de posts
+CREATE TABLE IF NOT EXISTS post_revisions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    content TEXT NOT NULL,
+    excerpt TEXT,
+    editor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    note TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(post_id, revision)
+);
+
+-- Tabla de series de posts
+CREATE TABLE IF NOT EXISTS series (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    title VARCHAR(255) NOT NULL,
+    slug VARCHAR(255) UNIQUE NOT NULL,
+    description TEXT,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Partes de cada serie. Un post pertenece como máximo a una serie y la unicidad de la
+-- posición se verifica al confirmar la transacción para poder reordenar las partes.
+CREATE TABLE IF NOT EXISTS series_posts (
+    series_id UUID NOT NULL REFERENCES series(id) ON DELETE CASCADE,
+    post_id UUID NOT NULL UNIQUE REFERENCES posts(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL CHECK (position > 0),
+    PRIMARY KEY (series_id, post_id),
+    CONSTRAINT series_posts_position_key UNIQUE (series_id, position) DEFERRABLE INITIALLY DEFERRED
+);
+
+-- Tabla de visitas diarias de posts
+CREATE TABLE IF NOT EXISTS post_views (
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    views BIGINT NOT NULL DEFAULT 0 CHECK (views >= 0),
+    PRIMARY KEY (post_id, day)
+);
+
+-- Tabla de slugs anteriores de posts que redirigen al slug actual
+CREATE TABLE IF NOT EXISTS post_slug_redirects (
+    slug VARCHAR(255) PRIMARY KEY,
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
 -- Tabla de tags
 CREATE TABLE IF NOT EXISTS tags (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     name VARCHAR(50) UNIQUE NOT NULL,
     slug VARCHAR(50) UNIQUE NOT NULL,
     description TEXT,
-    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
 );
 
 -- Tabla de relación posts-tags (many-to-many)
@@ -83,9 +181,12 @@ CREATE TABLE IF NOT EXISTS comments (
     author_id UUID REFERENCES users(id) ON DELETE SET NULL,
     parent_id UUID REFERENCES comments(id) ON DELETE CASCADE,
     content TEXT NOT NULL,
+    content_html TEXT,
+    render_version SMALLINT NOT NULL DEFAULT 0,
     is_approved BOOLEAN DEFAULT false,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
 );
 
 -- Tabla de sesiones de usuario
@@ -94,13 +195,113 @@ CREATE TABLE IF NOT EXISTS user_sessions (
     user_id UUID REFERENCES users(id) ON DELETE CASCADE,
     token_hash VARCHAR(255) UNIQUE NOT NULL,
     expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    ip_address INET,
+    user_agent TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Tabla de tokens de un solo uso (restablecimiento de contraseña y verificación de email)
+CREATE TABLE IF NOT EXISTS user_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose VARCHAR(30) NOT NULL CHECK (purpose IN ('password_reset', 'email_verification')),
+    token_hash VARCHAR(255) UNIQUE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Tabla de intentos fallidos de inicio de sesión por cuenta y por IP
+CREATE TABLE IF NOT EXISTS login_throttles (
+    scope VARCHAR(10) NOT NULL CHECK (scope IN ('account', 'ip')),
+    key VARCHAR(255) NOT NULL,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP WITH TIME ZONE,
+    next_attempt_at TIMESTAMP WITH TIME ZONE,
+    locked_until TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (scope, key)
+);
+
+-- Tabla de códigos de recuperación de dos factores (hasheados, de un solo uso)
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash VARCHAR(255) NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Tabla de configuración de seguridad por rol
+CREATE TABLE IF NOT EXISTS role_settings (
+    role VARCHAR(20) PRIMARY KEY CHECK (role IN ('admin', 'editor', 'author', 'commenter', 'reader')),
+    require_two_factor BOOLEAN NOT NULL DEFAULT false,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO role_settings (role) VALUES ('admin'), ('editor'), ('author'), ('commenter'), ('reader')
+ON CONFLICT (role) DO NOTHING;
+
+-- Tabla de suplantaciones de usuarios por administradores
+CREATE TABLE IF NOT EXISTS impersonations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    admin_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(255) UNIQUE NOT NULL,
+    reason TEXT,
+    ip_address INET,
+    user_agent TEXT,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ended_at TIMESTAMP WITH TIME ZONE,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Tabla de invitaciones para el registro solo por invitación
+CREATE TABLE IF NOT EXISTS invites (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    code_prefix VARCHAR(16) NOT NULL,
+    code_hash VARCHAR(255) UNIQUE NOT NULL,
+    email VARCHAR(255),
+    role VARCHAR(20) NOT NULL DEFAULT 'commenter' CHECK (role IN ('admin', 'editor', 'author', 'commenter', 'reader')),
+    max_uses INTEGER NOT NULL DEFAULT 1 CHECK (max_uses > 0),
+    uses INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Tabla de API keys para clientes máquina a máquina
+CREATE TABLE IF NOT EXISTS api_keys (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    key_prefix VARCHAR(16) NOT NULL,
+    key_hash VARCHAR(255) UNIQUE NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    expires_at TIMESTAMP WITH TIME ZONE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Tabla de IDs importados desde otras plataformas (ej: WordPress), por sitio de origen y tipo.
+-- Permite repetir una importación sin duplicar elementos.
+CREATE TABLE IF NOT EXISTS import_mappings (
+    source VARCHAR(255) NOT NULL,
+    source_type VARCHAR(20) NOT NULL CHECK (source_type IN ('user', 'commenter', 'category', 'tag', 'post', 'comment')),
+    source_id VARCHAR(255) NOT NULL,
+    target_id UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (source, source_type, source_id)
+);
+
 -- Tabla de logs de actividad
 CREATE TABLE IF NOT EXISTS activity_logs (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    impersonator_id UUID REFERENCES users(id) ON DELETE SET NULL,
     action VARCHAR(100) NOT NULL,
     resource_type VARCHAR(50),
     resource_id UUID,
@@ -113,18 +314,46 @@ CREATE TABLE IF NOT EXISTS activity_logs (
 -- Crear índices para mejorar el rendimiento
 CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
 CREATE INDEX IF NOT EXISTS idx_users_username ON users(username);
+CREATE INDEX IF NOT EXISTS idx_users_role ON users(role);
 CREATE INDEX IF NOT EXISTS idx_posts_slug ON posts(slug);
 CREATE INDEX IF NOT EXISTS idx_posts_status ON posts(status);
 CREATE INDEX IF NOT EXISTS idx_posts_author_id ON posts(author_id);
 CREATE INDEX IF NOT EXISTS idx_posts_category_id ON posts(category_id);
 CREATE INDEX IF NOT EXISTS idx_posts_published_at ON posts(published_at);
+CREATE INDEX IF NOT EXISTS idx_posts_published_at_id ON posts(published_at DESC NULLS LAST, id DESC);
+CREATE INDEX IF NOT EXISTS idx_posts_search_vector ON posts USING GIN(search_vector);
+CREATE INDEX IF NOT EXISTS idx_posts_scheduled_at ON posts(scheduled_at) WHERE status = 'scheduled';
+CREATE INDEX IF NOT EXISTS idx_posts_deleted_at ON posts(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_posts_featured_image_id ON posts(featured_image_id) WHERE featured_image_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_media_uploader_id ON media(uploader_id);
+CREATE INDEX IF NOT EXISTS idx_media_post_id ON media(post_id);
+CREATE INDEX IF NOT EXISTS idx_media_created_at_id ON media(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_post_revisions_created_at ON post_revisions(created_at);
+CREATE INDEX IF NOT EXISTS idx_post_views_day ON post_views(day);
+CREATE INDEX IF NOT EXISTS idx_post_slug_redirects_post_id ON post_slug_redirects(post_id);
+CREATE INDEX IF NOT EXISTS idx_post_tags_tag_id ON post_tags(tag_id);
 CREATE INDEX IF NOT EXISTS idx_comments_post_id ON comments(post_id);
 CREATE INDEX IF NOT EXISTS idx_comments_author_id ON comments(author_id);
+CREATE INDEX IF NOT EXISTS idx_comments_created_at_id ON comments(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_comments_deleted_at ON comments(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_categories_deleted_at ON categories(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_tags_deleted_at ON tags(deleted_at) WHERE deleted_at IS NOT NULL;
 CREATE INDEX IF NOT EXISTS idx_user_sessions_token_hash ON user_sessions(token_hash);
 CREATE INDEX IF NOT EXISTS idx_user_sessions_expires_at ON user_sessions(expires_at);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user_id ON user_sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_user_tokens_token_hash ON user_tokens(token_hash);
+CREATE INDEX IF NOT EXISTS idx_user_tokens_user_id ON user_tokens(user_id, purpose);
+CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user_id ON user_recovery_codes(user_id);
+CREATE INDEX IF NOT EXISTS idx_impersonations_token_hash ON impersonations(token_hash);
+CREATE INDEX IF NOT EXISTS idx_impersonations_expires_at ON impersonations(expires_at);
+CREATE INDEX IF NOT EXISTS idx_invites_code_hash ON invites(code_hash);
+CREATE INDEX IF NOT EXISTS idx_api_keys_key_hash ON api_keys(key_hash);
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys(user_id);
 CREATE INDEX IF NOT EXISTS idx_activity_logs_user_id ON activity_logs(user_id);
 CREATE INDEX IF NOT EXISTS idx_activity_logs_action ON activity_logs(action);
 CREATE INDEX IF NOT EXISTS idx_activity_logs_created_at ON activity_logs(created_at);
+CREATE INDEX IF NOT EXISTS idx_activity_logs_created_at_id ON activity_logs(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_activity_logs_impersonator_id ON activity_logs(impersonator_id);
 
 -- Crear función para actualizar automáticamente updated_at
 CREATE OR REPLACE FUNCTION update_updated_at_column()
@@ -135,6 +364,24 @@ BEGIN
 END;
 $$ language 'plpgsql';
 
+-- Variante para las tablas con contenido renderizado. Una actualización que solo cambia las
+-- columnas derivadas del renderizado (como el backfill al subir la versión del renderizador)
+-- conserva updated_at, que la API usa como versión para If-Match.
+CREATE OR REPLACE FUNCTION update_updated_at_unless_render_only()
+RETURNS TRIGGER AS $$
+DECLARE
+    render_columns TEXT[] := ARRAY[
+        'content_html', 'toc', 'word_count', 'reading_time', 'render_version', 'search_vector', 'updated_at'
+    ];
+BEGIN
+    IF to_jsonb(NEW) - render_columns = to_jsonb(OLD) - render_columns THEN
+        RETURN NEW;
+    END IF;
+    NEW.updated_at = CURRENT_TIMESTAMP;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
 -- Crear triggers para actualizar automáticamente updated_at
 CREATE TRIGGER update_users_updated_at BEFORE UPDATE ON users
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -143,10 +390,13 @@ CREATE TRIGGER update_user_profiles_updated_at BEFORE UPDATE ON user_profiles
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 
 CREATE TRIGGER update_posts_updated_at BEFORE UPDATE ON posts
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_unless_render_only();
+
+CREATE TRIGGER update_series_updated_at BEFORE UPDATE ON series
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 
 CREATE TRIGGER update_comments_updated_at BEFORE UPDATE ON comments
-    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_unless_render_only();
 
 -- Crear función para generar slugs únicos
 CREATE OR REPLACE FUNCTION generate_unique_slug(table_name TEXT, column_name TEXT, value TEXT, id UUID DEFAULT NULL)
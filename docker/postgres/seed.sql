@@ -26,12 +26,12 @@ INSERT INTO tags (name, slug, description) VALUES
 ('Data Science', 'data-science', 'Ciencia de datos');
 
 -- Insertar usuarios de ejemplo (password: 'password123')
-INSERT INTO users (username, email, password_hash, first_name, last_name) VALUES
-('admin', 'admin@goasync.com', crypt('password123', gen_salt('bf')), 'Admin', 'User'),
-('johndoe', 'john.doe@example.com', crypt('password123', gen_salt('bf')), 'John', 'Doe'),
-('janesmith', 'jane.smith@example.com', crypt('password123', gen_salt('bf')), 'Jane', 'Smith'),
-('bobwilson', 'bob.wilson@example.com', crypt('password123', gen_salt('bf')), 'Bob', 'Wilson'),
-('alicebrown', 'alice.brown@example.com', crypt('password123', gen_salt('bf')), 'Alice', 'Brown');
+INSERT INTO users (username, email, password_hash, first_name, last_name, role, is_verified) VALUES
+('admin', 'admin@goasync.com', crypt('password123', gen_salt('bf')), 'Admin', 'User', 'admin', true),
+('johndoe', 'john.doe@example.com', crypt('password123', gen_salt('bf')), 'John', 'Doe', 'author', true),
+('janesmith', 'jane.smith@example.com', crypt('password123', gen_salt('bf')), 'Jane', 'Smith', 'editor', true),
+('bobwilson', 'bob.wilson@example.com', crypt('password123', gen_salt('bf')), 'Bob', 'Wilson', 'author', true),
+('alicebrown', 'alice.brown@example.com', crypt('password123', gen_salt('bf')), 'Alice', 'Brown', 'author', true);
 
 -- Insertar perfiles de usuario
 INSERT INTO user_profiles (user_id, bio, avatar_url, phone, address) VALUES
@@ -576,9 +576,9 @@ BEGIN
     RETURN QUERY
     SELECT 
         (SELECT COUNT(*) FROM users)::BIGINT,
-        (SELECT COUNT(*) FROM posts)::BIGINT,
-        (SELECT COUNT(*) FROM comments)::BIGINT,
-        (SELECT COUNT(*) FROM categories)::BIGINT,
-        (SELECT COUNT(*) FROM tags)::BIGINT;
+        (SELECT COUNT(*) FROM posts WHERE deleted_at IS NULL)::BIGINT,
+        (SELECT COUNT(*) FROM comments WHERE deleted_at IS NULL)::BIGINT,
+        (SELECT COUNT(*) FROM categories WHERE deleted_at IS NULL)::BIGINT,
+        (SELECT COUNT(*) FROM tags WHERE deleted_at IS NULL)::BIGINT;
 END;
 $$ LANGUAGE plpgsql;
@@ -3,6 +3,24 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultJWTSecret es el secreto JWT de ejemplo, que solo se acepta en desarrollo
+	DefaultJWTSecret = "change-me-in-production"
+
+	// MinJWTSecretLength es la longitud mínima en bytes del secreto JWT fuera de desarrollo
+	MinJWTSecretLength = 32
+
+	// DefaultTOTPEncryptionKey es la clave de ejemplo para cifrar los secretos TOTP, que solo
+	// se acepta en desarrollo
+	DefaultTOTPEncryptionKey = "change-me-in-production-totp"
+
+	// MinTOTPEncryptionKeyLength es la longitud mínima en bytes de la clave de cifrado TOTP
+	// fuera de desarrollo
+	MinTOTPEncryptionKeyLength = 32
 )
 
 // Config contiene toda la configuración de la aplicación
@@ -10,12 +28,18 @@ type Config struct {
 	Server   ServerConfig
 	Database DatabaseConfig
 	Log      LogConfig
+	Auth     AuthConfig
+	Mail     MailConfig
+	Security SecurityConfig
+	Content  ContentConfig
+	Media    MediaConfig
 }
 
 // ServerConfig configuración del servidor
 type ServerConfig struct {
-	Port    string
-	GinMode string
+	Port            string
+	GinMode         string
+	ShutdownTimeout time.Duration // tiempo máximo para terminar las peticiones en curso al apagar
 }
 
 // DatabaseConfig configuración de la base de datos
@@ -39,12 +63,91 @@ type LogConfig struct {
 	Level string
 }
 
+// AuthConfig configuración de autenticación
+type AuthConfig struct {
+	JWTSecret       string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	BcryptCost      int
+	SessionTTL      time.Duration
+	SessionSweep    time.Duration
+
+	RequireVerifiedEmail bool
+	PasswordResetTTL     time.Duration
+	EmailVerificationTTL time.Duration
+
+	TOTPIssuer            string
+	TOTPEncryptionKey     string
+	TwoFactorChallengeTTL time.Duration
+
+	RegistrationMode string // "open" o "invite"
+
+	ImpersonationTTL time.Duration
+}
+
+// SecurityConfig configuración de la protección contra fuerza bruta en el inicio de sesión
+type SecurityConfig struct {
+	MaxFailedLogins      int           // fallos por cuenta antes del bloqueo
+	MaxFailedLoginsPerIP int           // fallos por IP antes del bloqueo
+	FailureWindow        time.Duration // los fallos más antiguos que esta ventana no cuentan
+	LockoutDuration      time.Duration
+	BackoffBase          time.Duration // espera tras el primer fallo, se duplica con cada fallo
+	BackoffMax           time.Duration
+}
+
+// ContentConfig configuración de la gestión de contenido
+type ContentConfig struct {
+	MaxRevisions   int           // revisiones conservadas por post, 0 para no limitar
+	RevisionMaxAge time.Duration // antigüedad máxima de las revisiones, 0 para no limitar
+	RevisionSweep  time.Duration
+
+	PublishInterval  time.Duration // frecuencia con la que se publican los posts programados
+	PublishBatchSize int
+
+	SearchLanguage string // configuración de búsqueda de texto de Postgres (ej: spanish, english, simple)
+
+	ViewFlushInterval time.Duration // frecuencia con la que se guardan las visitas acumuladas
+	ViewDedupWindow   time.Duration // ventana en la que se ignoran visitas repetidas, 0 para no deduplicar
+
+	TrashRetention time.Duration // tiempo que se conserva el contenido en la papelera, 0 para no purgar
+	TrashSweep     time.Duration
+}
+
+// MediaConfig configuración de la biblioteca de medios
+type MediaConfig struct {
+	Driver        string // "local" o "s3"
+	LocalPath     string // directorio de los archivos locales
+	BaseURL       string // URL pública de los archivos locales
+	MaxUploadSize int64  // bytes
+
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3PathStyle bool   // necesario para MinIO y la mayoría de servicios compatibles
+	S3PublicURL string // vacío para usar la URL del endpoint
+}
+
+// MailConfig configuración del envío de correos
+type MailConfig struct {
+	Driver       string // "outbox" o "smtp"
+	From         string
+	OutboxPath   string // vacío para escribir en stdout
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	LinkBaseURL  string // URL base de los enlaces incluidos en los correos
+}
+
 // Load carga la configuración desde variables de entorno
 func Load() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Port:    getEnv("PORT", "8080"),
-			GinMode: getEnv("GIN_MODE", "debug"),
+			Port:            getEnv("PORT", "8080"),
+			GinMode:         getEnv("GIN_MODE", "debug"),
+			ShutdownTimeout: getEnvDuration("SHUTDOWN_TIMEOUT", 15*time.Second),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -57,7 +160,104 @@ func Load() *Config {
 		Log: LogConfig{
 			Level: getEnv("LOG_LEVEL", "info"),
 		},
+		Auth: AuthConfig{
+			JWTSecret:       getEnv("JWT_SECRET", DefaultJWTSecret),
+			AccessTokenTTL:  getEnvDuration("JWT_ACCESS_TTL", 15*time.Minute),
+			RefreshTokenTTL: getEnvDuration("JWT_REFRESH_TTL", 7*24*time.Hour),
+			BcryptCost:      getEnvInt("BCRYPT_COST", 12),
+			SessionTTL:      getEnvDuration("SESSION_TTL", 24*time.Hour),
+			SessionSweep:    getEnvDuration("SESSION_SWEEP_INTERVAL", 10*time.Minute),
+
+			RequireVerifiedEmail: getEnvBool("REQUIRE_EMAIL_VERIFICATION", false),
+			PasswordResetTTL:     getEnvDuration("PASSWORD_RESET_TTL", time.Hour),
+			EmailVerificationTTL: getEnvDuration("EMAIL_VERIFICATION_TTL", 48*time.Hour),
+
+			TOTPIssuer:            getEnv("TOTP_ISSUER", "GoAsync"),
+			TOTPEncryptionKey:     getEnv("TOTP_ENCRYPTION_KEY", DefaultTOTPEncryptionKey),
+			TwoFactorChallengeTTL: getEnvDuration("TWO_FACTOR_CHALLENGE_TTL", 5*time.Minute),
+
+			RegistrationMode: getEnv("REGISTRATION_MODE", "open"),
+
+			ImpersonationTTL: getEnvDuration("IMPERSONATION_TTL", 15*time.Minute),
+		},
+		Security: SecurityConfig{
+			MaxFailedLogins:      getEnvInt("LOGIN_MAX_FAILURES", 5),
+			MaxFailedLoginsPerIP: getEnvInt("LOGIN_MAX_FAILURES_PER_IP", 20),
+			FailureWindow:        getEnvDuration("LOGIN_FAILURE_WINDOW", 15*time.Minute),
+			LockoutDuration:      getEnvDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+			BackoffBase:          getEnvDuration("LOGIN_BACKOFF_BASE", time.Second),
+			BackoffMax:           getEnvDuration("LOGIN_BACKOFF_MAX", 30*time.Second),
+		},
+		Content: ContentConfig{
+			MaxRevisions:   getEnvInt("POST_REVISIONS_MAX", 50),
+			RevisionMaxAge: getEnvDuration("POST_REVISIONS_MAX_AGE", 0),
+			RevisionSweep:  getEnvDuration("POST_REVISIONS_SWEEP_INTERVAL", time.Hour),
+
+			PublishInterval:  getEnvDuration("PUBLISH_INTERVAL", 30*time.Second),
+			PublishBatchSize: getEnvInt("PUBLISH_BATCH_SIZE", 100),
+
+			SearchLanguage: getEnv("SEARCH_LANGUAGE", "spanish"),
+
+			ViewFlushInterval: getEnvDuration("VIEWS_FLUSH_INTERVAL", 10*time.Second),
+			ViewDedupWindow:   getEnvDuration("VIEWS_DEDUP_WINDOW", 30*time.Minute),
+
+			TrashRetention: getEnvDuration("TRASH_RETENTION", 30*24*time.Hour),
+			TrashSweep:     getEnvDuration("TRASH_SWEEP_INTERVAL", time.Hour),
+		},
+		Media: MediaConfig{
+			Driver:        getEnv("MEDIA_DRIVER", "local"),
+			LocalPath:     getEnv("MEDIA_LOCAL_PATH", "./uploads"),
+			BaseURL:       getEnv("MEDIA_BASE_URL", "http://localhost:8080/uploads"),
+			MaxUploadSize: int64(getEnvInt("MEDIA_MAX_UPLOAD_MB", 10)) << 20,
+
+			S3Endpoint:  getEnv("S3_ENDPOINT", "http://localhost:9000"),
+			S3Region:    getEnv("S3_REGION", "us-east-1"),
+			S3Bucket:    getEnv("S3_BUCKET", "goasync"),
+			S3AccessKey: getEnv("S3_ACCESS_KEY", ""),
+			S3SecretKey: getEnv("S3_SECRET_KEY", ""),
+			S3PathStyle: getEnvBool("S3_PATH_STYLE", true),
+			S3PublicURL: getEnv("S3_PUBLIC_URL", ""),
+		},
+		Mail: MailConfig{
+			Driver:       getEnv("MAIL_DRIVER", "outbox"),
+			From:         getEnv("MAIL_FROM", "no-reply@goasync.local"),
+			OutboxPath:   getEnv("MAIL_OUTBOX_PATH", ""),
+			SMTPHost:     getEnv("SMTP_HOST", "localhost"),
+			SMTPPort:     getEnv("SMTP_PORT", "587"),
+			SMTPUsername: getEnv("SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+			LinkBaseURL:  getEnv("APP_BASE_URL", "http://localhost:8080"),
+		},
+	}
+}
+
+// IsDevelopment indica si el servidor se ejecuta en desarrollo (GIN_MODE distinto de release)
+func (c *Config) IsDevelopment() bool {
+	return c.Server.GinMode != "release"
+}
+
+// Validate verifica la configuración antes de arrancar el servidor. Fuera de desarrollo exige
+// un JWT_SECRET y una TOTP_ENCRYPTION_KEY distintos de los de ejemplo y con la longitud mínima.
+func (c *Config) Validate() error {
+	if c.IsDevelopment() {
+		return nil
+	}
+
+	if c.Auth.JWTSecret == DefaultJWTSecret {
+		return fmt.Errorf("JWT_SECRET no puede ser el valor por defecto fuera de desarrollo")
+	}
+	if len(c.Auth.JWTSecret) < MinJWTSecretLength {
+		return fmt.Errorf("JWT_SECRET debe tener al menos %d bytes fuera de desarrollo", MinJWTSecretLength)
+	}
+
+	if c.Auth.TOTPEncryptionKey == DefaultTOTPEncryptionKey {
+		return fmt.Errorf("TOTP_ENCRYPTION_KEY no puede ser el valor por defecto fuera de desarrollo")
+	}
+	if len(c.Auth.TOTPEncryptionKey) < MinTOTPEncryptionKeyLength {
+		return fmt.Errorf("TOTP_ENCRYPTION_KEY debe tener al menos %d bytes fuera de desarrollo", MinTOTPEncryptionKeyLength)
 	}
+
+	return nil
 }
 
 // getEnv obtiene una variable de entorno o retorna un valor por defecto
@@ -67,3 +267,33 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvDuration obtiene una duración de una variable de entorno o retorna un valor por defecto
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+// getEnvInt obtiene un entero de una variable de entorno o retorna un valor por defecto
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
+
+// getEnvBool obtiene un booleano de una variable de entorno o retorna un valor por defecto
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/internal/services"
+	"github.com/alan.bermudez/goasync/pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// AccountHandler maneja las peticiones HTTP de recuperación de cuenta y verificación de email
+type AccountHandler struct {
+	accountService *services.AccountService
+	statsService   *services.StatsService
+	logger         *logrus.Logger
+}
+
+// NewAccountHandler crea una nueva instancia del handler de cuentas
+func NewAccountHandler(accountService *services.AccountService, statsService *services.StatsService, logger *logrus.Logger) *AccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+		statsService:   statsService,
+		logger:         logger,
+	}
+}
+
+// ForgotPassword envía un enlace para restablecer la contraseña. Siempre responde
+// lo mismo para no revelar si el email está registrado.
+func (h *AccountHandler) ForgotPassword(c *gin.Context) {
+	var req models.PasswordResetRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Datos de entrada inválidos",
+		})
+		return
+	}
+
+	user, err := h.accountService.RequestPasswordReset(req.Email)
+	if err != nil {
+		h.logger.Errorf("Error solicitando restablecimiento de contraseña: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	if user != nil {
+		// Crear log de actividad
+		h.statsService.CreateActivityLog(
+			&user.ID,
+			"password_reset_requested",
+			"user",
+			&user.ID,
+			map[string]interface{}{
+				"username": user.Username,
+			},
+			requestInfo(c),
+		)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Si el email está registrado recibirás un enlace para restablecer tu contraseña",
+	})
+}
+
+// ResetPassword restablece la contraseña con un token de un solo uso
+func (h *AccountHandler) ResetPassword(c *gin.Context) {
+	var req models.PasswordResetConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Datos de entrada inválidos",
+		})
+		return
+	}
+
+	userID, err := h.accountService.ResetPassword(req)
+	if err != nil {
+		if err.Error() == "token inválido o expirado" ||
+			err.Error() == "la nueva contraseña debe tener al menos 6 caracteres" ||
+			err.Error() == "la contraseña es demasiado larga" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		h.logger.Errorf("Error restableciendo contraseña: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&userID,
+		"password_reset",
+		"user",
+		&userID,
+		map[string]interface{}{},
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Contraseña restablecida exitosamente. Inicia sesión nuevamente",
+	})
+}
+
+// VerifyEmail verifica el email de un usuario con un token de un solo uso
+func (h *AccountHandler) VerifyEmail(c *gin.Context) {
+	var req models.EmailVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Datos de entrada inválidos",
+		})
+		return
+	}
+
+	userID, err := h.accountService.VerifyEmail(req.Token)
+	if err != nil {
+		if err.Error() == "token inválido o expirado" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		h.logger.Errorf("Error verificando email: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&userID,
+		"email_verified",
+		"user",
+		&userID,
+		map[string]interface{}{},
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Email verificado exitosamente",
+	})
+}
+
+// ResendVerification reenvía el enlace de verificación al usuario autenticado
+func (h *AccountHandler) ResendVerification(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	err := h.accountService.RequestEmailVerification(userID)
+	if err != nil {
+		if err.Error() == "el email ya está verificado" {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		h.logger.Errorf("Error reenviando verificación de email: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Enlace de verificación enviado",
+	})
+}
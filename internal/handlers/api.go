@@ -1,43 +1,207 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
+	"net/url"
+	"strings"
+	"sync"
 
+	"github.com/alan.bermudez/goasync/internal/config"
 	"github.com/alan.bermudez/goasync/internal/services"
+	"github.com/alan.bermudez/goasync/pkg/auth"
+	"github.com/alan.bermudez/goasync/pkg/mailer"
 	"github.com/alan.bermudez/goasync/pkg/middleware"
+	"github.com/alan.bermudez/goasync/pkg/password"
+	"github.com/alan.bermudez/goasync/pkg/storage"
+	"github.com/alan.bermudez/goasync/pkg/totp"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
 
-// SetupRoutes configura todas las rutas de la API
-func SetupRoutes(r *gin.Engine, db *sql.DB, logger *logrus.Logger) {
+// SetupRoutes configura todas las rutas de la API. Los procesos en segundo plano
+// de los servicios se detienen cuando ctx se cancela; wg espera a que terminen.
+func SetupRoutes(ctx context.Context, wg *sync.WaitGroup, r *gin.Engine, db *sql.DB, cfg *config.Config, logger *logrus.Logger) {
+	// Gestor de tokens JWT
+	tokenManager := auth.NewTokenManager(cfg.Auth.JWTSecret, cfg.Auth.AccessTokenTTL, cfg.Auth.RefreshTokenTTL)
+
+	// Hasher de contraseñas
+	passwordHasher := password.NewHasher(cfg.Auth.BcryptCost)
+
+	// Envío de correos
+	mail := newMailer(cfg.Mail, logger)
+
+	// Almacenamiento de la biblioteca de medios
+	store := newStorage(cfg.Media, logger)
+
+	// Cifrado de los secretos TOTP guardados en la base de datos
+	totpCipher, err := totp.NewCipher(cfg.Auth.TOTPEncryptionKey)
+	if err != nil {
+		logger.Fatalf("Error configurando el cifrado de secretos TOTP: %v", err)
+	}
+
 	// Crear servicios
-	userService := services.NewUserService(db, logger)
-	postService := services.NewPostService(db, logger)
+	userService := services.NewUserService(db, passwordHasher, logger)
+	revisionService := services.NewPostRevisionService(db, cfg.Content, logger)
+	postService := services.NewPostService(db, revisionService, cfg.Content.SearchLanguage, logger)
 	categoryService := services.NewCategoryService(db, logger)
 	tagService := services.NewTagService(db, logger)
 	commentService := services.NewCommentService(db, logger)
 	statsService := services.NewStatsService(db, logger)
+	seriesService := services.NewSeriesService(db, postService, logger)
+	mediaService := services.NewMediaService(db, store, postService, cfg.Media.MaxUploadSize, logger)
+	exportService := services.NewExportService(postService, tagService, commentService, logger)
+	viewService := services.NewViewService(db, cfg.Content.ViewDedupWindow, logger)
+	trashService := services.NewTrashService(db, cfg.Content.TrashRetention, logger)
+	renderService := services.NewRenderService(db, logger)
+	sessionService := services.NewSessionService(db, cfg.Auth.SessionTTL, logger)
+	apiKeyService := services.NewAPIKeyService(db, logger)
+	twoFactorService := services.NewTwoFactorService(db, cfg.Auth.TOTPIssuer, totpCipher, logger)
+	throttleService := services.NewLoginThrottleService(db, cfg.Security, statsService, logger)
+	inviteService := services.NewInviteService(db, userService, cfg.Auth.RegistrationMode, logger)
+	impersonationService := services.NewImpersonationService(db, cfg.Auth.ImpersonationTTL, logger)
+	authService := services.NewAuthService(userService, sessionService, twoFactorService, throttleService, tokenManager,
+		cfg.Auth.RequireVerifiedEmail, cfg.Auth.TwoFactorChallengeTTL, logger)
+	accountService := services.NewAccountService(db, userService, sessionService, mail,
+		cfg.Auth.PasswordResetTTL, cfg.Auth.EmailVerificationTTL, cfg.Mail.LinkBaseURL, logger)
+
+	// Procesos en segundo plano
+	runInBackground(wg, func() { sessionService.StartSweeper(ctx, cfg.Auth.SessionSweep) })
+	runInBackground(wg, func() { throttleService.StartSweeper(ctx, cfg.Security.FailureWindow) })
+	runInBackground(wg, func() { revisionService.StartSweeper(ctx, cfg.Content.RevisionSweep) })
+	runInBackground(wg, func() { trashService.StartSweeper(ctx, cfg.Content.TrashSweep) })
+	runInBackground(wg, func() { viewService.Start(ctx, cfg.Content.ViewFlushInterval) })
+	runInBackground(wg, func() { renderService.Start(ctx) })
 
 	// Crear handlers
-	userHandler := NewUserHandler(userService, statsService, logger)
-	postHandler := NewPostHandler(postService, statsService, logger)
+	userHandler := NewUserHandler(userService, accountService, inviteService, throttleService, statsService, logger)
+	postHandler := NewPostHandler(postService, seriesService, mediaService, viewService, statsService, logger)
+	seriesHandler := NewSeriesHandler(seriesService, statsService, logger)
+	mediaHandler := NewMediaHandler(mediaService, statsService, logger)
+	revisionHandler := NewPostRevisionHandler(revisionService, postService, statsService, logger)
 	categoryHandler := NewCategoryHandler(categoryService, statsService, logger)
 	tagHandler := NewTagHandler(tagService, statsService, logger)
 	commentHandler := NewCommentHandler(commentService, statsService, logger)
 	statsHandler := NewStatsHandler(statsService, logger)
+	exportHandler := NewExportHandler(exportService, statsService, logger)
 	healthHandler := NewHealthHandler(db, logger)
+	authHandler := NewAuthHandler(authService, sessionService, statsService, logger)
+	sessionHandler := NewSessionHandler(sessionService, statsService, logger)
+	apiKeyHandler := NewAPIKeyHandler(apiKeyService, statsService, logger)
+	accountHandler := NewAccountHandler(accountService, statsService, logger)
+	twoFactorHandler := NewTwoFactorHandler(twoFactorService, userService, statsService, logger)
+	inviteHandler := NewInviteHandler(inviteService, statsService, logger)
+	impersonationHandler := NewImpersonationHandler(impersonationService, statsService, logger)
 
 	// Middleware global
 	r.Use(middleware.CORS())
 	r.Use(middleware.Logger(logger))
 
+	// Opciones de autenticación compartidas por las rutas que la exigen y las que la admiten
+	authOptions := middleware.AuthOptions{
+		Tokens:   tokenManager,
+		Sessions: sessionService,
+		APIKeys:  apiKeyService,
+
+		Impersonations: impersonationService,
+		OnAPIKeyUse: func(c *gin.Context, userID, keyID uuid.UUID) {
+			statsService.CreateActivityLog(
+				&userID,
+				"api_key_used",
+				"api_key",
+				&keyID,
+				map[string]interface{}{
+					"key_id": keyID.String(),
+					"method": c.Request.Method,
+					"path":   c.FullPath(),
+				},
+				requestInfo(c),
+			)
+		},
+	}
+
+	// Middleware de autenticación para rutas que modifican datos
+	requireAuth := middleware.Auth(authOptions)
+
+	// Middleware de autenticación para lecturas públicas que muestran más datos a ciertos usuarios
+	optionalAuth := middleware.OptionalAuth(authOptions)
+
+	// Middlewares que impiden modificar credenciales mientras se suplanta a un usuario o con una API key
+	denyImpersonation := middleware.DenyImpersonation()
+	denyAPIKey := middleware.DenyAPIKey()
+
+	// Middleware de autorización por rol según la política de acceso
+	can := func(resource, action string) gin.HandlerFunc {
+		return middleware.RequirePermission(accessPolicy, userService, resource, action)
+	}
+
 	// Grupo de rutas de la API
 	api := r.Group("/api/v1")
 	{
 		// Health check
 		api.GET("/health", healthHandler.HealthCheck)
 
+		// Rutas de autenticación
+		authRoutes := api.Group("/auth")
+		{
+			authRoutes.POST("/login", authHandler.Login)
+			authRoutes.POST("/refresh", authHandler.Refresh)
+			authRoutes.POST("/session", authHandler.LoginSession)
+			authRoutes.POST("/logout", requireAuth, denyAPIKey, authHandler.Logout)
+			authRoutes.POST("/password/forgot", accountHandler.ForgotPassword)
+			authRoutes.POST("/password/reset", accountHandler.ResetPassword)
+			authRoutes.POST("/email/verify", accountHandler.VerifyEmail)
+			authRoutes.POST("/email/resend", requireAuth, denyAPIKey, accountHandler.ResendVerification)
+			authRoutes.POST("/2fa/challenge", authHandler.CompleteTwoFactor)
+		}
+
+		// Rutas de autenticación de dos factores del usuario autenticado
+		twoFactor := api.Group("/auth/2fa", requireAuth, denyAPIKey)
+		{
+			twoFactor.GET("", twoFactorHandler.GetStatus)
+			twoFactor.POST("/enroll", denyImpersonation, twoFactorHandler.Enroll)
+			twoFactor.POST("/activate", denyImpersonation, twoFactorHandler.Activate)
+			twoFactor.POST("/disable", denyImpersonation, twoFactorHandler.Disable)
+			twoFactor.POST("/recovery-codes", denyImpersonation, twoFactorHandler.RegenerateRecoveryCodes)
+		}
+
+		// Rutas de configuración de roles (solo administradores)
+		roles := api.Group("/roles", requireAuth, can("roles", "manage"))
+		{
+			roles.GET("/two-factor", twoFactorHandler.GetRoleRequirements)
+			roles.PUT("/:role/two-factor", twoFactorHandler.SetRoleRequirement)
+		}
+
+		// Rutas de API keys (solo administradores)
+		apiKeys := api.Group("/api-keys", requireAuth, denyAPIKey, can("api_keys", "manage"))
+		{
+			apiKeys.GET("", apiKeyHandler.GetAPIKeys)
+			apiKeys.POST("", apiKeyHandler.CreateAPIKey)
+			apiKeys.DELETE("/:id", apiKeyHandler.RevokeAPIKey)
+		}
+
+		// Rutas de invitaciones (solo administradores)
+		invites := api.Group("/invites", requireAuth, can("invites", "manage"))
+		{
+			invites.GET("", inviteHandler.GetInvites)
+			invites.POST("", inviteHandler.CreateInvite)
+			invites.DELETE("/:id", inviteHandler.RevokeInvite)
+		}
+
+		// Rutas de export estático (solo administradores)
+		export := api.Group("/export", requireAuth, can("export", "create"))
+		{
+			export.GET("/static", exportHandler.ExportStatic)
+		}
+
+		// Rutas de suplantaciones (solo administradores)
+		impersonations := api.Group("/impersonations", requireAuth, denyImpersonation, denyAPIKey, can("users", "impersonate"))
+		{
+			impersonations.GET("", impersonationHandler.GetActiveImpersonations)
+			impersonations.DELETE("/:id", impersonationHandler.EndImpersonation)
+		}
+
 		// Rutas de usuarios
 		users := api.Group("/users")
 		{
@@ -48,34 +212,80 @@ func SetupRoutes(r *gin.Engine, db *sql.DB, logger *logrus.Logger) {
 			users.GET("/:id/stats", userHandler.GetUserStats)
 			users.GET("/:id/activity", userHandler.GetUserActivity)
 			users.POST("", userHandler.CreateUser)
-			users.PUT("/:id", userHandler.UpdateUser)
-			users.DELETE("/:id", userHandler.DeleteUser)
+			users.PUT("/:id", requireAuth, can("users", "update"), userHandler.UpdateUser)
+			users.POST("/:id/unlock", requireAuth, can("users", "unlock"), userHandler.UnlockUser)
+			users.PUT("/:id/role", requireAuth, can("users", "update_role"), userHandler.UpdateUserRole)
+			users.POST("/:id/impersonate", requireAuth, denyImpersonation, denyAPIKey, can("users", "impersonate"), impersonationHandler.StartImpersonation)
+			users.PUT("/:id/password", requireAuth, denyImpersonation, denyAPIKey, can("users", "change_password"), userHandler.ChangePassword)
+			users.DELETE("/:id", requireAuth, can("users", "delete"), userHandler.DeleteUser)
+			users.GET("/:id/sessions", requireAuth, can("users", "manage_sessions"), sessionHandler.GetUserSessions)
+			users.DELETE("/:id/sessions", requireAuth, can("users", "manage_sessions"), sessionHandler.RevokeAllSessions)
+			users.DELETE("/:id/sessions/:session_id", requireAuth, can("users", "manage_sessions"), sessionHandler.RevokeSession)
 		}
 
+		// Búsqueda de texto completo en posts publicados
+		api.GET("/search", postHandler.Search)
+
 		// Rutas de posts
 		posts := api.Group("/posts")
 		{
 			posts.GET("", postHandler.GetPosts)
 			posts.GET("/published", postHandler.GetPublishedPosts)
+			posts.GET("/trash", requireAuth, can("posts", "restore"), postHandler.GetTrash)
 			posts.GET("/:id", postHandler.GetPost)
 			posts.GET("/slug/:slug", postHandler.GetPostBySlug)
 			posts.GET("/:id/with-tags", postHandler.GetPostWithTags)
-			posts.POST("", postHandler.CreatePost)
-			posts.PUT("/:id", postHandler.UpdatePost)
-			posts.DELETE("/:id", postHandler.DeletePost)
+			posts.GET("/:id/related", postHandler.GetRelatedPosts)
+			posts.POST("", requireAuth, can("posts", "create"), postHandler.CreatePost)
+			posts.PUT("/:id", requireAuth, can("posts", "update"), postHandler.UpdatePost)
+			posts.DELETE("/:id", requireAuth, can("posts", "delete"), postHandler.DeletePost)
+			posts.POST("/:id/restore", requireAuth, can("posts", "restore"), postHandler.RestorePost)
+			posts.DELETE("/:id/purge", requireAuth, can("posts", "purge"), postHandler.PurgePost)
+			posts.PUT("/:id/schedule", requireAuth, can("posts", "update"), postHandler.SchedulePost)
+			posts.DELETE("/:id/schedule", requireAuth, can("posts", "update"), postHandler.UnschedulePost)
+			posts.GET("/:id/revisions", requireAuth, can("posts", "update"), revisionHandler.GetRevisions)
+			posts.GET("/:id/revisions/diff", requireAuth, can("posts", "update"), revisionHandler.DiffRevisions)
+			posts.GET("/:id/revisions/:rev", requireAuth, can("posts", "update"), revisionHandler.GetRevision)
+			posts.POST("/:id/revisions/:rev/restore", requireAuth, can("posts", "update"), revisionHandler.RestoreRevision)
 			posts.GET("/:id/comments", commentHandler.GetComments)
 		}
 
+		// Rutas de la biblioteca de medios
+		media := api.Group("/media")
+		{
+			media.GET("", requireAuth, can("media", "read"), mediaHandler.GetMedia)
+			media.GET("/:id", requireAuth, can("media", "read"), mediaHandler.GetMediaByID)
+			media.POST("", requireAuth, can("media", "upload"), mediaHandler.UploadMedia)
+			media.DELETE("/:id", requireAuth, can("media", "delete"), mediaHandler.DeleteMedia)
+		}
+
+		// Rutas de series
+		series := api.Group("/series")
+		{
+			series.GET("", seriesHandler.GetSeries)
+			series.GET("/:id", optionalAuth, seriesHandler.GetSeriesByID)
+			series.GET("/slug/:slug", optionalAuth, seriesHandler.GetSeriesBySlug)
+			series.POST("", requireAuth, can("series", "create"), seriesHandler.CreateSeries)
+			series.PUT("/:id", requireAuth, can("series", "update"), seriesHandler.UpdateSeries)
+			series.DELETE("/:id", requireAuth, can("series", "delete"), seriesHandler.DeleteSeries)
+			series.POST("/:id/posts", requireAuth, can("series", "update"), seriesHandler.AddPost)
+			series.DELETE("/:id/posts/:post_id", requireAuth, can("series", "update"), seriesHandler.RemovePost)
+			series.PUT("/:id/order", requireAuth, can("series", "update"), seriesHandler.ReorderPosts)
+		}
+
 		// Rutas de categorías
 		categories := api.Group("/categories")
 		{
 			categories.GET("", categoryHandler.GetCategories)
+			categories.GET("/trash", requireAuth, can("categories", "restore"), categoryHandler.GetTrash)
 			categories.GET("/:id", categoryHandler.GetCategory)
 			categories.GET("/slug/:slug", categoryHandler.GetCategoryBySlug)
 			categories.GET("/:id/with-posts", categoryHandler.GetCategoryWithPosts)
-			categories.POST("", categoryHandler.CreateCategory)
-			categories.PUT("/:id", categoryHandler.UpdateCategory)
-			categories.DELETE("/:id", categoryHandler.DeleteCategory)
+			categories.POST("", requireAuth, can("categories", "create"), categoryHandler.CreateCategory)
+			categories.PUT("/:id", requireAuth, can("categories", "update"), categoryHandler.UpdateCategory)
+			categories.DELETE("/:id", requireAuth, can("categories", "delete"), categoryHandler.DeleteCategory)
+			categories.POST("/:id/restore", requireAuth, can("categories", "restore"), categoryHandler.RestoreCategory)
+			categories.DELETE("/:id/purge", requireAuth, can("categories", "purge"), categoryHandler.PurgeCategory)
 		}
 
 		// Rutas de tags
@@ -83,27 +293,33 @@ func SetupRoutes(r *gin.Engine, db *sql.DB, logger *logrus.Logger) {
 		{
 			tags.GET("", tagHandler.GetTags)
 			tags.GET("/popular", tagHandler.GetPopularTags)
+			tags.GET("/trash", requireAuth, can("tags", "restore"), tagHandler.GetTrash)
 			tags.GET("/:id", tagHandler.GetTag)
 			tags.GET("/slug/:slug", tagHandler.GetTagBySlug)
 			tags.GET("/:id/with-posts", tagHandler.GetTagWithPosts)
-			tags.POST("", tagHandler.CreateTag)
-			tags.PUT("/:id", tagHandler.UpdateTag)
-			tags.DELETE("/:id", tagHandler.DeleteTag)
+			tags.POST("", requireAuth, can("tags", "create"), tagHandler.CreateTag)
+			tags.PUT("/:id", requireAuth, can("tags", "update"), tagHandler.UpdateTag)
+			tags.DELETE("/:id", requireAuth, can("tags", "delete"), tagHandler.DeleteTag)
+			tags.POST("/:id/restore", requireAuth, can("tags", "restore"), tagHandler.RestoreTag)
+			tags.DELETE("/:id/purge", requireAuth, can("tags", "purge"), tagHandler.PurgeTag)
 		}
 
 		// Rutas de comentarios
 		comments := api.Group("/comments")
 		{
 			comments.GET("", commentHandler.GetAllComments)
+			comments.GET("/trash", requireAuth, can("comments", "restore"), commentHandler.GetTrash)
 			comments.GET("/:id", commentHandler.GetComment)
-			comments.POST("", commentHandler.CreateComment)
-			comments.PUT("/:id", commentHandler.UpdateComment)
-			comments.DELETE("/:id", commentHandler.DeleteComment)
-			comments.PATCH("/:id/approve", commentHandler.ApproveComment)
+			comments.POST("", requireAuth, can("comments", "create"), commentHandler.CreateComment)
+			comments.PUT("/:id", requireAuth, can("comments", "update"), commentHandler.UpdateComment)
+			comments.DELETE("/:id", requireAuth, can("comments", "delete"), commentHandler.DeleteComment)
+			comments.POST("/:id/restore", requireAuth, can("comments", "restore"), commentHandler.RestoreComment)
+			comments.DELETE("/:id/purge", requireAuth, can("comments", "purge"), commentHandler.PurgeComment)
+			comments.PATCH("/:id/approve", requireAuth, can("comments", "approve"), commentHandler.ApproveComment)
 		}
 
-		// Rutas de estadísticas
-		stats := api.Group("/stats")
+		// Rutas de estadísticas (solo administradores y editores)
+		stats := api.Group("/stats", requireAuth, can("stats", "read"))
 		{
 			stats.GET("/database", statsHandler.GetDatabaseStats)
 			stats.GET("/activity", statsHandler.GetActivityLogs)
@@ -114,6 +330,11 @@ func SetupRoutes(r *gin.Engine, db *sql.DB, logger *logrus.Logger) {
 		}
 	}
 
+	// Archivos de la biblioteca de medios guardados en el directorio local
+	if _, ok := store.(*storage.LocalStorage); ok {
+		r.Static(localMediaPath(cfg.Media.BaseURL), cfg.Media.LocalPath)
+	}
+
 	// Ruta raíz
 	r.GET("/", func(c *gin.Context) {
 		c.JSON(200, gin.H{
@@ -123,3 +344,61 @@ func SetupRoutes(r *gin.Engine, db *sql.DB, logger *logrus.Logger) {
 		})
 	})
 }
+
+// newMailer crea el mailer configurado. Si el outbox no puede abrirse, escribe en stdout.
+func newMailer(cfg config.MailConfig, logger *logrus.Logger) mailer.Mailer {
+	if cfg.Driver == "smtp" {
+		return mailer.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From)
+	}
+
+	outbox, err := mailer.NewFileOutboxMailer(cfg.OutboxPath, cfg.From)
+	if err != nil {
+		logger.Errorf("Error abriendo outbox de correo, usando stdout: %v", err)
+		outbox, _ = mailer.NewFileOutboxMailer("", cfg.From)
+	}
+	return outbox
+}
+
+// runInBackground ejecuta run en una goroutine registrada en wg
+func runInBackground(wg *sync.WaitGroup, run func()) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		run()
+	}()
+}
+
+// newStorage crea el almacenamiento de medios configurado. Si el driver s3 no puede
+// configurarse el servidor no arranca, para no guardar archivos en disco por error.
+func newStorage(cfg config.MediaConfig, logger *logrus.Logger) storage.Storage {
+	if cfg.Driver == "s3" {
+		s3, err := storage.NewS3Storage(storage.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PathStyle: cfg.S3PathStyle,
+			PublicURL: cfg.S3PublicURL,
+		})
+		if err != nil {
+			logger.Fatalf("Error configurando almacenamiento S3: %v", err)
+		}
+		return s3
+	}
+
+	local, err := storage.NewLocalStorage(cfg.LocalPath, cfg.BaseURL)
+	if err != nil {
+		logger.Fatalf("Error abriendo almacenamiento local de medios: %v", err)
+	}
+	return local
+}
+
+// localMediaPath retorna la ruta de MEDIA_BASE_URL bajo la que se sirven los archivos locales
+func localMediaPath(baseURL string) string {
+	parsed, err := url.Parse(baseURL)
+	if err != nil || strings.Trim(parsed.Path, "/") == "" {
+		return "/uploads"
+	}
+	return "/" + strings.Trim(parsed.Path, "/")
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/internal/services"
+	"github.com/alan.bermudez/goasync/pkg/middleware"
+	"github.com/alan.bermudez/goasync/pkg/rbac"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// APIKeyHandler maneja las peticiones HTTP relacionadas con API keys
+type APIKeyHandler struct {
+	apiKeyService *services.APIKeyService
+	statsService  *services.StatsService
+	logger        *logrus.Logger
+}
+
+// NewAPIKeyHandler crea una nueva instancia del handler de API keys
+func NewAPIKeyHandler(apiKeyService *services.APIKeyService, statsService *services.StatsService, logger *logrus.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+		statsService:  statsService,
+		logger:        logger,
+	}
+}
+
+// GetAPIKeys lista las API keys, opcionalmente filtradas por usuario
+func (h *APIKeyHandler) GetAPIKeys(c *gin.Context) {
+	var userID uuid.UUID
+	if userIDStr := c.Query("user_id"); userIDStr != "" {
+		parsed, err := uuid.Parse(userIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "ID de usuario inválido",
+			})
+			return
+		}
+		userID = parsed
+	}
+
+	apiKeys, err := h.apiKeyService.GetAPIKeys(userID)
+	if err != nil {
+		h.logger.Errorf("Error obteniendo API keys: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"api_keys": apiKeys,
+	})
+}
+
+// CreateAPIKey emite una nueva API key. La key solo se muestra en esta respuesta.
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	actorID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	var req models.APIKeyCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.UserID == uuid.Nil || req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Datos de entrada inválidos",
+		})
+		return
+	}
+
+	key, apiKey, err := h.apiKeyService.CreateAPIKey(req, actorID)
+	if err != nil {
+		if err.Error() == "usuario no encontrado" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Usuario no encontrado",
+			})
+			return
+		}
+		if strings.HasPrefix(err.Error(), "scope inválido") || err.Error() == "se requiere al menos un scope" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":     err.Error(),
+				"resources": rbac.ScopeResources,
+			})
+			return
+		}
+		if err.Error() == "la fecha de expiración debe ser futura" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		h.logger.Errorf("Error creando API key: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&actorID,
+		"api_key_created",
+		"api_key",
+		&apiKey.ID,
+		map[string]interface{}{
+			"key_id":  apiKey.ID.String(),
+			"name":    apiKey.Name,
+			"user_id": apiKey.UserID.String(),
+			"scopes":  apiKey.Scopes,
+		},
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"api_key": apiKey,
+		"key":     key,
+		"message": "API key creada exitosamente. Guárdala ahora, no se volverá a mostrar",
+	})
+}
+
+// RevokeAPIKey revoca una API key
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	actorID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	keyIDStr := c.Param("id")
+	keyID, err := uuid.Parse(keyIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de API key inválido",
+		})
+		return
+	}
+
+	err = h.apiKeyService.RevokeAPIKey(keyID)
+	if err != nil {
+		if err.Error() == "API key no encontrada" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "API key no encontrada",
+			})
+			return
+		}
+		h.logger.Errorf("Error revocando API key: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&actorID,
+		"api_key_revoked",
+		"api_key",
+		&keyID,
+		map[string]interface{}{
+			"key_id": keyID.String(),
+		},
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "API key revocada exitosamente",
+	})
+}
@@ -0,0 +1,353 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/internal/services"
+	"github.com/alan.bermudez/goasync/pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// AuthHandler maneja las peticiones HTTP relacionadas con autenticación
+type AuthHandler struct {
+	authService    *services.AuthService
+	sessionService *services.SessionService
+	statsService   *services.StatsService
+	logger         *logrus.Logger
+}
+
+// NewAuthHandler crea una nueva instancia del handler de autenticación
+func NewAuthHandler(authService *services.AuthService, sessionService *services.SessionService, statsService *services.StatsService, logger *logrus.Logger) *AuthHandler {
+	return &AuthHandler{
+		authService:    authService,
+		sessionService: sessionService,
+		statsService:   statsService,
+		logger:         logger,
+	}
+}
+
+// Login autentica a un usuario y retorna sus tokens
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req models.LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Password == "" || (req.Username == "" && req.Email == "") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Datos de entrada inválidos",
+		})
+		return
+	}
+
+	user, tokens, challenge, err := h.authService.Login(req, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		var throttled *services.LoginThrottledError
+		if errors.As(err, &throttled) {
+			respondThrottled(c, throttled)
+			return
+		}
+		if err.Error() == "credenciales inválidas" || err.Error() == "usuario inactivo" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if err.Error() == "email no verificado" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Debes verificar tu email antes de iniciar sesión",
+			})
+			return
+		}
+		h.logger.Errorf("Error iniciando sesión: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	if challenge != nil {
+		h.respondChallenge(c, challenge)
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&user.ID,
+		"user_login",
+		"user",
+		&user.ID,
+		map[string]interface{}{
+			"username": user.Username,
+		},
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":   user,
+		"tokens": tokens,
+	})
+}
+
+// Refresh renueva los tokens a partir de un token de refresco
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Datos de entrada inválidos",
+		})
+		return
+	}
+
+	user, tokens, err := h.authService.Refresh(req.RefreshToken)
+	if err != nil {
+		if err.Error() == "token de refresco inválido" || err.Error() == "usuario inactivo" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if err.Error() == "email no verificado" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Debes verificar tu email antes de iniciar sesión",
+			})
+			return
+		}
+		h.logger.Errorf("Error renovando tokens: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":   user,
+		"tokens": tokens,
+	})
+}
+
+// LoginSession autentica a un usuario y crea una sesión de servidor revocable
+func (h *AuthHandler) LoginSession(c *gin.Context) {
+	var req models.LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Password == "" || (req.Username == "" && req.Email == "") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Datos de entrada inválidos",
+		})
+		return
+	}
+
+	user, token, session, challenge, err := h.authService.LoginWithSession(req, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		var throttled *services.LoginThrottledError
+		if errors.As(err, &throttled) {
+			respondThrottled(c, throttled)
+			return
+		}
+		if err.Error() == "credenciales inválidas" || err.Error() == "usuario inactivo" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if err.Error() == "email no verificado" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Debes verificar tu email antes de iniciar sesión",
+			})
+			return
+		}
+		h.logger.Errorf("Error creando sesión: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	if challenge != nil {
+		h.respondChallenge(c, challenge)
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&user.ID,
+		"user_login",
+		"user",
+		&user.ID,
+		map[string]interface{}{
+			"username":   user.Username,
+			"session_id": session.ID.String(),
+		},
+		requestInfo(c),
+	)
+
+	setSessionCookie(c, token, session)
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":          user,
+		"session":       session,
+		"session_token": token,
+	})
+}
+
+// CompleteTwoFactor resuelve un desafío de dos factores y completa el inicio de sesión
+func (h *AuthHandler) CompleteTwoFactor(c *gin.Context) {
+	var req models.TwoFactorChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.ChallengeToken == "" || (req.Code == "" && req.RecoveryCode == "") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Datos de entrada inválidos",
+		})
+		return
+	}
+
+	user, method, recoveryCodes, err := h.authService.CompleteTwoFactor(req, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		var throttled *services.LoginThrottledError
+		if errors.As(err, &throttled) {
+			respondThrottled(c, throttled)
+			return
+		}
+		if err.Error() == "desafío de dos factores inválido" ||
+			err.Error() == "código de verificación inválido" ||
+			err.Error() == "usuario inactivo" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		h.logger.Errorf("Error completando desafío de dos factores: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	details := map[string]interface{}{
+		"username":      user.Username,
+		"two_factor":    true,
+		"recovery_code": req.RecoveryCode != "",
+	}
+
+	response := gin.H{
+		"user": user,
+	}
+	if recoveryCodes != nil {
+		response["recovery_codes"] = recoveryCodes
+	}
+
+	if method == services.LoginMethodSession {
+		token, session, err := h.authService.StartSession(user.ID, c.ClientIP(), c.GetHeader("User-Agent"))
+		if err != nil {
+			h.logger.Errorf("Error creando sesión: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Error interno del servidor",
+			})
+			return
+		}
+		setSessionCookie(c, token, session)
+		details["session_id"] = session.ID.String()
+		response["session"] = session
+		response["session_token"] = token
+	} else {
+		tokens, err := h.authService.IssueTokens(user.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Error interno del servidor",
+			})
+			return
+		}
+		response["tokens"] = tokens
+	}
+
+	if recoveryCodes != nil {
+		// Crear log de actividad
+		h.statsService.CreateActivityLog(
+			&user.ID,
+			"two_factor_enabled",
+			"user",
+			&user.ID,
+			map[string]interface{}{
+				"username": user.Username,
+				"forced":   true,
+			},
+			requestInfo(c),
+		)
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&user.ID,
+		"user_login",
+		"user",
+		&user.ID,
+		details,
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Logout revoca la sesión de servidor usada en la petición
+func (h *AuthHandler) Logout(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	if sessionID, ok := middleware.GetSessionID(c); ok {
+		if err := h.sessionService.RevokeSession(userID, sessionID); err != nil && err.Error() != "sesión no encontrada" {
+			h.logger.Errorf("Error cerrando sesión: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Error interno del servidor",
+			})
+			return
+		}
+	}
+
+	// Eliminar la cookie de sesión
+	c.SetCookie(middleware.SessionCookieName, "", -1, "/", "", c.Request.TLS != nil, true)
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&userID,
+		"user_logout",
+		"user",
+		&userID,
+		map[string]interface{}{},
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sesión cerrada exitosamente",
+	})
+}
+
+// respondChallenge responde con un desafío de dos factores en lugar de las credenciales
+func (h *AuthHandler) respondChallenge(c *gin.Context, challenge *models.TwoFactorChallenge) {
+	c.JSON(http.StatusOK, gin.H{
+		"two_factor_required": true,
+		"challenge":           challenge,
+		"message":             "Se requiere un código de verificación de dos factores",
+	})
+}
+
+// setSessionCookie guarda el token de sesión en una cookie HttpOnly para clientes de navegador
+func setSessionCookie(c *gin.Context, token string, session *models.UserSession) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(middleware.SessionCookieName, token, int(time.Until(session.ExpiresAt).Seconds()),
+		"/", "", c.Request.TLS != nil, true)
+}
+
+// respondThrottled responde con un 429 cuando el inicio de sesión está limitado por intentos fallidos
+func respondThrottled(c *gin.Context, throttled *services.LoginThrottledError) {
+	retryAfter := int(throttled.RetryAfter.Seconds()) + 1
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       throttled.Error(),
+		"locked":      throttled.Locked,
+		"retry_after": retryAfter,
+	})
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondCacheable responde con un cuerpo JSON cacheable por clientes y proxies durante
+// maxAge. Incluye un ETag del contenido y responde 304 si coincide con If-None-Match.
+func respondCacheable(c *gin.Context, maxAge time.Duration, body gin.H) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+	c.Header("ETag", etag)
+
+	for _, candidate := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		if candidate = strings.TrimSpace(candidate); candidate == etag || candidate == "*" {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
+
+// setVersionETag añade el ETag de versión de un recurso, derivado de su updated_at. Los
+// clientes lo envían en If-Match al modificarlo para detectar ediciones concurrentes.
+func setVersionETag(c *gin.Context, updatedAt time.Time) {
+	c.Header("ETag", `"`+strconv.FormatInt(updatedAt.UnixMicro(), 36)+`"`)
+}
+
+// ifMatchVersions obtiene las versiones aceptadas por el header If-Match. Retorna nil si no
+// hay precondición (header ausente o "*"). Las ETags débiles nunca coinciden; si ninguna ETag
+// es una versión válida responde 412 y retorna false.
+func ifMatchVersions(c *gin.Context) ([]time.Time, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		return nil, true
+	}
+
+	versions := []time.Time{}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return nil, true
+		}
+		if len(candidate) < 2 || !strings.HasPrefix(candidate, `"`) || !strings.HasSuffix(candidate, `"`) {
+			continue
+		}
+
+		micros, err := strconv.ParseInt(candidate[1:len(candidate)-1], 36, 64)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, time.UnixMicro(micros))
+	}
+
+	if len(versions) == 0 {
+		respondPreconditionFailed(c)
+		return nil, false
+	}
+	return versions, true
+}
+
+// respondPreconditionFailed responde 412 cuando la versión de If-Match ya no es la actual
+func respondPreconditionFailed(c *gin.Context) {
+	c.JSON(http.StatusPreconditionFailed, gin.H{
+		"error": "El recurso fue modificado por otra petición",
+	})
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/alan.bermudez/goasync/internal/models"
 	"github.com/alan.bermudez/goasync/internal/services"
+	"github.com/alan.bermudez/goasync/pkg/middleware"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
@@ -68,6 +69,7 @@ func (h *CategoryHandler) GetCategory(c *gin.Context) {
 		return
 	}
 
+	setVersionETag(c, category.UpdatedAt)
 	c.JSON(http.StatusOK, gin.H{
 		"category": category,
 	})
@@ -98,6 +100,7 @@ func (h *CategoryHandler) GetCategoryBySlug(c *gin.Context) {
 		return
 	}
 
+	setVersionETag(c, category.UpdatedAt)
 	c.JSON(http.StatusOK, gin.H{
 		"category": category,
 	})
@@ -129,6 +132,7 @@ func (h *CategoryHandler) GetCategoryWithPosts(c *gin.Context) {
 		return
 	}
 
+	setVersionETag(c, category.UpdatedAt)
 	c.JSON(http.StatusOK, gin.H{
 		"category": category,
 	})
@@ -136,6 +140,15 @@ func (h *CategoryHandler) GetCategoryWithPosts(c *gin.Context) {
 
 // CreateCategory crea una nueva categoría
 func (h *CategoryHandler) CreateCategory(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
 	var req models.CategoryCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -159,9 +172,6 @@ func (h *CategoryHandler) CreateCategory(c *gin.Context) {
 		return
 	}
 
-	// TODO: Obtener el ID del usuario autenticado
-	userID := uuid.New() // En producción, esto vendría del contexto de autenticación
-
 	// Crear log de actividad
 	h.statsService.CreateActivityLog(
 		&userID,
@@ -172,8 +182,7 @@ func (h *CategoryHandler) CreateCategory(c *gin.Context) {
 			"name": category.Name,
 			"slug": category.Slug,
 		},
-		c.ClientIP(),
-		c.GetHeader("User-Agent"),
+		requestInfo(c),
 	)
 
 	c.JSON(http.StatusCreated, gin.H{
@@ -184,6 +193,15 @@ func (h *CategoryHandler) CreateCategory(c *gin.Context) {
 
 // UpdateCategory actualiza una categoría existente
 func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
 	categoryIDStr := c.Param("id")
 	categoryID, err := uuid.Parse(categoryIDStr)
 	if err != nil {
@@ -201,7 +219,12 @@ func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
 		return
 	}
 
-	category, err := h.categoryService.UpdateCategory(categoryID, req)
+	ifMatch, ok := ifMatchVersions(c)
+	if !ok {
+		return
+	}
+
+	category, err := h.categoryService.UpdateCategory(categoryID, req, ifMatch)
 	if err != nil {
 		if err.Error() == "categoría no encontrada" {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -209,6 +232,16 @@ func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
 			})
 			return
 		}
+		if err.Error() == "el slug de categoría ya existe" {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if err.Error() == "el recurso fue modificado por otra petición" {
+			respondPreconditionFailed(c)
+			return
+		}
 		h.logger.Errorf("Error actualizando categoría: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Error interno del servidor",
@@ -216,9 +249,6 @@ func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
 		return
 	}
 
-	// TODO: Obtener el ID del usuario autenticado
-	userID := uuid.New() // En producción, esto vendría del contexto de autenticación
-
 	// Crear log de actividad
 	h.statsService.CreateActivityLog(
 		&userID,
@@ -229,10 +259,10 @@ func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
 			"name": category.Name,
 			"slug": category.Slug,
 		},
-		c.ClientIP(),
-		c.GetHeader("User-Agent"),
+		requestInfo(c),
 	)
 
+	setVersionETag(c, category.UpdatedAt)
 	c.JSON(http.StatusOK, gin.H{
 		"category": category,
 		"message":  "Categoría actualizada exitosamente",
@@ -241,6 +271,15 @@ func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
 
 // DeleteCategory elimina una categoría
 func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
 	categoryIDStr := c.Param("id")
 	categoryID, err := uuid.Parse(categoryIDStr)
 	if err != nil {
@@ -271,9 +310,6 @@ func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
 		return
 	}
 
-	// TODO: Obtener el ID del usuario autenticado
-	userID := uuid.New() // En producción, esto vendría del contexto de autenticación
-
 	// Crear log de actividad
 	h.statsService.CreateActivityLog(
 		&userID,
@@ -283,11 +319,128 @@ func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
 		map[string]interface{}{
 			"category_id": categoryID.String(),
 		},
-		c.ClientIP(),
-		c.GetHeader("User-Agent"),
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Categoría movida a la papelera",
+	})
+}
+
+// GetTrash obtiene las categorías de la papelera
+func (h *CategoryHandler) GetTrash(c *gin.Context) {
+	categories, err := h.categoryService.GetDeletedCategories()
+	if err != nil {
+		h.logger.Errorf("Error obteniendo papelera de categories: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"categories": categories,
+	})
+}
+
+// RestoreCategory saca una categoría de la papelera
+func (h *CategoryHandler) RestoreCategory(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	categoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de categoría inválido",
+		})
+		return
+	}
+
+	category, err := h.categoryService.RestoreCategory(categoryID)
+	if err != nil {
+		if err.Error() == "categoría no encontrada en la papelera" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Categoría no encontrada en la papelera",
+			})
+			return
+		}
+		h.logger.Errorf("Error restaurando categoría: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&userID,
+		"category_restored",
+		"category",
+		&categoryID,
+		map[string]interface{}{
+			"name": category.Name,
+			"slug": category.Slug,
+		},
+		requestInfo(c),
+	)
+
+	setVersionETag(c, category.UpdatedAt)
+	c.JSON(http.StatusOK, gin.H{
+		"category": category,
+		"message":  "Categoría restaurada exitosamente",
+	})
+}
+
+// PurgeCategory elimina definitivamente una categoría de la papelera
+func (h *CategoryHandler) PurgeCategory(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	categoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de categoría inválido",
+		})
+		return
+	}
+
+	if err := h.categoryService.PurgeCategory(categoryID); err != nil {
+		if err.Error() == "categoría no encontrada en la papelera" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Categoría no encontrada en la papelera",
+			})
+			return
+		}
+		h.logger.Errorf("Error purgando categoría: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&userID,
+		"category_purged",
+		"category",
+		&categoryID,
+		map[string]interface{}{
+			"category_id": categoryID.String(),
+		},
+		requestInfo(c),
 	)
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Categoría eliminada exitosamente",
+		"message": "Categoría eliminada definitivamente",
 	})
 }
@@ -6,6 +6,9 @@ import (
 
 	"github.com/alan.bermudez/goasync/internal/models"
 	"github.com/alan.bermudez/goasync/internal/services"
+	"github.com/alan.bermudez/goasync/pkg/cursor"
+	"github.com/alan.bermudez/goasync/pkg/middleware"
+	"github.com/alan.bermudez/goasync/pkg/rbac"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
@@ -44,7 +47,7 @@ func (h *CommentHandler) GetComments(c *gin.Context) {
 	if page < 1 {
 		page = 1
 	}
-	if perPage < 1 || perPage > 10000 {
+	if perPage < 1 || perPage > cursor.MaxLimit {
 		perPage = 10
 	}
 
@@ -77,11 +80,16 @@ func (h *CommentHandler) GetAllComments(c *gin.Context) {
 	if page < 1 {
 		page = 1
 	}
-	if perPage < 1 || perPage > 10000 {
+	if perPage < 1 || perPage > cursor.MaxLimit {
 		perPage = 10
 	}
 
-	response, err := h.commentService.GetAllComments(page, perPage, approvedOnly)
+	pageCursor, ok := cursorPage(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.commentService.GetAllComments(page, perPage, approvedOnly, pageCursor)
 	if err != nil {
 		h.logger.Errorf("Error obteniendo comentarios: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -90,6 +98,14 @@ func (h *CommentHandler) GetAllComments(c *gin.Context) {
 		return
 	}
 
+	if pageCursor != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"comments":   response.Comments,
+			"pagination": cursorPagination(response.PerPage, response.NextCursor, response.PrevCursor),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"comments": response.Comments,
 		"pagination": gin.H{
@@ -127,6 +143,7 @@ func (h *CommentHandler) GetComment(c *gin.Context) {
 		return
 	}
 
+	setVersionETag(c, comment.UpdatedAt)
 	c.JSON(http.StatusOK, gin.H{
 		"comment": comment,
 	})
@@ -134,6 +151,15 @@ func (h *CommentHandler) GetComment(c *gin.Context) {
 
 // CreateComment crea un nuevo comentario
 func (h *CommentHandler) CreateComment(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	authorID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
 	var req models.CommentCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -142,9 +168,6 @@ func (h *CommentHandler) CreateComment(c *gin.Context) {
 		return
 	}
 
-	// TODO: Obtener el ID del usuario autenticado
-	authorID := uuid.New() // En producción, esto vendría del contexto de autenticación
-
 	comment, err := h.commentService.CreateComment(req, authorID)
 	if err != nil {
 		if err.Error() == "post no encontrado" {
@@ -182,8 +205,7 @@ func (h *CommentHandler) CreateComment(c *gin.Context) {
 			"post_id": comment.PostID.String(),
 			"content": comment.Content[:50] + "...", // Solo los primeros 50 caracteres
 		},
-		c.ClientIP(),
-		c.GetHeader("User-Agent"),
+		requestInfo(c),
 	)
 
 	c.JSON(http.StatusCreated, gin.H{
@@ -194,6 +216,15 @@ func (h *CommentHandler) CreateComment(c *gin.Context) {
 
 // UpdateComment actualiza un comentario existente
 func (h *CommentHandler) UpdateComment(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
 	commentIDStr := c.Param("id")
 	commentID, err := uuid.Parse(commentIDStr)
 	if err != nil {
@@ -211,14 +242,31 @@ func (h *CommentHandler) UpdateComment(c *gin.Context) {
 		return
 	}
 
-	comment, err := h.commentService.UpdateComment(commentID, req)
+	ifMatch, ok := ifMatchVersions(c)
+	if !ok {
+		return
+	}
+
+	role, _ := middleware.GetUserRole(c)
+	comment, err := h.commentService.UpdateComment(commentID, req, ifMatch, userID, role)
 	if err != nil {
+		if err.Error() == "no tienes permiso para modificar este comentario" || err.Error() == "no tienes permiso para aprobar comentarios" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":  err.Error(),
+				"reason": rbac.ReasonNotOwner,
+			})
+			return
+		}
 		if err.Error() == "comentario no encontrado" {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Comentario no encontrado",
 			})
 			return
 		}
+		if err.Error() == "el recurso fue modificado por otra petición" {
+			respondPreconditionFailed(c)
+			return
+		}
 		h.logger.Errorf("Error actualizando comentario: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Error interno del servidor",
@@ -226,9 +274,6 @@ func (h *CommentHandler) UpdateComment(c *gin.Context) {
 		return
 	}
 
-	// TODO: Obtener el ID del usuario autenticado
-	userID := uuid.New() // En producción, esto vendría del contexto de autenticación
-
 	// Crear log de actividad
 	h.statsService.CreateActivityLog(
 		&userID,
@@ -238,10 +283,10 @@ func (h *CommentHandler) UpdateComment(c *gin.Context) {
 		map[string]interface{}{
 			"post_id": comment.PostID.String(),
 		},
-		c.ClientIP(),
-		c.GetHeader("User-Agent"),
+		requestInfo(c),
 	)
 
+	setVersionETag(c, comment.UpdatedAt)
 	c.JSON(http.StatusOK, gin.H{
 		"comment": comment,
 		"message": "Comentario actualizado exitosamente",
@@ -250,6 +295,15 @@ func (h *CommentHandler) UpdateComment(c *gin.Context) {
 
 // DeleteComment elimina un comentario
 func (h *CommentHandler) DeleteComment(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
 	commentIDStr := c.Param("id")
 	commentID, err := uuid.Parse(commentIDStr)
 	if err != nil {
@@ -259,8 +313,16 @@ func (h *CommentHandler) DeleteComment(c *gin.Context) {
 		return
 	}
 
-	err = h.commentService.DeleteComment(commentID)
+	role, _ := middleware.GetUserRole(c)
+	err = h.commentService.DeleteComment(commentID, userID, role)
 	if err != nil {
+		if err.Error() == "no tienes permiso para eliminar este comentario" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":  err.Error(),
+				"reason": rbac.ReasonNotOwner,
+			})
+			return
+		}
 		if err.Error() == "comentario no encontrado" {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Comentario no encontrado",
@@ -274,9 +336,6 @@ func (h *CommentHandler) DeleteComment(c *gin.Context) {
 		return
 	}
 
-	// TODO: Obtener el ID del usuario autenticado
-	userID := uuid.New() // En producción, esto vendría del contexto de autenticación
-
 	// Crear log de actividad
 	h.statsService.CreateActivityLog(
 		&userID,
@@ -286,17 +345,25 @@ func (h *CommentHandler) DeleteComment(c *gin.Context) {
 		map[string]interface{}{
 			"comment_id": commentID.String(),
 		},
-		c.ClientIP(),
-		c.GetHeader("User-Agent"),
+		requestInfo(c),
 	)
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Comentario eliminado exitosamente",
+		"message": "Comentario movido a la papelera",
 	})
 }
 
 // ApproveComment aprueba un comentario
 func (h *CommentHandler) ApproveComment(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
 	commentIDStr := c.Param("id")
 	commentID, err := uuid.Parse(commentIDStr)
 	if err != nil {
@@ -306,7 +373,12 @@ func (h *CommentHandler) ApproveComment(c *gin.Context) {
 		return
 	}
 
-	err = h.commentService.ApproveComment(commentID)
+	ifMatch, ok := ifMatchVersions(c)
+	if !ok {
+		return
+	}
+
+	err = h.commentService.ApproveComment(commentID, ifMatch)
 	if err != nil {
 		if err.Error() == "comentario no encontrado" {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -314,6 +386,10 @@ func (h *CommentHandler) ApproveComment(c *gin.Context) {
 			})
 			return
 		}
+		if err.Error() == "el recurso fue modificado por otra petición" {
+			respondPreconditionFailed(c)
+			return
+		}
 		h.logger.Errorf("Error aprobando comentario: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Error interno del servidor",
@@ -321,9 +397,6 @@ func (h *CommentHandler) ApproveComment(c *gin.Context) {
 		return
 	}
 
-	// TODO: Obtener el ID del usuario autenticado
-	userID := uuid.New() // En producción, esto vendría del contexto de autenticación
-
 	// Crear log de actividad
 	h.statsService.CreateActivityLog(
 		&userID,
@@ -333,11 +406,164 @@ func (h *CommentHandler) ApproveComment(c *gin.Context) {
 		map[string]interface{}{
 			"comment_id": commentID.String(),
 		},
-		c.ClientIP(),
-		c.GetHeader("User-Agent"),
+		requestInfo(c),
 	)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Comentario aprobado exitosamente",
 	})
 }
+
+// GetTrash obtiene los comentarios de la papelera. Los usuarios que no son moderadores solo
+// ven sus propios comentarios.
+func (h *CommentHandler) GetTrash(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 10
+	}
+
+	authorID := userID
+	if role, _ := middleware.GetUserRole(c); rbac.IsModerator(role) {
+		authorID = uuid.Nil
+	}
+
+	response, err := h.commentService.GetDeletedComments(page, perPage, authorID)
+	if err != nil {
+		h.logger.Errorf("Error obteniendo papelera de comentarios: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"comments": response.Comments,
+		"pagination": gin.H{
+			"page":        response.Page,
+			"per_page":    response.PerPage,
+			"total":       response.Total,
+			"total_pages": response.TotalPages,
+		},
+	})
+}
+
+// RestoreComment saca un comentario de la papelera
+func (h *CommentHandler) RestoreComment(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	commentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de comentario inválido",
+		})
+		return
+	}
+
+	role, _ := middleware.GetUserRole(c)
+	comment, err := h.commentService.RestoreComment(commentID, userID, role)
+	if err != nil {
+		switch err.Error() {
+		case "no tienes permiso para restaurar este comentario":
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":  err.Error(),
+				"reason": rbac.ReasonNotOwner,
+			})
+		case "comentario no encontrado en la papelera":
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Comentario no encontrado en la papelera",
+			})
+		default:
+			h.logger.Errorf("Error restaurando comentario: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Error interno del servidor",
+			})
+		}
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&userID,
+		"comment_restored",
+		"comment",
+		&commentID,
+		map[string]interface{}{
+			"post_id": comment.PostID.String(),
+		},
+		requestInfo(c),
+	)
+
+	setVersionETag(c, comment.UpdatedAt)
+	c.JSON(http.StatusOK, gin.H{
+		"comment": comment,
+		"message": "Comentario restaurado exitosamente",
+	})
+}
+
+// PurgeComment elimina definitivamente un comentario de la papelera. Sus respuestas se
+// conservan y pasan a colgar del comentario padre.
+func (h *CommentHandler) PurgeComment(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	commentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de comentario inválido",
+		})
+		return
+	}
+
+	if err := h.commentService.PurgeComment(commentID); err != nil {
+		if err.Error() == "comentario no encontrado en la papelera" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Comentario no encontrado en la papelera",
+			})
+			return
+		}
+		h.logger.Errorf("Error purgando comentario: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&userID,
+		"comment_purged",
+		"comment",
+		&commentID,
+		map[string]interface{}{
+			"comment_id": commentID.String(),
+		},
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Comentario eliminado definitivamente",
+	})
+}
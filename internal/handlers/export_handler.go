@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/internal/services"
+	"github.com/alan.bermudez/goasync/pkg/archive"
+	"github.com/alan.bermudez/goasync/pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// ExportHandler maneja las peticiones HTTP del export estático
+type ExportHandler struct {
+	exportService *services.ExportService
+	statsService  *services.StatsService
+	logger        *logrus.Logger
+}
+
+// NewExportHandler crea una nueva instancia del handler de export
+func NewExportHandler(exportService *services.ExportService, statsService *services.StatsService, logger *logrus.Logger) *ExportHandler {
+	return &ExportHandler{
+		exportService: exportService,
+		statsService:  statsService,
+		logger:        logger,
+	}
+}
+
+// ExportStatic descarga los posts publicados como un tar.gz de archivos Markdown para Hugo o
+// Jekyll. El archivo se genera mientras se envía; si el export falla a mitad la respuesta se
+// corta sin completar el gzip, de modo que el cliente detecta el archivo incompleto.
+func (h *ExportHandler) ExportStatic(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	opts := models.ExportOptions{
+		Format: c.DefaultQuery("format", models.ExportFormatHugo),
+	}
+	if !services.ValidExportFormat(opts.Format) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Formato de export inválido (hugo o jekyll)",
+		})
+		return
+	}
+	if value := c.Query("comments"); value != "" {
+		comments, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "El parámetro comments debe ser true o false",
+			})
+			return
+		}
+		opts.Comments = comments
+	}
+
+	filename := fmt.Sprintf("goasync-%s-%s.tar.gz", opts.Format, time.Now().UTC().Format("20060102-150405"))
+	c.Header("Content-Type", "application/gzip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+
+	writer := archive.NewTarGz(c.Writer)
+	report, err := h.exportService.ExportStatic(c.Request.Context(), writer, opts)
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		h.logger.Errorf("Error generando export estático: %v", err)
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&userID,
+		"static_exported",
+		"export",
+		nil,
+		map[string]interface{}{
+			"format":   report.Format,
+			"posts":    report.Posts,
+			"comments": report.Comments,
+		},
+		requestInfo(c),
+	)
+}
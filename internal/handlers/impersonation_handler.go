@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/internal/services"
+	"github.com/alan.bermudez/goasync/pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// ImpersonationHandler maneja las peticiones HTTP relacionadas con la suplantación de usuarios
+type ImpersonationHandler struct {
+	impersonationService *services.ImpersonationService
+	statsService         *services.StatsService
+	logger               *logrus.Logger
+}
+
+// NewImpersonationHandler crea una nueva instancia del handler de suplantación
+func NewImpersonationHandler(impersonationService *services.ImpersonationService, statsService *services.StatsService, logger *logrus.Logger) *ImpersonationHandler {
+	return &ImpersonationHandler{
+		impersonationService: impersonationService,
+		statsService:         statsService,
+		logger:               logger,
+	}
+}
+
+// StartImpersonation emite un token de corta duración para actuar como el usuario indicado.
+// El token solo se muestra en esta respuesta.
+func (h *ImpersonationHandler) StartImpersonation(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	adminID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	userIDStr := c.Param("id")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de usuario inválido",
+		})
+		return
+	}
+
+	var req models.ImpersonationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Datos de entrada inválidos",
+			})
+			return
+		}
+	}
+
+	token, impersonation, err := h.impersonationService.StartImpersonation(adminID, userID, req.Reason,
+		c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		if err.Error() == "usuario no encontrado" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Usuario no encontrado",
+			})
+			return
+		}
+		if err.Error() == "no puedes suplantarte a ti mismo" ||
+			err.Error() == "no se puede suplantar a un administrador" ||
+			err.Error() == "el usuario está inactivo" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		h.logger.Errorf("Error iniciando suplantación: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&adminID,
+		"impersonation_started",
+		"user",
+		&userID,
+		map[string]interface{}{
+			"impersonation_id": impersonation.ID.String(),
+			"user_id":          userID.String(),
+			"reason":           impersonation.Reason,
+			"expires_at":       impersonation.ExpiresAt.Format(time.RFC3339),
+		},
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"impersonation": impersonation,
+		"token":         token,
+		"token_type":    "Impersonation",
+		"message":       "Suplantación iniciada. Usa el header Authorization: Impersonation <token>",
+	})
+}
+
+// GetActiveImpersonations lista las suplantaciones activas
+func (h *ImpersonationHandler) GetActiveImpersonations(c *gin.Context) {
+	impersonations, err := h.impersonationService.GetActiveImpersonations()
+	if err != nil {
+		h.logger.Errorf("Error obteniendo suplantaciones activas: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"impersonations": impersonations,
+	})
+}
+
+// EndImpersonation finaliza una suplantación activa
+func (h *ImpersonationHandler) EndImpersonation(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	adminID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	impersonationIDStr := c.Param("id")
+	impersonationID, err := uuid.Parse(impersonationIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de suplantación inválido",
+		})
+		return
+	}
+
+	userID, err := h.impersonationService.EndImpersonation(impersonationID)
+	if err != nil {
+		if err.Error() == "suplantación no encontrada" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Suplantación no encontrada",
+			})
+			return
+		}
+		h.logger.Errorf("Error finalizando suplantación: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&adminID,
+		"impersonation_ended",
+		"user",
+		&userID,
+		map[string]interface{}{
+			"impersonation_id": impersonationID.String(),
+			"user_id":          userID.String(),
+		},
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Suplantación finalizada exitosamente",
+	})
+}
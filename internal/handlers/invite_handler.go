@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/internal/services"
+	"github.com/alan.bermudez/goasync/pkg/middleware"
+	"github.com/alan.bermudez/goasync/pkg/rbac"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// InviteHandler maneja las peticiones HTTP relacionadas con invitaciones
+type InviteHandler struct {
+	inviteService *services.InviteService
+	statsService  *services.StatsService
+	logger        *logrus.Logger
+}
+
+// NewInviteHandler crea una nueva instancia del handler de invitaciones
+func NewInviteHandler(inviteService *services.InviteService, statsService *services.StatsService, logger *logrus.Logger) *InviteHandler {
+	return &InviteHandler{
+		inviteService: inviteService,
+		statsService:  statsService,
+		logger:        logger,
+	}
+}
+
+// GetInvites lista las invitaciones
+func (h *InviteHandler) GetInvites(c *gin.Context) {
+	invites, err := h.inviteService.GetInvites()
+	if err != nil {
+		h.logger.Errorf("Error obteniendo invitaciones: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invites":           invites,
+		"registration_mode": h.registrationMode(),
+	})
+}
+
+// CreateInvite emite un nuevo código de invitación. El código solo se muestra en esta respuesta.
+func (h *InviteHandler) CreateInvite(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	actorID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	var req models.InviteCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Datos de entrada inválidos",
+		})
+		return
+	}
+
+	code, invite, err := h.inviteService.CreateInvite(req, actorID)
+	if err != nil {
+		if err.Error() == "rol inválido" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Rol inválido",
+				"roles": rbac.Roles,
+			})
+			return
+		}
+		if err.Error() == "el número máximo de usos debe ser positivo" ||
+			err.Error() == "la fecha de expiración debe ser futura" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		h.logger.Errorf("Error creando invitación: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&actorID,
+		"invite_created",
+		"invite",
+		&invite.ID,
+		map[string]interface{}{
+			"invite_id": invite.ID.String(),
+			"role":      invite.Role,
+			"max_uses":  invite.MaxUses,
+			"email":     invite.Email,
+		},
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"invite":  invite,
+		"code":    code,
+		"message": "Invitación creada exitosamente. Guarda el código ahora, no se volverá a mostrar",
+	})
+}
+
+// RevokeInvite revoca una invitación
+func (h *InviteHandler) RevokeInvite(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	actorID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	inviteIDStr := c.Param("id")
+	inviteID, err := uuid.Parse(inviteIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de invitación inválido",
+		})
+		return
+	}
+
+	err = h.inviteService.RevokeInvite(inviteID)
+	if err != nil {
+		if err.Error() == "invitación no encontrada" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Invitación no encontrada",
+			})
+			return
+		}
+		h.logger.Errorf("Error revocando invitación: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&actorID,
+		"invite_revoked",
+		"invite",
+		&inviteID,
+		map[string]interface{}{
+			"invite_id": inviteID.String(),
+		},
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Invitación revocada exitosamente",
+	})
+}
+
+// registrationMode retorna el modo de registro configurado
+func (h *InviteHandler) registrationMode() string {
+	if h.inviteService.RequiresInvite() {
+		return services.RegistrationModeInvite
+	}
+	return services.RegistrationModeOpen
+}
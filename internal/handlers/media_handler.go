@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/internal/services"
+	"github.com/alan.bermudez/goasync/pkg/middleware"
+	"github.com/alan.bermudez/goasync/pkg/rbac"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// multipartOverhead es el margen sobre el tamaño máximo de archivo para las cabeceras y los
+// demás campos del formulario
+const multipartOverhead = 1 << 20
+
+// MediaHandler maneja las peticiones HTTP de la biblioteca de medios
+type MediaHandler struct {
+	mediaService *services.MediaService
+	statsService *services.StatsService
+	logger       *logrus.Logger
+}
+
+// NewMediaHandler crea una nueva instancia del handler de medios
+func NewMediaHandler(mediaService *services.MediaService, statsService *services.StatsService, logger *logrus.Logger) *MediaHandler {
+	return &MediaHandler{
+		mediaService: mediaService,
+		statsService: statsService,
+		logger:       logger,
+	}
+}
+
+// UploadMedia sube un archivo a la biblioteca. Espera un formulario multipart con el campo
+// file y opcionalmente alt_text y post_id.
+func (h *MediaHandler) UploadMedia(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	// Limitar el cuerpo antes de leer el formulario
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.mediaService.MaxUploadSize()+multipartOverhead)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.respondTooLarge(c)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Se requiere un archivo en el campo file",
+		})
+		return
+	}
+
+	var postID *uuid.UUID
+	if value := c.PostForm("post_id"); value != "" {
+		parsed, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "ID de post inválido",
+			})
+			return
+		}
+		postID = &parsed
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		h.logger.Errorf("Error abriendo archivo subido: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+	defer file.Close()
+
+	role, _ := middleware.GetUserRole(c)
+	media, err := h.mediaService.Upload(c.Request.Context(), file, fileHeader.Filename, c.PostForm("alt_text"), postID, userID, role)
+	if err != nil {
+		switch err.Error() {
+		case "el archivo supera el tamaño máximo":
+			h.respondTooLarge(c)
+		case "tipo de archivo no permitido":
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{
+				"error": err.Error(),
+			})
+		case "el archivo está vacío", "la imagen no es válida", "la imagen es demasiado grande":
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+		case "post no encontrado":
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Post no encontrado",
+			})
+		case "no tienes permiso para modificar este post":
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":  err.Error(),
+				"reason": rbac.ReasonNotOwner,
+			})
+		default:
+			h.logger.Errorf("Error subiendo archivo: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Error interno del servidor",
+			})
+		}
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&userID,
+		"media_uploaded",
+		"media",
+		&media.ID,
+		map[string]interface{}{
+			"filename":     media.Filename,
+			"content_type": media.ContentType,
+			"size":         media.Size,
+		},
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"media":   media,
+		"message": "Archivo subido exitosamente",
+	})
+}
+
+// GetMedia lista los archivos de la biblioteca. Los autores solo ven los que subieron.
+func (h *MediaHandler) GetMedia(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+
+	filter := models.MediaFilter{
+		Page:        page,
+		PerPage:     perPage,
+		UploaderID:  userID,
+		ContentType: c.Query("type"),
+	}
+	if role, _ := middleware.GetUserRole(c); rbac.IsModerator(role) {
+		filter.UploaderID = uuid.Nil
+	}
+	if value := c.Query("post_id"); value != "" {
+		postID, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "ID de post inválido",
+			})
+			return
+		}
+		filter.PostID = postID
+	}
+
+	response, err := h.mediaService.GetAllMedia(filter)
+	if err != nil {
+		h.logger.Errorf("Error obteniendo archivos: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"media": response.Media,
+		"pagination": gin.H{
+			"page":        response.Page,
+			"per_page":    response.PerPage,
+			"total":       response.Total,
+			"total_pages": response.TotalPages,
+		},
+	})
+}
+
+// GetMediaByID obtiene un archivo de la biblioteca con sus variantes. Como en el listado, los
+// autores solo ven los que subieron; para los demás el archivo no existe.
+func (h *MediaHandler) GetMediaByID(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	mediaID, ok := mediaIDParam(c)
+	if !ok {
+		return
+	}
+
+	media, err := h.mediaService.GetMediaByID(mediaID)
+	if err != nil && err.Error() != "archivo no encontrado" {
+		h.logger.Errorf("Error obteniendo archivo: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	role, _ := middleware.GetUserRole(c)
+	if err != nil || (!rbac.IsModerator(role) && (media.UploaderID == nil || *media.UploaderID != userID)) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Archivo no encontrado",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"media": media,
+	})
+}
+
+// DeleteMedia elimina un archivo que no se usa en ningún post ni perfil
+func (h *MediaHandler) DeleteMedia(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	mediaID, ok := mediaIDParam(c)
+	if !ok {
+		return
+	}
+
+	role, _ := middleware.GetUserRole(c)
+	usage, err := h.mediaService.DeleteMedia(c.Request.Context(), mediaID, userID, role)
+	if err != nil {
+		switch err.Error() {
+		case "archivo no encontrado":
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Archivo no encontrado",
+			})
+		case "no tienes permiso para eliminar este archivo":
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":  err.Error(),
+				"reason": rbac.ReasonNotOwner,
+			})
+		case "el archivo está en uso":
+			c.JSON(http.StatusConflict, gin.H{
+				"error": err.Error(),
+				"usage": usage,
+			})
+		default:
+			h.logger.Errorf("Error eliminando archivo: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Error interno del servidor",
+			})
+		}
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&userID,
+		"media_deleted",
+		"media",
+		&mediaID,
+		map[string]interface{}{},
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Archivo eliminado exitosamente",
+	})
+}
+
+// respondTooLarge responde con 413 indicando el tamaño máximo permitido
+func (h *MediaHandler) respondTooLarge(c *gin.Context) {
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+		"error":     "El archivo supera el tamaño máximo",
+		"max_bytes": h.mediaService.MaxUploadSize(),
+	})
+}
+
+// mediaIDParam obtiene el ID de archivo de la ruta o responde con 400
+func mediaIDParam(c *gin.Context) (uuid.UUID, bool) {
+	mediaID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de archivo inválido",
+		})
+		return uuid.Nil, false
+	}
+
+	return mediaID, true
+}
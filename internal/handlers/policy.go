@@ -0,0 +1,65 @@
+package handlers
+
+import "github.com/alan.bermudez/goasync/pkg/rbac"
+
+// Conjuntos de roles usados en la política de acceso
+var (
+	allRoles      = rbac.Roles
+	contentRoles  = []string{rbac.RoleAdmin, rbac.RoleEditor, rbac.RoleAuthor}
+	commentRoles  = []string{rbac.RoleAdmin, rbac.RoleEditor, rbac.RoleAuthor, rbac.RoleCommenter}
+	moderateRoles = []string{rbac.RoleAdmin, rbac.RoleEditor}
+	adminRoles    = []string{rbac.RoleAdmin}
+)
+
+// accessPolicy define qué roles pueden realizar cada acción sobre cada recurso.
+// La propiedad de posts, comentarios y cuentas se verifica además en los handlers y servicios.
+var accessPolicy = rbac.Policy{
+	{Resource: "users", Action: "update"}:          allRoles,
+	{Resource: "users", Action: "change_password"}: allRoles,
+	{Resource: "users", Action: "manage_sessions"}: allRoles,
+	{Resource: "users", Action: "update_role"}:     adminRoles,
+	{Resource: "users", Action: "unlock"}:          adminRoles,
+	{Resource: "users", Action: "delete"}:          adminRoles,
+	{Resource: "users", Action: "impersonate"}:     adminRoles,
+
+	{Resource: "posts", Action: "create"}:  contentRoles,
+	{Resource: "posts", Action: "update"}:  contentRoles,
+	{Resource: "posts", Action: "delete"}:  contentRoles,
+	{Resource: "posts", Action: "restore"}: contentRoles,
+	{Resource: "posts", Action: "purge"}:   adminRoles,
+
+	{Resource: "media", Action: "upload"}: contentRoles,
+	{Resource: "media", Action: "read"}:   contentRoles,
+	{Resource: "media", Action: "delete"}: contentRoles,
+
+	{Resource: "series", Action: "create"}: contentRoles,
+	{Resource: "series", Action: "update"}: contentRoles,
+	{Resource: "series", Action: "delete"}: moderateRoles,
+
+	{Resource: "categories", Action: "create"}:  moderateRoles,
+	{Resource: "categories", Action: "update"}:  moderateRoles,
+	{Resource: "categories", Action: "delete"}:  moderateRoles,
+	{Resource: "categories", Action: "restore"}: moderateRoles,
+	{Resource: "categories", Action: "purge"}:   adminRoles,
+
+	{Resource: "tags", Action: "create"}:  contentRoles,
+	{Resource: "tags", Action: "update"}:  contentRoles,
+	{Resource: "tags", Action: "delete"}:  moderateRoles,
+	{Resource: "tags", Action: "restore"}: moderateRoles,
+	{Resource: "tags", Action: "purge"}:   adminRoles,
+
+	{Resource: "comments", Action: "create"}:  commentRoles,
+	{Resource: "comments", Action: "update"}:  commentRoles,
+	{Resource: "comments", Action: "delete"}:  commentRoles,
+	{Resource: "comments", Action: "approve"}: moderateRoles,
+	{Resource: "comments", Action: "restore"}: commentRoles,
+	{Resource: "comments", Action: "purge"}:   adminRoles,
+
+	{Resource: "stats", Action: "read"}: moderateRoles,
+
+	{Resource: "export", Action: "create"}: adminRoles,
+
+	{Resource: "api_keys", Action: "manage"}: adminRoles,
+	{Resource: "roles", Action: "manage"}:    adminRoles,
+	{Resource: "invites", Action: "manage"}:  adminRoles,
+}
@@ -3,9 +3,14 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/alan.bermudez/goasync/internal/models"
 	"github.com/alan.bermudez/goasync/internal/services"
+	"github.com/alan.bermudez/goasync/pkg/cursor"
+	"github.com/alan.bermudez/goasync/pkg/middleware"
+	"github.com/alan.bermudez/goasync/pkg/rbac"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
@@ -13,17 +18,23 @@ import (
 
 // PostHandler maneja las peticiones HTTP relacionadas con posts
 type PostHandler struct {
-	postService  *services.PostService
-	statsService *services.StatsService
-	logger       *logrus.Logger
+	postService   *services.PostService
+	seriesService *services.SeriesService
+	mediaService  *services.MediaService
+	viewService   *services.ViewService
+	statsService  *services.StatsService
+	logger        *logrus.Logger
 }
 
 // NewPostHandler crea una nueva instancia del handler de posts
-func NewPostHandler(postService *services.PostService, statsService *services.StatsService, logger *logrus.Logger) *PostHandler {
+func NewPostHandler(postService *services.PostService, seriesService *services.SeriesService, mediaService *services.MediaService, viewService *services.ViewService, statsService *services.StatsService, logger *logrus.Logger) *PostHandler {
 	return &PostHandler{
-		postService:  postService,
-		statsService: statsService,
-		logger:       logger,
+		postService:   postService,
+		seriesService: seriesService,
+		mediaService:  mediaService,
+		viewService:   viewService,
+		statsService:  statsService,
+		logger:        logger,
 	}
 }
 
@@ -37,7 +48,7 @@ func (h *PostHandler) GetPosts(c *gin.Context) {
 	if page < 1 {
 		page = 1
 	}
-	if perPage < 1 || perPage > 10000 {
+	if perPage < 1 || perPage > cursor.MaxLimit {
 		perPage = 10
 	}
 
@@ -56,6 +67,15 @@ func (h *PostHandler) GetPosts(c *gin.Context) {
 		filter.Search = search
 	}
 
+	filter.SearchMode = c.DefaultQuery("search_mode", models.SearchModeFullText)
+	if filter.SearchMode != models.SearchModeFullText && filter.SearchMode != models.SearchModeSimple {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Modo de búsqueda inválido",
+			"modes": []string{models.SearchModeFullText, models.SearchModeSimple},
+		})
+		return
+	}
+
 	if categoryIDStr := c.Query("category_id"); categoryIDStr != "" {
 		if categoryID, err := uuid.Parse(categoryIDStr); err == nil {
 			filter.CategoryID = categoryID
@@ -68,14 +88,32 @@ func (h *PostHandler) GetPosts(c *gin.Context) {
 		}
 	}
 
-	if tagIDStr := c.Query("tag_id"); tagIDStr != "" {
-		if tagID, err := uuid.Parse(tagIDStr); err == nil {
-			filter.TagID = tagID
-		}
+	// tag_id se conserva por compatibilidad; tags y exclude_tags aceptan IDs o slugs
+	filter.Tags = queryList(c, "tags", "tag_id")
+	filter.ExcludeTags = queryList(c, "exclude_tags")
+	filter.TagMatch = c.DefaultQuery("tag_match", models.TagMatchAny)
+	if filter.TagMatch != models.TagMatchAny && filter.TagMatch != models.TagMatchAll {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Modo de coincidencia de tags inválido",
+			"modes": []string{models.TagMatchAny, models.TagMatchAll},
+		})
+		return
+	}
+
+	pageCursor, ok := cursorPage(c)
+	if !ok {
+		return
 	}
+	filter.Cursor = pageCursor
 
 	response, err := h.postService.GetAllPosts(filter)
 	if err != nil {
+		if err.Error() == "la paginación por cursor no admite búsqueda de texto completo" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
 		h.logger.Errorf("Error obteniendo posts: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Error interno del servidor",
@@ -83,6 +121,14 @@ func (h *PostHandler) GetPosts(c *gin.Context) {
 		return
 	}
 
+	if pageCursor != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"posts":      response.Posts,
+			"pagination": cursorPagination(response.PerPage, response.NextCursor, response.PrevCursor),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"posts": response.Posts,
 		"pagination": gin.H{
@@ -94,6 +140,64 @@ func (h *PostHandler) GetPosts(c *gin.Context) {
 	})
 }
 
+// Search busca posts publicados por texto completo, ordenados por relevancia. Admite la
+// sintaxis de buscadores web: frases entre comillas, OR y exclusiones con -.
+func (h *PostHandler) Search(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Se requiere el parámetro q",
+		})
+		return
+	}
+
+	// Obtener parámetros de paginación
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "10"))
+
+	// Validar parámetros
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 10
+	}
+
+	filter := models.PostFilter{
+		Status:     "published",
+		Search:     query,
+		SearchMode: models.SearchModeFullText,
+		Page:       page,
+		PerPage:    perPage,
+	}
+
+	if categoryIDStr := c.Query("category_id"); categoryIDStr != "" {
+		if categoryID, err := uuid.Parse(categoryIDStr); err == nil {
+			filter.CategoryID = categoryID
+		}
+	}
+
+	response, err := h.postService.GetAllPosts(filter)
+	if err != nil {
+		h.logger.Errorf("Error buscando posts: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"query":   query,
+		"results": response.Posts,
+		"pagination": gin.H{
+			"page":        response.Page,
+			"per_page":    response.PerPage,
+			"total":       response.Total,
+			"total_pages": response.TotalPages,
+		},
+	})
+}
+
 // GetPublishedPosts obtiene solo posts publicados
 func (h *PostHandler) GetPublishedPosts(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
@@ -102,7 +206,7 @@ func (h *PostHandler) GetPublishedPosts(c *gin.Context) {
 	if page < 1 {
 		page = 1
 	}
-	if perPage < 1 || perPage > 10000 {
+	if perPage < 1 || perPage > cursor.MaxLimit {
 		perPage = 10
 	}
 
@@ -152,11 +256,91 @@ func (h *PostHandler) GetPost(c *gin.Context) {
 		return
 	}
 
+	h.attachSeries(post)
+	h.attachFeaturedImage(post)
+	h.recordView(c, post)
+
+	setVersionETag(c, post.UpdatedAt)
 	c.JSON(http.StatusOK, gin.H{
 		"post": post,
 	})
 }
 
+// relatedPostsMaxAge es el tiempo que clientes y proxies pueden cachear los posts relacionados
+const relatedPostsMaxAge = 5 * time.Minute
+
+// GetRelatedPosts obtiene los posts publicados relacionados con un post
+func (h *PostHandler) GetRelatedPosts(c *gin.Context) {
+	postIDStr := c.Param("id")
+	postID, err := uuid.Parse(postIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de post inválido",
+		})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	if limit < 1 || limit > 20 {
+		limit = 5
+	}
+	withText := c.DefaultQuery("text", "false") == "true"
+
+	related, err := h.postService.GetRelatedPosts(postID, limit, withText)
+	if err != nil {
+		if err.Error() == "post no encontrado" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Post no encontrado",
+			})
+			return
+		}
+		h.logger.Errorf("Error obteniendo posts relacionados: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	respondCacheable(c, relatedPostsMaxAge, gin.H{
+		"post_id": postID,
+		"related": related,
+	})
+}
+
+// attachSeries añade al post la navegación de su serie. Un error no impide responder con el post.
+func (h *PostHandler) attachSeries(post *models.Post) {
+	navigation, err := h.seriesService.GetNavigation(post.ID)
+	if err != nil {
+		h.logger.Errorf("Error obteniendo navegación de la serie: %v", err)
+		return
+	}
+	post.Series = navigation
+}
+
+// attachFeaturedImage añade al post su imagen destacada con las variantes. Un error no impide
+// responder con el post.
+func (h *PostHandler) attachFeaturedImage(post *models.Post) {
+	if post.FeaturedImageID == nil {
+		return
+	}
+
+	media, err := h.mediaService.GetMediaByID(*post.FeaturedImageID)
+	if err != nil {
+		h.logger.Errorf("Error obteniendo imagen destacada: %v", err)
+		return
+	}
+	post.FeaturedImage = media
+}
+
+// recordView cuenta una visita a un post publicado. El conteo es en memoria y se guarda en
+// segundo plano, por lo que no añade escrituras a la petición.
+func (h *PostHandler) recordView(c *gin.Context, post *models.Post) {
+	if post.Status != "published" {
+		return
+	}
+	h.viewService.Record(post.ID, c.ClientIP(), c.GetHeader("User-Agent"))
+}
+
 // GetPostBySlug obtiene un post por su slug
 func (h *PostHandler) GetPostBySlug(c *gin.Context) {
 	slug := c.Param("slug")
@@ -170,9 +354,7 @@ func (h *PostHandler) GetPostBySlug(c *gin.Context) {
 	post, err := h.postService.GetPostBySlug(slug)
 	if err != nil {
 		if err.Error() == "post no encontrado" {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "Post no encontrado",
-			})
+			h.redirectOldSlug(c, slug)
 			return
 		}
 		h.logger.Errorf("Error obteniendo post por slug: %v", err)
@@ -182,11 +364,42 @@ func (h *PostHandler) GetPostBySlug(c *gin.Context) {
 		return
 	}
 
+	h.attachSeries(post)
+	h.attachFeaturedImage(post)
+	h.recordView(c, post)
+
+	setVersionETag(c, post.UpdatedAt)
 	c.JSON(http.StatusOK, gin.H{
 		"post": post,
 	})
 }
 
+// redirectOldSlug responde con una redirección permanente al slug actual si slug es un slug
+// anterior de un post, o con 404 si no lo es
+func (h *PostHandler) redirectOldSlug(c *gin.Context, slug string) {
+	current, err := h.postService.ResolveSlugRedirect(slug)
+	if err != nil {
+		if err.Error() == "post no encontrado" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Post no encontrado",
+			})
+			return
+		}
+		h.logger.Errorf("Error resolviendo redirección de slug: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	location := strings.Replace(c.FullPath(), ":slug", current, 1)
+	c.Header("Location", location)
+	c.JSON(http.StatusMovedPermanently, gin.H{
+		"slug":     current,
+		"redirect": location,
+	})
+}
+
 // GetPostWithTags obtiene un post con sus tags
 func (h *PostHandler) GetPostWithTags(c *gin.Context) {
 	postIDStr := c.Param("id")
@@ -213,6 +426,9 @@ func (h *PostHandler) GetPostWithTags(c *gin.Context) {
 		return
 	}
 
+	h.attachFeaturedImage(post)
+
+	setVersionETag(c, post.UpdatedAt)
 	c.JSON(http.StatusOK, gin.H{
 		"post": post,
 	})
@@ -220,6 +436,15 @@ func (h *PostHandler) GetPostWithTags(c *gin.Context) {
 
 // CreatePost crea un nuevo post
 func (h *PostHandler) CreatePost(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	authorID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
 	var req models.PostCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -228,12 +453,15 @@ func (h *PostHandler) CreatePost(c *gin.Context) {
 		return
 	}
 
-	// TODO: Obtener el ID del usuario autenticado
-	// Por ahora, usamos un ID falso para pruebas
-	authorID := uuid.New() // En producción, esto vendría del contexto de autenticación
-
-	post, err := h.postService.CreatePost(req, authorID)
+	role, _ := middleware.GetUserRole(c)
+	post, err := h.postService.CreatePost(req, authorID, role)
 	if err != nil {
+		if isScheduleError(err) || isFeaturedImageError(err) || err.Error() == "tag no encontrado" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
 		h.logger.Errorf("Error creando post: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Error interno del servidor",
@@ -252,8 +480,7 @@ func (h *PostHandler) CreatePost(c *gin.Context) {
 			"slug":   post.Slug,
 			"status": post.Status,
 		},
-		c.ClientIP(),
-		c.GetHeader("User-Agent"),
+		requestInfo(c),
 	)
 
 	c.JSON(http.StatusCreated, gin.H{
@@ -264,6 +491,15 @@ func (h *PostHandler) CreatePost(c *gin.Context) {
 
 // UpdatePost actualiza un post existente
 func (h *PostHandler) UpdatePost(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	authorID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
 	postIDStr := c.Param("id")
 	postID, err := uuid.Parse(postIDStr)
 	if err != nil {
@@ -281,14 +517,43 @@ func (h *PostHandler) UpdatePost(c *gin.Context) {
 		return
 	}
 
-	post, err := h.postService.UpdatePost(postID, req)
+	ifMatch, ok := ifMatchVersions(c)
+	if !ok {
+		return
+	}
+
+	role, _ := middleware.GetUserRole(c)
+	post, err := h.postService.UpdatePost(postID, req, ifMatch, authorID, role)
 	if err != nil {
+		if err.Error() == "no tienes permiso para modificar este post" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":  err.Error(),
+				"reason": rbac.ReasonNotOwner,
+			})
+			return
+		}
 		if err.Error() == "post no encontrado" {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Post no encontrado",
 			})
 			return
 		}
+		if isScheduleError(err) || isFeaturedImageError(err) || err.Error() == "tag no encontrado" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if err.Error() == "el post ya está publicado" {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if err.Error() == "el recurso fue modificado por otra petición" {
+			respondPreconditionFailed(c)
+			return
+		}
 		h.logger.Errorf("Error actualizando post: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Error interno del servidor",
@@ -296,9 +561,6 @@ func (h *PostHandler) UpdatePost(c *gin.Context) {
 		return
 	}
 
-	// TODO: Obtener el ID del usuario autenticado
-	authorID := uuid.New() // En producción, esto vendría del contexto de autenticación
-
 	// Crear log de actividad
 	h.statsService.CreateActivityLog(
 		&authorID,
@@ -310,10 +572,10 @@ func (h *PostHandler) UpdatePost(c *gin.Context) {
 			"slug":   post.Slug,
 			"status": post.Status,
 		},
-		c.ClientIP(),
-		c.GetHeader("User-Agent"),
+		requestInfo(c),
 	)
 
+	setVersionETag(c, post.UpdatedAt)
 	c.JSON(http.StatusOK, gin.H{
 		"post":    post,
 		"message": "Post actualizado exitosamente",
@@ -322,6 +584,15 @@ func (h *PostHandler) UpdatePost(c *gin.Context) {
 
 // DeletePost elimina un post
 func (h *PostHandler) DeletePost(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	authorID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
 	postIDStr := c.Param("id")
 	postID, err := uuid.Parse(postIDStr)
 	if err != nil {
@@ -331,8 +602,16 @@ func (h *PostHandler) DeletePost(c *gin.Context) {
 		return
 	}
 
-	err = h.postService.DeletePost(postID)
+	role, _ := middleware.GetUserRole(c)
+	err = h.postService.DeletePost(postID, authorID, role)
 	if err != nil {
+		if err.Error() == "no tienes permiso para eliminar este post" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":  err.Error(),
+				"reason": rbac.ReasonNotOwner,
+			})
+			return
+		}
 		if err.Error() == "post no encontrado" {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": "Post no encontrado",
@@ -346,9 +625,6 @@ func (h *PostHandler) DeletePost(c *gin.Context) {
 		return
 	}
 
-	// TODO: Obtener el ID del usuario autenticado
-	authorID := uuid.New() // En producción, esto vendría del contexto de autenticación
-
 	// Crear log de actividad
 	h.statsService.CreateActivityLog(
 		&authorID,
@@ -358,11 +634,309 @@ func (h *PostHandler) DeletePost(c *gin.Context) {
 		map[string]interface{}{
 			"post_id": postID.String(),
 		},
-		c.ClientIP(),
-		c.GetHeader("User-Agent"),
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Post movido a la papelera",
+	})
+}
+
+// GetTrash obtiene los posts de la papelera. Los autores solo ven sus propios posts.
+func (h *PostHandler) GetTrash(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 10
+	}
+
+	authorID := userID
+	if role, _ := middleware.GetUserRole(c); rbac.IsModerator(role) {
+		authorID = uuid.Nil
+	}
+
+	response, err := h.postService.GetDeletedPosts(page, perPage, authorID)
+	if err != nil {
+		h.logger.Errorf("Error obteniendo papelera de posts: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"posts": response.Posts,
+		"pagination": gin.H{
+			"page":        response.Page,
+			"per_page":    response.PerPage,
+			"total":       response.Total,
+			"total_pages": response.TotalPages,
+		},
+	})
+}
+
+// RestorePost saca un post de la papelera
+func (h *PostHandler) RestorePost(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	postID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de post inválido",
+		})
+		return
+	}
+
+	role, _ := middleware.GetUserRole(c)
+	post, err := h.postService.RestorePost(postID, userID, role)
+	if err != nil {
+		switch err.Error() {
+		case "no tienes permiso para restaurar este post":
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":  err.Error(),
+				"reason": rbac.ReasonNotOwner,
+			})
+		case "post no encontrado en la papelera":
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Post no encontrado en la papelera",
+			})
+		default:
+			h.logger.Errorf("Error restaurando post: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Error interno del servidor",
+			})
+		}
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&userID,
+		"post_restored",
+		"post",
+		&postID,
+		map[string]interface{}{
+			"title": post.Title,
+		},
+		requestInfo(c),
+	)
+
+	setVersionETag(c, post.UpdatedAt)
+	c.JSON(http.StatusOK, gin.H{
+		"post":    post,
+		"message": "Post restaurado exitosamente",
+	})
+}
+
+// PurgePost elimina definitivamente un post de la papelera
+func (h *PostHandler) PurgePost(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	postID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de post inválido",
+		})
+		return
+	}
+
+	if err := h.postService.PurgePost(postID); err != nil {
+		if err.Error() == "post no encontrado en la papelera" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Post no encontrado en la papelera",
+			})
+			return
+		}
+		h.logger.Errorf("Error purgando post: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&userID,
+		"post_purged",
+		"post",
+		&postID,
+		map[string]interface{}{
+			"post_id": postID.String(),
+		},
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Post eliminado definitivamente",
+	})
+}
+
+// SchedulePost programa o reprograma la publicación de un post
+func (h *PostHandler) SchedulePost(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	actorID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	postIDStr := c.Param("id")
+	postID, err := uuid.Parse(postIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de post inválido",
+		})
+		return
+	}
+
+	var req models.PostScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Datos de entrada inválidos",
+		})
+		return
+	}
+
+	ifMatch, ok := ifMatchVersions(c)
+	if !ok {
+		return
+	}
+
+	role, _ := middleware.GetUserRole(c)
+	post, err := h.postService.SchedulePost(postID, req.PublishAt, ifMatch, actorID, role)
+	if err != nil {
+		h.respondScheduleError(c, err, "Error programando post")
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&actorID,
+		"post_scheduled",
+		"post",
+		&postID,
+		map[string]interface{}{
+			"title":        post.Title,
+			"scheduled_at": post.ScheduledAt.Format(time.RFC3339),
+		},
+		requestInfo(c),
 	)
 
+	setVersionETag(c, post.UpdatedAt)
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Post eliminado exitosamente",
+		"post":    post,
+		"message": "Publicación programada exitosamente",
 	})
 }
+
+// UnschedulePost cancela la publicación programada de un post y lo devuelve a borrador
+func (h *PostHandler) UnschedulePost(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	actorID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	postIDStr := c.Param("id")
+	postID, err := uuid.Parse(postIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de post inválido",
+		})
+		return
+	}
+
+	role, _ := middleware.GetUserRole(c)
+	post, err := h.postService.UnschedulePost(postID, actorID, role)
+	if err != nil {
+		h.respondScheduleError(c, err, "Error desprogramando post")
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&actorID,
+		"post_unscheduled",
+		"post",
+		&postID,
+		map[string]interface{}{
+			"title": post.Title,
+		},
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"post":    post,
+		"message": "Publicación programada cancelada",
+	})
+}
+
+// respondScheduleError responde con el código correspondiente a los errores de programación
+func (h *PostHandler) respondScheduleError(c *gin.Context, err error, logMessage string) {
+	switch {
+	case err.Error() == "no tienes permiso para modificar este post":
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":  err.Error(),
+			"reason": rbac.ReasonNotOwner,
+		})
+	case err.Error() == "post no encontrado":
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Post no encontrado",
+		})
+	case err.Error() == "el post ya está publicado" || err.Error() == "el post no está programado":
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+	case isScheduleError(err):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+	case err.Error() == "el recurso fue modificado por otra petición":
+		respondPreconditionFailed(c)
+	default:
+		h.logger.Errorf("%s: %v", logMessage, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+	}
+}
+
+// isScheduleError indica si el error proviene de una fecha de publicación programada inválida
+func isScheduleError(err error) bool {
+	return err.Error() == "se requiere publish_at para programar un post" ||
+		err.Error() == "la fecha de publicación debe ser futura"
+}
+
+// isFeaturedImageError indica si el error proviene de una imagen destacada inválida
+func isFeaturedImageError(err error) bool {
+	return err.Error() == "imagen destacada no encontrada" ||
+		err.Error() == "la imagen destacada debe ser una imagen"
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/internal/services"
+	"github.com/alan.bermudez/goasync/pkg/diff"
+	"github.com/alan.bermudez/goasync/pkg/middleware"
+	"github.com/alan.bermudez/goasync/pkg/rbac"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// PostRevisionHandler maneja las peticiones HTTP relacionadas con el historial de revisiones de posts
+type PostRevisionHandler struct {
+	revisionService *services.PostRevisionService
+	postService     *services.PostService
+	statsService    *services.StatsService
+	logger          *logrus.Logger
+}
+
+// NewPostRevisionHandler crea una nueva instancia del handler de revisiones
+func NewPostRevisionHandler(revisionService *services.PostRevisionService, postService *services.PostService, statsService *services.StatsService, logger *logrus.Logger) *PostRevisionHandler {
+	return &PostRevisionHandler{
+		revisionService: revisionService,
+		postService:     postService,
+		statsService:    statsService,
+		logger:          logger,
+	}
+}
+
+// GetRevisions lista las revisiones de un post
+func (h *PostRevisionHandler) GetRevisions(c *gin.Context) {
+	actorID, postID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	role, _ := middleware.GetUserRole(c)
+	revisions, err := h.revisionService.GetRevisions(postID, actorID, role)
+	if err != nil {
+		h.respondError(c, err, "Error obteniendo revisiones")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"revisions": revisions,
+	})
+}
+
+// GetRevision obtiene una revisión completa de un post
+func (h *PostRevisionHandler) GetRevision(c *gin.Context) {
+	actorID, postID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	number, err := strconv.Atoi(c.Param("rev"))
+	if err != nil || number < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Número de revisión inválido",
+		})
+		return
+	}
+
+	role, _ := middleware.GetUserRole(c)
+	revision, err := h.revisionService.GetRevision(postID, number, actorID, role)
+	if err != nil {
+		h.respondError(c, err, "Error obteniendo revisión")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"revision": revision,
+	})
+}
+
+// DiffRevisions compara dos revisiones de un post por líneas o por palabras
+func (h *PostRevisionHandler) DiffRevisions(c *gin.Context) {
+	actorID, postID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	from, errFrom := strconv.Atoi(c.Query("from"))
+	to, errTo := strconv.Atoi(c.Query("to"))
+	if errFrom != nil || errTo != nil || from < 1 || to < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Se requieren los números de revisión from y to",
+		})
+		return
+	}
+
+	mode := c.DefaultQuery("mode", diff.ModeLine)
+
+	role, _ := middleware.GetUserRole(c)
+	result, err := h.revisionService.DiffRevisions(postID, from, to, mode, actorID, role)
+	if err != nil {
+		if err.Error() == "modo de diff inválido" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Modo de diff inválido",
+				"modes": []string{diff.ModeLine, diff.ModeWord},
+			})
+			return
+		}
+		h.respondError(c, err, "Error comparando revisiones")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"diff": result,
+	})
+}
+
+// RestoreRevision restaura una revisión como la versión actual del post
+func (h *PostRevisionHandler) RestoreRevision(c *gin.Context) {
+	actorID, postID, ok := h.parseRequest(c)
+	if !ok {
+		return
+	}
+
+	number, err := strconv.Atoi(c.Param("rev"))
+	if err != nil || number < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Número de revisión inválido",
+		})
+		return
+	}
+
+	var req models.PostRevisionRestoreRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Datos de entrada inválidos",
+			})
+			return
+		}
+	}
+
+	role, _ := middleware.GetUserRole(c)
+	post, err := h.postService.RestoreRevision(postID, number, req.Note, actorID, role)
+	if err != nil {
+		if err.Error() == "no tienes permiso para modificar este post" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":  err.Error(),
+				"reason": rbac.ReasonNotOwner,
+			})
+			return
+		}
+		h.respondError(c, err, "Error restaurando revisión")
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&actorID,
+		"post_revision_restored",
+		"post",
+		&postID,
+		map[string]interface{}{
+			"title":    post.Title,
+			"revision": number,
+		},
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"post":    post,
+		"message": "Revisión restaurada exitosamente",
+	})
+}
+
+// parseRequest obtiene el usuario autenticado y el ID del post o responde con el error correspondiente
+func (h *PostRevisionHandler) parseRequest(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	// Obtener el ID del usuario autenticado
+	actorID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	postIDStr := c.Param("id")
+	postID, err := uuid.Parse(postIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de post inválido",
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return actorID, postID, true
+}
+
+// respondError responde con el código correspondiente a los errores comunes de revisiones
+func (h *PostRevisionHandler) respondError(c *gin.Context, err error, logMessage string) {
+	switch err.Error() {
+	case "no tienes permiso para ver las revisiones de este post":
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":  err.Error(),
+			"reason": rbac.ReasonNotOwner,
+		})
+	case "post no encontrado":
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Post no encontrado",
+		})
+	case "revisión no encontrada":
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Revisión no encontrada",
+		})
+	default:
+		h.logger.Errorf("%s: %v", logMessage, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alan.bermudez/goasync/pkg/cursor"
+	"github.com/gin-gonic/gin"
+)
+
+// cursorPage obtiene la paginación por cursor de los parámetros after, before y limit.
+// Retorna nil si la petición usa paginación por page/per_page. Si los parámetros son
+// inválidos responde con 400 y retorna ok = false.
+func cursorPage(c *gin.Context) (*cursor.Page, bool) {
+	after, before := c.Query("after"), c.Query("before")
+	limitStr, hasLimit := c.GetQuery("limit")
+	if after == "" && before == "" && !hasLimit {
+		return nil, true
+	}
+
+	limit, _ := strconv.Atoi(limitStr)
+	page, err := cursor.Parse(after, before, limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Cursor inválido",
+		})
+		return nil, false
+	}
+
+	return page, true
+}
+
+// cursorPagination construye el bloque de paginación de una respuesta en modo cursor
+func cursorPagination(limit int, next, prev string) gin.H {
+	pagination := gin.H{
+		"limit":       limit,
+		"next_cursor": nil,
+		"prev_cursor": nil,
+	}
+	if next != "" {
+		pagination["next_cursor"] = next
+	}
+	if prev != "" {
+		pagination["prev_cursor"] = prev
+	}
+
+	return pagination
+}
+
+// queryList obtiene los valores de uno o más parámetros que pueden repetirse
+// (?tags=a&tags=b) o separarse por comas (?tags=a,b)
+func queryList(c *gin.Context, keys ...string) []string {
+	var values []string
+	for _, key := range keys {
+		for _, raw := range c.QueryArray(key) {
+			for _, value := range strings.Split(raw, ",") {
+				if value = strings.TrimSpace(value); value != "" {
+					values = append(values, value)
+				}
+			}
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/pkg/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// requestInfo obtiene los datos de la petición que se registran en los logs de actividad,
+// incluyendo al administrador si la petición se realiza suplantando a un usuario
+func requestInfo(c *gin.Context) models.RequestInfo {
+	info := models.RequestInfo{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.GetHeader("User-Agent"),
+	}
+
+	if impersonatorID, ok := middleware.GetImpersonatorID(c); ok {
+		info.ImpersonatorID = &impersonatorID
+	}
+	if impersonationID, ok := middleware.GetImpersonationID(c); ok {
+		info.ImpersonationID = &impersonationID
+	}
+
+	return info
+}
@@ -0,0 +1,415 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/internal/services"
+	"github.com/alan.bermudez/goasync/pkg/middleware"
+	"github.com/alan.bermudez/goasync/pkg/rbac"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// SeriesHandler maneja las peticiones HTTP relacionadas con series de posts
+type SeriesHandler struct {
+	seriesService *services.SeriesService
+	statsService  *services.StatsService
+	logger        *logrus.Logger
+}
+
+// NewSeriesHandler crea una nueva instancia del handler de series
+func NewSeriesHandler(seriesService *services.SeriesService, statsService *services.StatsService, logger *logrus.Logger) *SeriesHandler {
+	return &SeriesHandler{
+		seriesService: seriesService,
+		statsService:  statsService,
+		logger:        logger,
+	}
+}
+
+// GetSeries obtiene todas las series
+func (h *SeriesHandler) GetSeries(c *gin.Context) {
+	series, err := h.seriesService.GetAllSeries()
+	if err != nil {
+		h.logger.Errorf("Error obteniendo series: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"series": series,
+	})
+}
+
+// GetSeriesByID obtiene una serie por su ID con sus partes. Las no publicadas solo se
+// incluyen para su creador o un moderador.
+func (h *SeriesHandler) GetSeriesByID(c *gin.Context) {
+	seriesID, ok := seriesIDParam(c)
+	if !ok {
+		return
+	}
+
+	series, err := h.seriesService.GetSeriesByID(seriesID, seriesViewer(c))
+	if err != nil {
+		h.respondSeriesError(c, err, "Error obteniendo serie")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"series": series,
+	})
+}
+
+// GetSeriesBySlug obtiene una serie por su slug con sus partes
+func (h *SeriesHandler) GetSeriesBySlug(c *gin.Context) {
+	series, err := h.seriesService.GetSeriesBySlug(c.Param("slug"), seriesViewer(c))
+	if err != nil {
+		h.respondSeriesError(c, err, "Error obteniendo serie por slug")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"series": series,
+	})
+}
+
+// CreateSeries crea una nueva serie
+func (h *SeriesHandler) CreateSeries(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	var req models.SeriesCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Title == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Datos de entrada inválidos",
+		})
+		return
+	}
+
+	series, err := h.seriesService.CreateSeries(req, userID)
+	if err != nil {
+		h.respondSeriesError(c, err, "Error creando serie")
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&userID,
+		"series_created",
+		"series",
+		&series.ID,
+		map[string]interface{}{
+			"title": series.Title,
+			"slug":  series.Slug,
+		},
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"series":  series,
+		"message": "Serie creada exitosamente",
+	})
+}
+
+// UpdateSeries actualiza una serie existente
+func (h *SeriesHandler) UpdateSeries(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	seriesID, ok := seriesIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.SeriesUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Datos de entrada inválidos",
+		})
+		return
+	}
+
+	role, _ := middleware.GetUserRole(c)
+	series, err := h.seriesService.UpdateSeries(seriesID, req, userID, role)
+	if err != nil {
+		h.respondSeriesError(c, err, "Error actualizando serie")
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&userID,
+		"series_updated",
+		"series",
+		&seriesID,
+		map[string]interface{}{
+			"title": series.Title,
+			"slug":  series.Slug,
+		},
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"series":  series,
+		"message": "Serie actualizada exitosamente",
+	})
+}
+
+// DeleteSeries elimina una serie sin eliminar sus posts
+func (h *SeriesHandler) DeleteSeries(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	seriesID, ok := seriesIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.seriesService.DeleteSeries(seriesID); err != nil {
+		h.respondSeriesError(c, err, "Error eliminando serie")
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&userID,
+		"series_deleted",
+		"series",
+		&seriesID,
+		map[string]interface{}{},
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Serie eliminada exitosamente",
+	})
+}
+
+// AddPost añade un post a una serie
+func (h *SeriesHandler) AddPost(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	seriesID, ok := seriesIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.SeriesAddPostRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.PostID == uuid.Nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Datos de entrada inválidos",
+		})
+		return
+	}
+
+	role, _ := middleware.GetUserRole(c)
+	series, err := h.seriesService.AddPost(seriesID, req, userID, role)
+	if err != nil {
+		h.respondSeriesError(c, err, "Error añadiendo post a la serie")
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&userID,
+		"series_post_added",
+		"series",
+		&seriesID,
+		map[string]interface{}{
+			"post_id":  req.PostID,
+			"position": req.Position,
+		},
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"series":  series,
+		"message": "Post añadido a la serie",
+	})
+}
+
+// RemovePost quita un post de una serie
+func (h *SeriesHandler) RemovePost(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	seriesID, ok := seriesIDParam(c)
+	if !ok {
+		return
+	}
+
+	postID, err := uuid.Parse(c.Param("post_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de post inválido",
+		})
+		return
+	}
+
+	role, _ := middleware.GetUserRole(c)
+	series, err := h.seriesService.RemovePost(seriesID, postID, userID, role)
+	if err != nil {
+		h.respondSeriesError(c, err, "Error quitando post de la serie")
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&userID,
+		"series_post_removed",
+		"series",
+		&seriesID,
+		map[string]interface{}{
+			"post_id": postID,
+		},
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"series":  series,
+		"message": "Post quitado de la serie",
+	})
+}
+
+// ReorderPosts reemplaza el orden de las partes de una serie
+func (h *SeriesHandler) ReorderPosts(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	seriesID, ok := seriesIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.SeriesReorderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Datos de entrada inválidos",
+		})
+		return
+	}
+
+	role, _ := middleware.GetUserRole(c)
+	series, err := h.seriesService.Reorder(seriesID, req.PostIDs, userID, role)
+	if err != nil {
+		h.respondSeriesError(c, err, "Error reordenando serie")
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&userID,
+		"series_reordered",
+		"series",
+		&seriesID,
+		map[string]interface{}{
+			"post_ids": req.PostIDs,
+		},
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"series":  series,
+		"message": "Orden de la serie actualizado",
+	})
+}
+
+// respondSeriesError responde con el código HTTP correspondiente a un error del servicio de series
+func (h *SeriesHandler) respondSeriesError(c *gin.Context, err error, logMessage string) {
+	switch err.Error() {
+	case "serie no encontrada":
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Serie no encontrada",
+		})
+	case "post no encontrado":
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Post no encontrado",
+		})
+	case "no tienes permiso para modificar este post", "no tienes permiso para modificar esta serie":
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":  err.Error(),
+			"reason": rbac.ReasonNotOwner,
+		})
+	case "el slug de la serie ya existe", "el post ya pertenece a una serie":
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+	case "el post no pertenece a la serie", "posición inválida",
+		"el nuevo orden debe incluir exactamente las partes actuales de la serie":
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+	default:
+		h.logger.Errorf("%s: %v", logMessage, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+	}
+}
+
+// seriesIDParam obtiene el ID de serie de la ruta o responde con 400
+func seriesIDParam(c *gin.Context) (uuid.UUID, bool) {
+	seriesID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de serie inválido",
+		})
+		return uuid.Nil, false
+	}
+
+	return seriesID, true
+}
+
+// seriesViewer obtiene el usuario autenticado que consulta una serie, o uuid.Nil si es anónimo.
+// Una API key sin scope de lectura de series se trata como anónima.
+func seriesViewer(c *gin.Context) uuid.UUID {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return uuid.Nil
+	}
+	if scopes, ok := middleware.GetAPIKeyScopes(c); ok && !rbac.HasScope(scopes, "series", "read") {
+		return uuid.Nil
+	}
+
+	return userID
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/alan.bermudez/goasync/internal/services"
+	"github.com/alan.bermudez/goasync/pkg/middleware"
+	"github.com/alan.bermudez/goasync/pkg/rbac"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// SessionHandler maneja las peticiones HTTP relacionadas con sesiones de usuario
+type SessionHandler struct {
+	sessionService *services.SessionService
+	statsService   *services.StatsService
+	logger         *logrus.Logger
+}
+
+// NewSessionHandler crea una nueva instancia del handler de sesiones
+func NewSessionHandler(sessionService *services.SessionService, statsService *services.StatsService, logger *logrus.Logger) *SessionHandler {
+	return &SessionHandler{
+		sessionService: sessionService,
+		statsService:   statsService,
+		logger:         logger,
+	}
+}
+
+// GetUserSessions obtiene las sesiones activas de un usuario
+func (h *SessionHandler) GetUserSessions(c *gin.Context) {
+	userID, ok := h.authorizeOwner(c)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.sessionService.GetUserSessions(userID)
+	if err != nil {
+		h.logger.Errorf("Error obteniendo sesiones: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	// Marcar la sesión actual
+	if currentID, ok := middleware.GetSessionID(c); ok {
+		for i := range sessions {
+			sessions[i].Current = sessions[i].ID == currentID
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sessions": sessions,
+	})
+}
+
+// RevokeSession revoca una sesión específica de un usuario
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	userID, ok := h.authorizeOwner(c)
+	if !ok {
+		return
+	}
+
+	sessionIDStr := c.Param("session_id")
+	sessionID, err := uuid.Parse(sessionIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de sesión inválido",
+		})
+		return
+	}
+
+	err = h.sessionService.RevokeSession(userID, sessionID)
+	if err != nil {
+		if err.Error() == "sesión no encontrada" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Sesión no encontrada",
+			})
+			return
+		}
+		h.logger.Errorf("Error revocando sesión: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	actorID, _ := middleware.GetUserID(c)
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&actorID,
+		"session_revoked",
+		"user",
+		&userID,
+		map[string]interface{}{
+			"session_id": sessionID.String(),
+		},
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sesión revocada exitosamente",
+	})
+}
+
+// RevokeAllSessions revoca todas las sesiones de un usuario
+func (h *SessionHandler) RevokeAllSessions(c *gin.Context) {
+	userID, ok := h.authorizeOwner(c)
+	if !ok {
+		return
+	}
+
+	revoked, err := h.sessionService.RevokeAllSessions(userID)
+	if err != nil {
+		h.logger.Errorf("Error revocando sesiones: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	actorID, _ := middleware.GetUserID(c)
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&actorID,
+		"sessions_revoked",
+		"user",
+		&userID,
+		map[string]interface{}{
+			"revoked": revoked,
+		},
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"revoked": revoked,
+		"message": "Sesiones revocadas exitosamente",
+	})
+}
+
+// authorizeOwner verifica que el usuario autenticado sea el dueño de las sesiones o un administrador
+func (h *SessionHandler) authorizeOwner(c *gin.Context) (uuid.UUID, bool) {
+	actorID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return uuid.Nil, false
+	}
+
+	userIDStr := c.Param("id")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de usuario inválido",
+		})
+		return uuid.Nil, false
+	}
+
+	if role, _ := middleware.GetUserRole(c); actorID != userID && role != rbac.RoleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":  "Solo puedes gestionar tus propias sesiones",
+			"reason": rbac.ReasonNotOwner,
+		})
+		return uuid.Nil, false
+	}
+
+	return userID, true
+}
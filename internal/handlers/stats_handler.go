@@ -7,6 +7,7 @@ import (
 
 	"github.com/alan.bermudez/goasync/internal/models"
 	"github.com/alan.bermudez/goasync/internal/services"
+	"github.com/alan.bermudez/goasync/pkg/cursor"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
@@ -50,7 +51,7 @@ func (h *StatsHandler) GetActivityLogs(c *gin.Context) {
 	if page < 1 {
 		page = 1
 	}
-	if perPage < 1 || perPage > 10000 {
+	if perPage < 1 || perPage > cursor.MaxLimit {
 		perPage = 10
 	}
 
@@ -86,7 +87,13 @@ func (h *StatsHandler) GetActivityLogs(c *gin.Context) {
 		}
 	}
 
-	logs, err := h.statsService.GetActivityLogs(filter)
+	pageCursor, ok := cursorPage(c)
+	if !ok {
+		return
+	}
+	filter.Cursor = pageCursor
+
+	response, err := h.statsService.GetActivityLogs(filter)
 	if err != nil {
 		h.logger.Errorf("Error obteniendo logs de actividad: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -95,8 +102,23 @@ func (h *StatsHandler) GetActivityLogs(c *gin.Context) {
 		return
 	}
 
+	if pageCursor != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"logs":       response.Logs,
+			"pagination": cursorPagination(pageCursor.Limit, response.NextCursor, response.PrevCursor),
+			"filters": gin.H{
+				"user_id":       filter.UserID,
+				"action":        filter.Action,
+				"resource_type": filter.ResourceType,
+				"start_date":    filter.StartDate,
+				"end_date":      filter.EndDate,
+			},
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"logs": logs,
+		"logs": response.Logs,
 		"filters": gin.H{
 			"page":          filter.Page,
 			"per_page":      filter.PerPage,
@@ -163,7 +185,12 @@ func (h *StatsHandler) GetUserActivity(c *gin.Context) {
 
 // GetPostStats obtiene estadísticas de posts
 func (h *StatsHandler) GetPostStats(c *gin.Context) {
-	stats, err := h.statsService.GetPostStats()
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if days < 1 || days > 90 {
+		days = 7
+	}
+
+	stats, err := h.statsService.GetPostStats(days)
 	if err != nil {
 		h.logger.Errorf("Error obteniendo estadísticas de posts: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
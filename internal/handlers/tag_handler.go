@@ -6,6 +6,7 @@ import (
 
 	"github.com/alan.bermudez/goasync/internal/models"
 	"github.com/alan.bermudez/goasync/internal/services"
+	"github.com/alan.bermudez/goasync/pkg/middleware"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
@@ -69,6 +70,7 @@ func (h *TagHandler) GetTag(c *gin.Context) {
 		return
 	}
 
+	setVersionETag(c, tag.UpdatedAt)
 	c.JSON(http.StatusOK, gin.H{
 		"tag": tag,
 	})
@@ -99,6 +101,7 @@ func (h *TagHandler) GetTagBySlug(c *gin.Context) {
 		return
 	}
 
+	setVersionETag(c, tag.UpdatedAt)
 	c.JSON(http.StatusOK, gin.H{
 		"tag": tag,
 	})
@@ -130,6 +133,7 @@ func (h *TagHandler) GetTagWithPosts(c *gin.Context) {
 		return
 	}
 
+	setVersionETag(c, tag.UpdatedAt)
 	c.JSON(http.StatusOK, gin.H{
 		"tag": tag,
 	})
@@ -158,6 +162,15 @@ func (h *TagHandler) GetPopularTags(c *gin.Context) {
 
 // CreateTag crea un nuevo tag
 func (h *TagHandler) CreateTag(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
 	var req models.TagCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -181,9 +194,6 @@ func (h *TagHandler) CreateTag(c *gin.Context) {
 		return
 	}
 
-	// TODO: Obtener el ID del usuario autenticado
-	userID := uuid.New() // En producción, esto vendría del contexto de autenticación
-
 	// Crear log de actividad
 	h.statsService.CreateActivityLog(
 		&userID,
@@ -194,8 +204,7 @@ func (h *TagHandler) CreateTag(c *gin.Context) {
 			"name": tag.Name,
 			"slug": tag.Slug,
 		},
-		c.ClientIP(),
-		c.GetHeader("User-Agent"),
+		requestInfo(c),
 	)
 
 	c.JSON(http.StatusCreated, gin.H{
@@ -206,6 +215,15 @@ func (h *TagHandler) CreateTag(c *gin.Context) {
 
 // UpdateTag actualiza un tag existente
 func (h *TagHandler) UpdateTag(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
 	tagIDStr := c.Param("id")
 	tagID, err := uuid.Parse(tagIDStr)
 	if err != nil {
@@ -223,7 +241,12 @@ func (h *TagHandler) UpdateTag(c *gin.Context) {
 		return
 	}
 
-	tag, err := h.tagService.UpdateTag(tagID, req)
+	ifMatch, ok := ifMatchVersions(c)
+	if !ok {
+		return
+	}
+
+	tag, err := h.tagService.UpdateTag(tagID, req, ifMatch)
 	if err != nil {
 		if err.Error() == "tag no encontrado" {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -231,6 +254,16 @@ func (h *TagHandler) UpdateTag(c *gin.Context) {
 			})
 			return
 		}
+		if err.Error() == "el slug de tag ya existe" {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if err.Error() == "el recurso fue modificado por otra petición" {
+			respondPreconditionFailed(c)
+			return
+		}
 		h.logger.Errorf("Error actualizando tag: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Error interno del servidor",
@@ -238,9 +271,6 @@ func (h *TagHandler) UpdateTag(c *gin.Context) {
 		return
 	}
 
-	// TODO: Obtener el ID del usuario autenticado
-	userID := uuid.New() // En producción, esto vendría del contexto de autenticación
-
 	// Crear log de actividad
 	h.statsService.CreateActivityLog(
 		&userID,
@@ -251,10 +281,10 @@ func (h *TagHandler) UpdateTag(c *gin.Context) {
 			"name": tag.Name,
 			"slug": tag.Slug,
 		},
-		c.ClientIP(),
-		c.GetHeader("User-Agent"),
+		requestInfo(c),
 	)
 
+	setVersionETag(c, tag.UpdatedAt)
 	c.JSON(http.StatusOK, gin.H{
 		"tag":     tag,
 		"message": "Tag actualizado exitosamente",
@@ -263,6 +293,15 @@ func (h *TagHandler) UpdateTag(c *gin.Context) {
 
 // DeleteTag elimina un tag
 func (h *TagHandler) DeleteTag(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
 	tagIDStr := c.Param("id")
 	tagID, err := uuid.Parse(tagIDStr)
 	if err != nil {
@@ -293,9 +332,6 @@ func (h *TagHandler) DeleteTag(c *gin.Context) {
 		return
 	}
 
-	// TODO: Obtener el ID del usuario autenticado
-	userID := uuid.New() // En producción, esto vendría del contexto de autenticación
-
 	// Crear log de actividad
 	h.statsService.CreateActivityLog(
 		&userID,
@@ -305,11 +341,128 @@ func (h *TagHandler) DeleteTag(c *gin.Context) {
 		map[string]interface{}{
 			"tag_id": tagID.String(),
 		},
-		c.ClientIP(),
-		c.GetHeader("User-Agent"),
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tag movido a la papelera",
+	})
+}
+
+// GetTrash obtiene los tags de la papelera
+func (h *TagHandler) GetTrash(c *gin.Context) {
+	tags, err := h.tagService.GetDeletedTags()
+	if err != nil {
+		h.logger.Errorf("Error obteniendo papelera de tags: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tags": tags,
+	})
+}
+
+// RestoreTag saca un tag de la papelera
+func (h *TagHandler) RestoreTag(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	tagID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de tag inválido",
+		})
+		return
+	}
+
+	tag, err := h.tagService.RestoreTag(tagID)
+	if err != nil {
+		if err.Error() == "tag no encontrado en la papelera" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Tag no encontrado en la papelera",
+			})
+			return
+		}
+		h.logger.Errorf("Error restaurando tag: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&userID,
+		"tag_restored",
+		"tag",
+		&tagID,
+		map[string]interface{}{
+			"name": tag.Name,
+			"slug": tag.Slug,
+		},
+		requestInfo(c),
+	)
+
+	setVersionETag(c, tag.UpdatedAt)
+	c.JSON(http.StatusOK, gin.H{
+		"tag":     tag,
+		"message": "Tag restaurado exitosamente",
+	})
+}
+
+// PurgeTag elimina definitivamente un tag de la papelera
+func (h *TagHandler) PurgeTag(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	tagID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de tag inválido",
+		})
+		return
+	}
+
+	if err := h.tagService.PurgeTag(tagID); err != nil {
+		if err.Error() == "tag no encontrado en la papelera" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Tag no encontrado en la papelera",
+			})
+			return
+		}
+		h.logger.Errorf("Error purgando tag: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&userID,
+		"tag_purged",
+		"tag",
+		&tagID,
+		map[string]interface{}{
+			"tag_id": tagID.String(),
+		},
+		requestInfo(c),
 	)
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Tag eliminado exitosamente",
+		"message": "Tag eliminado definitivamente",
 	})
 }
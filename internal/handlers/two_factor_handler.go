@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/internal/services"
+	"github.com/alan.bermudez/goasync/pkg/middleware"
+	"github.com/alan.bermudez/goasync/pkg/rbac"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// TwoFactorHandler maneja las peticiones HTTP relacionadas con la autenticación de dos factores
+type TwoFactorHandler struct {
+	twoFactorService *services.TwoFactorService
+	userService      *services.UserService
+	statsService     *services.StatsService
+	logger           *logrus.Logger
+}
+
+// NewTwoFactorHandler crea una nueva instancia del handler de dos factores
+func NewTwoFactorHandler(twoFactorService *services.TwoFactorService, userService *services.UserService, statsService *services.StatsService, logger *logrus.Logger) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: twoFactorService,
+		userService:      userService,
+		statsService:     statsService,
+		logger:           logger,
+	}
+}
+
+// GetStatus obtiene el estado de 2FA del usuario autenticado
+func (h *TwoFactorHandler) GetStatus(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.twoFactorService.GetStatus(user)
+	if err != nil {
+		h.logger.Errorf("Error obteniendo estado de 2FA: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"two_factor": status,
+	})
+}
+
+// Enroll inicia la inscripción de 2FA y retorna el secreto y la URI otpauth://
+func (h *TwoFactorHandler) Enroll(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	enrollment, err := h.twoFactorService.Enroll(user)
+	if err != nil {
+		if err.Error() == "la autenticación de dos factores ya está activada" {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		h.logger.Errorf("Error iniciando inscripción de 2FA: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"enrollment": enrollment,
+		"message":    "Registra el secreto en tu aplicación de autenticación y confirma con un código",
+	})
+}
+
+// Activate confirma la inscripción de 2FA con un código y retorna los códigos de recuperación
+func (h *TwoFactorHandler) Activate(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Datos de entrada inválidos",
+		})
+		return
+	}
+
+	recoveryCodes, err := h.twoFactorService.Activate(user.ID, req.Code)
+	if err != nil {
+		if err.Error() == "la autenticación de dos factores ya está activada" {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if err.Error() == "no hay una inscripción de dos factores pendiente" ||
+			err.Error() == "código de verificación inválido" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		h.logger.Errorf("Error activando 2FA: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&user.ID,
+		"two_factor_enabled",
+		"user",
+		&user.ID,
+		map[string]interface{}{
+			"username": user.Username,
+		},
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"recovery_codes": recoveryCodes,
+		"message":        "Autenticación de dos factores activada. Guarda los códigos de recuperación, no se volverán a mostrar",
+	})
+}
+
+// Disable desactiva 2FA tras verificar un código o un código de recuperación
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Code == "" && req.RecoveryCode == "") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Datos de entrada inválidos",
+		})
+		return
+	}
+
+	err := h.twoFactorService.Disable(user, req)
+	if err != nil {
+		if err.Error() == "la autenticación de dos factores es obligatoria para tu rol" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if err.Error() == "la autenticación de dos factores no está activada" ||
+			err.Error() == "código de verificación inválido" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		h.logger.Errorf("Error desactivando 2FA: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&user.ID,
+		"two_factor_disabled",
+		"user",
+		&user.ID,
+		map[string]interface{}{
+			"username": user.Username,
+		},
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Autenticación de dos factores desactivada",
+	})
+}
+
+// RegenerateRecoveryCodes genera nuevos códigos de recuperación e invalida los anteriores
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Code == "" && req.RecoveryCode == "") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Datos de entrada inválidos",
+		})
+		return
+	}
+
+	recoveryCodes, err := h.twoFactorService.RegenerateRecoveryCodes(user.ID, req)
+	if err != nil {
+		if err.Error() == "la autenticación de dos factores no está activada" ||
+			err.Error() == "código de verificación inválido" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		h.logger.Errorf("Error regenerando códigos de recuperación: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&user.ID,
+		"recovery_codes_regenerated",
+		"user",
+		&user.ID,
+		map[string]interface{}{
+			"username": user.Username,
+		},
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"recovery_codes": recoveryCodes,
+		"message":        "Códigos de recuperación regenerados",
+	})
+}
+
+// GetRoleRequirements obtiene qué roles exigen 2FA
+func (h *TwoFactorHandler) GetRoleRequirements(c *gin.Context) {
+	requirements, err := h.twoFactorService.GetRoleRequirements()
+	if err != nil {
+		h.logger.Errorf("Error obteniendo configuración de roles: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"require_two_factor": requirements,
+	})
+}
+
+// SetRoleRequirement exige o deja de exigir 2FA a un rol
+func (h *TwoFactorHandler) SetRoleRequirement(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	actorID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	role := c.Param("role")
+
+	var req models.RoleTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Required == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Datos de entrada inválidos",
+		})
+		return
+	}
+
+	err := h.twoFactorService.SetRoleRequirement(role, *req.Required)
+	if err != nil {
+		if err.Error() == "rol inválido" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Rol inválido",
+				"roles": rbac.Roles,
+			})
+			return
+		}
+		h.logger.Errorf("Error actualizando configuración del rol: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&actorID,
+		"role_two_factor_updated",
+		"role",
+		nil,
+		map[string]interface{}{
+			"role":     role,
+			"required": *req.Required,
+		},
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"role":               role,
+		"require_two_factor": *req.Required,
+		"message":            "Configuración de dos factores del rol actualizada",
+	})
+}
+
+// currentUser obtiene el usuario autenticado o responde con el error correspondiente
+func (h *TwoFactorHandler) currentUser(c *gin.Context) (*models.User, bool) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return nil, false
+	}
+
+	user, err := h.userService.GetUserByID(userID)
+	if err != nil {
+		if err.Error() == "usuario no encontrado" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Usuario no autenticado",
+			})
+			return nil, false
+		}
+		h.logger.Errorf("Error obteniendo usuario: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return nil, false
+	}
+
+	return user, true
+}
@@ -6,6 +6,9 @@ import (
 
 	"github.com/alan.bermudez/goasync/internal/models"
 	"github.com/alan.bermudez/goasync/internal/services"
+	"github.com/alan.bermudez/goasync/pkg/cursor"
+	"github.com/alan.bermudez/goasync/pkg/middleware"
+	"github.com/alan.bermudez/goasync/pkg/rbac"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
@@ -13,17 +16,23 @@ import (
 
 // UserHandler maneja las peticiones HTTP relacionadas con usuarios
 type UserHandler struct {
-	userService  *services.UserService
-	statsService *services.StatsService
-	logger       *logrus.Logger
+	userService     *services.UserService
+	accountService  *services.AccountService
+	inviteService   *services.InviteService
+	throttleService *services.LoginThrottleService
+	statsService    *services.StatsService
+	logger          *logrus.Logger
 }
 
 // NewUserHandler crea una nueva instancia del handler de usuarios
-func NewUserHandler(userService *services.UserService, statsService *services.StatsService, logger *logrus.Logger) *UserHandler {
+func NewUserHandler(userService *services.UserService, accountService *services.AccountService, inviteService *services.InviteService, throttleService *services.LoginThrottleService, statsService *services.StatsService, logger *logrus.Logger) *UserHandler {
 	return &UserHandler{
-		userService:  userService,
-		statsService: statsService,
-		logger:       logger,
+		userService:     userService,
+		accountService:  accountService,
+		inviteService:   inviteService,
+		throttleService: throttleService,
+		statsService:    statsService,
+		logger:          logger,
 	}
 }
 
@@ -37,7 +46,7 @@ func (h *UserHandler) GetUsers(c *gin.Context) {
 	if page < 1 {
 		page = 1
 	}
-	if perPage < 1 || perPage > 10000 {
+	if perPage < 1 || perPage > cursor.MaxLimit {
 		perPage = 10
 	}
 
@@ -173,7 +182,8 @@ func (h *UserHandler) GetAllUserStats(c *gin.Context) {
 	})
 }
 
-// CreateUser crea un nuevo usuario
+// CreateUser crea un nuevo usuario. Cuando el registro es solo por invitación
+// se requiere un código válido, que además determina el rol del usuario.
 func (h *UserHandler) CreateUser(c *gin.Context) {
 	var req models.UserCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -183,14 +193,32 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.CreateUser(req)
+	user, invite, err := h.inviteService.Register(req)
 	if err != nil {
+		if err.Error() == "se requiere un código de invitación" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if err.Error() == "código de invitación inválido o expirado" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
 		if err.Error() == "el nombre de usuario ya existe" || err.Error() == "el email ya existe" {
 			c.JSON(http.StatusConflict, gin.H{
 				"error": err.Error(),
 			})
 			return
 		}
+		if err.Error() == "la contraseña es demasiado larga" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
 		h.logger.Errorf("Error creando usuario: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Error interno del servidor",
@@ -208,10 +236,31 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 			"username": user.Username,
 			"email":    user.Email,
 		},
-		c.ClientIP(),
-		c.GetHeader("User-Agent"),
+		requestInfo(c),
 	)
 
+	if invite != nil {
+		// Crear log de actividad
+		h.statsService.CreateActivityLog(
+			&user.ID,
+			"invite_redeemed",
+			"invite",
+			&invite.ID,
+			map[string]interface{}{
+				"invite_id": invite.ID.String(),
+				"role":      invite.Role,
+				"uses":      invite.Uses,
+				"max_uses":  invite.MaxUses,
+			},
+			requestInfo(c),
+		)
+	}
+
+	// Enviar enlace de verificación de email
+	if err := h.accountService.SendEmailVerification(user); err != nil {
+		h.logger.Errorf("Error enviando verificación de email: %v", err)
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
 		"user":    user,
 		"message": "Usuario creado exitosamente",
@@ -220,6 +269,15 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 
 // UpdateUser actualiza un usuario existente
 func (h *UserHandler) UpdateUser(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	actorID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
 	userIDStr := c.Param("id")
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
@@ -229,6 +287,15 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	// Solo el propio usuario o un administrador pueden modificar la cuenta
+	if role, _ := middleware.GetUserRole(c); actorID != userID && role != rbac.RoleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":  "Solo puedes modificar tu propia cuenta",
+			"reason": rbac.ReasonNotOwner,
+		})
+		return
+	}
+
 	var req models.UserUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -254,15 +321,14 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 
 	// Crear log de actividad
 	h.statsService.CreateActivityLog(
-		&userID,
+		&actorID,
 		"user_updated",
 		"user",
 		&userID,
 		map[string]interface{}{
 			"username": user.Username,
 		},
-		c.ClientIP(),
-		c.GetHeader("User-Agent"),
+		requestInfo(c),
 	)
 
 	c.JSON(http.StatusOK, gin.H{
@@ -271,8 +337,231 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	})
 }
 
+// ChangePassword cambia la contraseña de un usuario
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	actorID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	userIDStr := c.Param("id")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de usuario inválido",
+		})
+		return
+	}
+
+	if actorID != userID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":  "Solo puedes cambiar tu propia contraseña",
+			"reason": rbac.ReasonNotOwner,
+		})
+		return
+	}
+
+	var req models.PasswordChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.OldPassword == "" || req.NewPassword == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Datos de entrada inválidos",
+		})
+		return
+	}
+
+	// Se conserva la sesión de la petición; las demás se revocan
+	sessionID, _ := middleware.GetSessionID(c)
+	err = h.accountService.ChangePassword(userID, sessionID, req)
+	if err != nil {
+		if err.Error() == "usuario no encontrado" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Usuario no encontrado",
+			})
+			return
+		}
+		if err.Error() == "la contraseña actual es incorrecta" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if err.Error() == "la nueva contraseña debe tener al menos 6 caracteres" || err.Error() == "la contraseña es demasiado larga" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		h.logger.Errorf("Error cambiando contraseña: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&actorID,
+		"password_changed",
+		"user",
+		&userID,
+		map[string]interface{}{
+			"user_id": userID.String(),
+		},
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Contraseña actualizada exitosamente",
+	})
+}
+
+// UpdateUserRole cambia el rol de un usuario
+func (h *UserHandler) UpdateUserRole(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	actorID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	userIDStr := c.Param("id")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de usuario inválido",
+		})
+		return
+	}
+
+	var req models.UserRoleUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Datos de entrada inválidos",
+		})
+		return
+	}
+
+	user, err := h.userService.UpdateUserRole(userID, req.Role)
+	if err != nil {
+		if err.Error() == "usuario no encontrado" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Usuario no encontrado",
+			})
+			return
+		}
+		if err.Error() == "rol inválido" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Rol inválido",
+				"roles": rbac.Roles,
+			})
+			return
+		}
+		h.logger.Errorf("Error actualizando rol de usuario: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&actorID,
+		"user_role_updated",
+		"user",
+		&userID,
+		map[string]interface{}{
+			"username": user.Username,
+			"role":     user.Role,
+		},
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":    user,
+		"message": "Rol actualizado exitosamente",
+	})
+}
+
+// UnlockUser desbloquea una cuenta bloqueada por intentos fallidos de inicio de sesión
+func (h *UserHandler) UnlockUser(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	actorID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
+	userIDStr := c.Param("id")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "ID de usuario inválido",
+		})
+		return
+	}
+
+	user, err := h.userService.GetUserByID(userID)
+	if err != nil {
+		if err.Error() == "usuario no encontrado" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Usuario no encontrado",
+			})
+			return
+		}
+		h.logger.Errorf("Error obteniendo usuario: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	wasLocked, err := h.throttleService.Unlock(userID)
+	if err != nil {
+		h.logger.Errorf("Error desbloqueando usuario: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Error interno del servidor",
+		})
+		return
+	}
+
+	// Crear log de actividad
+	h.statsService.CreateActivityLog(
+		&actorID,
+		"user_unlocked",
+		"user",
+		&userID,
+		map[string]interface{}{
+			"username":   user.Username,
+			"was_locked": wasLocked,
+		},
+		requestInfo(c),
+	)
+
+	c.JSON(http.StatusOK, gin.H{
+		"was_locked": wasLocked,
+		"message":    "Usuario desbloqueado exitosamente",
+	})
+}
+
 // DeleteUser elimina un usuario
 func (h *UserHandler) DeleteUser(c *gin.Context) {
+	// Obtener el ID del usuario autenticado
+	actorID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Usuario no autenticado",
+		})
+		return
+	}
+
 	userIDStr := c.Param("id")
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
@@ -299,15 +588,14 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 
 	// Crear log de actividad
 	h.statsService.CreateActivityLog(
-		nil,
+		&actorID,
 		"user_deleted",
 		"user",
 		&userID,
 		map[string]interface{}{
 			"user_id": userID.String(),
 		},
-		c.ClientIP(),
-		c.GetHeader("User-Agent"),
+		requestInfo(c),
 	)
 
 	c.JSON(http.StatusOK, gin.H{
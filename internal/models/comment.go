@@ -6,16 +6,19 @@ import (
 	"github.com/google/uuid"
 )
 
-// Comment representa un comentario en un post
+// Comment representa un comentario en un post. ContentHTML es Content renderizado como
+// Markdown a HTML sanitizado.
 type Comment struct {
-	ID         uuid.UUID  `json:"id" db:"id"`
-	PostID     uuid.UUID  `json:"post_id" db:"post_id"`
-	AuthorID   uuid.UUID  `json:"author_id" db:"author_id"`
-	ParentID   *uuid.UUID `json:"parent_id,omitempty" db:"parent_id"`
-	Content    string     `json:"content" db:"content"`
-	IsApproved bool       `json:"is_approved" db:"is_approved"`
-	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+	ID          uuid.UUID  `json:"id" db:"id"`
+	PostID      uuid.UUID  `json:"post_id" db:"post_id"`
+	AuthorID    uuid.UUID  `json:"author_id" db:"author_id"`
+	ParentID    *uuid.UUID `json:"parent_id,omitempty" db:"parent_id"`
+	Content     string     `json:"content" db:"content"`
+	ContentHTML string     `json:"content_html" db:"content_html"`
+	IsApproved  bool       `json:"is_approved" db:"is_approved"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
 
 	// Relaciones
 	Author  *User     `json:"author,omitempty"`
@@ -43,4 +46,8 @@ type CommentListResponse struct {
 	Page       int       `json:"page"`
 	PerPage    int       `json:"per_page"`
 	TotalPages int       `json:"total_pages"`
+
+	// Cursores de la página siguiente y anterior en modo cursor
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
 }
@@ -0,0 +1,23 @@
+package models
+
+// Formatos del export estático
+const (
+	ExportFormatHugo   = "hugo"
+	ExportFormatJekyll = "jekyll"
+)
+
+// ExportOptions representa las opciones del export estático
+type ExportOptions struct {
+	// Format es la estructura de directorios y el front matter: hugo o jekyll
+	Format string
+
+	// Comments exporta los comentarios aprobados de cada post como archivo de datos JSON
+	Comments bool
+}
+
+// ExportReport representa el resultado de un export estático
+type ExportReport struct {
+	Format   string `json:"format"`
+	Posts    int    `json:"posts"`
+	Comments int    `json:"comments"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Impersonation representa una sesión en la que un administrador actúa como otro usuario
+type Impersonation struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	AdminID   uuid.UUID  `json:"admin_id" db:"admin_id"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	TokenHash string     `json:"-" db:"token_hash"`
+	Reason    string     `json:"reason" db:"reason"`
+	IPAddress string     `json:"ip_address" db:"ip_address"`
+	UserAgent string     `json:"user_agent" db:"user_agent"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	EndedAt   *time.Time `json:"ended_at,omitempty" db:"ended_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+
+	// Relaciones
+	AdminUsername string `json:"admin_username,omitempty"`
+	Username      string `json:"username,omitempty"`
+}
+
+// ImpersonationRequest representa la solicitud para suplantar a un usuario
+type ImpersonationRequest struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tipos de elemento guardados en import_mappings
+const (
+	ImportTypeUser      = "user"
+	ImportTypeCommenter = "commenter"
+	ImportTypeCategory  = "category"
+	ImportTypeTag       = "tag"
+	ImportTypePost      = "post"
+	ImportTypeComment   = "comment"
+)
+
+// PostImportRequest representa un post importado desde otra plataforma. A diferencia de
+// PostCreateRequest conserva el slug y las fechas originales.
+type PostImportRequest struct {
+	PostCreateRequest
+
+	Slug        string
+	PublishedAt *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// CommentImportRequest representa un comentario importado desde otra plataforma, con su
+// estado de aprobación y su fecha. AuthorID es nil para los comentarios anónimos.
+type CommentImportRequest struct {
+	CommentCreateRequest
+
+	AuthorID   *uuid.UUID
+	IsApproved bool
+	CreatedAt  time.Time
+}
+
+// ImportOptions representa las opciones de una importación
+type ImportOptions struct {
+	// Source identifica el sitio de origen en import_mappings; vacío para usar el del export
+	Source string
+
+	// DryRun calcula el informe sin escribir nada
+	DryRun bool
+
+	// CreateCommenters crea usuarios commenter para los comentaristas anónimos con email en
+	// lugar de importar sus comentarios como anónimos
+	CreateCommenters bool
+}
+
+// ImportCounts cuenta el resultado de importar un tipo de elemento
+type ImportCounts struct {
+	Created int `json:"created"` // creados
+	Linked  int `json:"linked"`  // asociados a uno existente con el mismo email, slug o nombre
+	Skipped int `json:"skipped"` // importados en una ejecución anterior
+	Failed  int `json:"failed"`
+}
+
+// ImportReport representa el resultado de una importación o, en modo dry-run, lo que haría
+type ImportReport struct {
+	Source     string       `json:"source"`
+	DryRun     bool         `json:"dry_run"`
+	Users      ImportCounts `json:"users"`
+	Categories ImportCounts `json:"categories"`
+	Tags       ImportCounts `json:"tags"`
+	Posts      ImportCounts `json:"posts"`
+	Comments   ImportCounts `json:"comments"`
+
+	// Ignored cuenta los elementos que no se importan por tipo (ej: page, attachment, spam)
+	Ignored  map[string]int `json:"ignored"`
+	Warnings []string       `json:"warnings"`
+}
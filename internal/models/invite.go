@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Invite representa una invitación para registrarse
+type Invite struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	CodePrefix string     `json:"code_prefix" db:"code_prefix"`
+	CodeHash   string     `json:"-" db:"code_hash"`
+	Email      *string    `json:"email,omitempty" db:"email"`
+	Role       string     `json:"role" db:"role"`
+	MaxUses    int        `json:"max_uses" db:"max_uses"`
+	Uses       int        `json:"uses" db:"uses"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedBy  *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+
+	// Status es calculado: active, expired, exhausted o revoked
+	Status string `json:"status"`
+}
+
+// InviteCreateRequest representa la solicitud para crear una invitación
+type InviteCreateRequest struct {
+	Email     string     `json:"email" validate:"omitempty,email"`
+	Role      string     `json:"role" validate:"omitempty,oneof=admin editor author commenter reader"`
+	MaxUses   int        `json:"max_uses" validate:"omitempty,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Media representa un archivo subido a la biblioteca de medios
+type Media struct {
+	ID          uuid.UUID      `json:"id" db:"id"`
+	UploaderID  *uuid.UUID     `json:"uploader_id,omitempty" db:"uploader_id"`
+	PostID      *uuid.UUID     `json:"post_id,omitempty" db:"post_id"`
+	StorageKey  string         `json:"storage_key" db:"storage_key"`
+	URL         string         `json:"url"`
+	Filename    string         `json:"filename" db:"filename"`
+	ContentType string         `json:"content_type" db:"content_type"`
+	Size        int64          `json:"size" db:"size_bytes"`
+	Width       *int           `json:"width,omitempty" db:"width"`
+	Height      *int           `json:"height,omitempty" db:"height"`
+	AltText     string         `json:"alt_text" db:"alt_text"`
+	Variants    []MediaVariant `json:"variants" db:"variants"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+}
+
+// MediaVariant representa una versión redimensionada de una imagen (ej: thumbnail, medium)
+type MediaVariant struct {
+	Name        string `json:"name"`
+	StorageKey  string `json:"storage_key"`
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Size        int64  `json:"size"`
+}
+
+// MediaUsage describe dónde se usa un archivo: posts que lo tienen como imagen destacada o lo
+// incluyen en su contenido, y usuarios que lo tienen como avatar
+type MediaUsage struct {
+	FeaturedIn []uuid.UUID `json:"featured_in"`
+	ContentIn  []uuid.UUID `json:"content_in"`
+	AvatarOf   []uuid.UUID `json:"avatar_of"`
+}
+
+// InUse indica si el archivo se usa en algún sitio
+func (u MediaUsage) InUse() bool {
+	return len(u.FeaturedIn) > 0 || len(u.ContentIn) > 0 || len(u.AvatarOf) > 0
+}
+
+// MediaFilter representa los filtros del listado de medios
+type MediaFilter struct {
+	Page        int
+	PerPage     int
+	UploaderID  uuid.UUID
+	PostID      uuid.UUID
+	ContentType string // prefijo, ej: "image/"
+}
+
+// MediaListResponse representa la respuesta paginada de medios
+type MediaListResponse struct {
+	Media      []Media `json:"media"`
+	Total      int     `json:"total"`
+	Page       int     `json:"page"`
+	PerPage    int     `json:"per_page"`
+	TotalPages int     `json:"total_pages"`
+}
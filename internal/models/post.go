@@ -3,6 +3,8 @@ package models
 import (
 	"time"
 
+	"github.com/alan.bermudez/goasync/pkg/cursor"
+	"github.com/alan.bermudez/goasync/pkg/markdown"
 	"github.com/google/uuid"
 )
 
@@ -17,14 +19,43 @@ type Post struct {
 	CategoryID  uuid.UUID  `json:"category_id" db:"category_id"`
 	Status      string     `json:"status" db:"status"`
 	PublishedAt *time.Time `json:"published_at,omitempty" db:"published_at"`
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty" db:"scheduled_at"`
 	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+
+	// FeaturedImageID referencia la imagen destacada en la biblioteca de medios
+	FeaturedImageID *uuid.UUID `json:"featured_image_id,omitempty" db:"featured_image_id"`
+
+	// Content renderizado como Markdown a HTML sanitizado, con su tabla de contenidos y el
+	// tiempo de lectura estimado en minutos
+	ContentHTML string             `json:"content_html" db:"content_html"`
+	TOC         []markdown.Heading `json:"toc" db:"toc"`
+	WordCount   int                `json:"word_count" db:"word_count"`
+	ReadingTime int                `json:"reading_time" db:"reading_time"`
 
 	// Relaciones
 	Author   *User     `json:"author,omitempty"`
 	Category *Category `json:"category,omitempty"`
 	Tags     []Tag     `json:"tags,omitempty"`
 	Comments []Comment `json:"comments,omitempty"`
+
+	// FeaturedImage contiene la imagen destacada con sus variantes
+	FeaturedImage *Media `json:"featured_image,omitempty"`
+
+	// Series contiene la navegación dentro de la serie a la que pertenece el post
+	Series *SeriesNavigation `json:"series,omitempty"`
+
+	// Search contiene la relevancia y los fragmentos resaltados en las búsquedas de texto completo
+	Search *PostSearchMatch `json:"search,omitempty"`
+}
+
+// PostSearchMatch representa la coincidencia de un post en una búsqueda de texto completo.
+// Title y Snippet son HTML escapado con los términos encontrados marcados con <mark>.
+type PostSearchMatch struct {
+	Rank    float64 `json:"rank"`
+	Title   string  `json:"title_highlight"`
+	Snippet string  `json:"snippet"`
 }
 
 // PostCreateRequest representa la solicitud para crear un post
@@ -33,8 +64,14 @@ type PostCreateRequest struct {
 	Content    string      `json:"content" validate:"required"`
 	Excerpt    string      `json:"excerpt"`
 	CategoryID uuid.UUID   `json:"category_id" validate:"required"`
-	Status     string      `json:"status" validate:"omitempty,oneof=draft published archived"`
+	Status     string      `json:"status" validate:"omitempty,oneof=draft published archived scheduled"`
 	TagIDs     []uuid.UUID `json:"tag_ids"`
+
+	// PublishAt es obligatorio cuando el estado es scheduled
+	PublishAt *time.Time `json:"publish_at"`
+
+	// FeaturedImageID debe referenciar una imagen de la biblioteca de medios
+	FeaturedImageID *uuid.UUID `json:"featured_image_id"`
 }
 
 // PostUpdateRequest representa la solicitud para actualizar un post
@@ -43,8 +80,34 @@ type PostUpdateRequest struct {
 	Content    string      `json:"content"`
 	Excerpt    string      `json:"excerpt"`
 	CategoryID *uuid.UUID  `json:"category_id"`
-	Status     string      `json:"status" validate:"omitempty,oneof=draft published archived"`
+	Status     string      `json:"status" validate:"omitempty,oneof=draft published archived scheduled"`
 	TagIDs     []uuid.UUID `json:"tag_ids"`
+
+	// PublishAt es obligatorio al pasar al estado scheduled y reprograma la publicación
+	PublishAt *time.Time `json:"publish_at"`
+
+	// RevisionNote describe el cambio en el historial de revisiones
+	RevisionNote string `json:"revision_note" validate:"max=500"`
+
+	// RegenerateSlug genera un nuevo slug a partir del título. El slug anterior se conserva
+	// como redirección.
+	RegenerateSlug bool `json:"regenerate_slug"`
+
+	// FeaturedImageID cambia la imagen destacada; RemoveFeaturedImage la quita
+	FeaturedImageID     *uuid.UUID `json:"featured_image_id"`
+	RemoveFeaturedImage bool       `json:"remove_featured_image"`
+}
+
+// PostScheduleRequest representa la solicitud para programar o reprogramar la publicación de un post
+type PostScheduleRequest struct {
+	PublishAt *time.Time `json:"publish_at" validate:"required"`
+}
+
+// RelatedPost representa un post relacionado con su puntuación
+type RelatedPost struct {
+	Post
+	Score      float64 `json:"score"`
+	SharedTags int     `json:"shared_tags"`
 }
 
 // PostListResponse representa la respuesta paginada de posts
@@ -54,15 +117,37 @@ type PostListResponse struct {
 	Page       int    `json:"page"`
 	PerPage    int    `json:"per_page"`
 	TotalPages int    `json:"total_pages"`
+
+	// Cursores de la página siguiente y anterior en modo cursor
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
 }
 
-// PostFilter representa los filtros para listar posts
+// Modos de búsqueda de posts
+const (
+	SearchModeFullText = "fulltext"
+	SearchModeSimple   = "simple"
+)
+
+// Modos de coincidencia del filtro de tags
+const (
+	TagMatchAny = "any"
+	TagMatchAll = "all"
+)
+
+// PostFilter representa los filtros para listar posts. Tags y ExcludeTags aceptan IDs o slugs.
 type PostFilter struct {
-	Status     string    `json:"status"`
-	CategoryID uuid.UUID `json:"category_id"`
-	AuthorID   uuid.UUID `json:"author_id"`
-	TagID      uuid.UUID `json:"tag_id"`
-	Search     string    `json:"search"`
-	Page       int       `json:"page"`
-	PerPage    int       `json:"per_page"`
+	Status      string    `json:"status"`
+	CategoryID  uuid.UUID `json:"category_id"`
+	AuthorID    uuid.UUID `json:"author_id"`
+	Tags        []string  `json:"tags"`
+	TagMatch    string    `json:"tag_match"`
+	ExcludeTags []string  `json:"exclude_tags"`
+	Search      string    `json:"search"`
+	SearchMode  string    `json:"search_mode"`
+	Page        int       `json:"page"`
+	PerPage     int       `json:"per_page"`
+
+	// Cursor activa la paginación por cursor en lugar de page/per_page
+	Cursor *cursor.Page `json:"-"`
 }
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/alan.bermudez/goasync/pkg/diff"
+	"github.com/google/uuid"
+)
+
+// PostRevision representa una versión guardada del título, contenido y extracto de un post
+type PostRevision struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	PostID    uuid.UUID  `json:"post_id" db:"post_id"`
+	Revision  int        `json:"revision" db:"revision"`
+	Title     string     `json:"title" db:"title"`
+	Content   string     `json:"content,omitempty" db:"content"`
+	Excerpt   string     `json:"excerpt,omitempty" db:"excerpt"`
+	EditorID  *uuid.UUID `json:"editor_id,omitempty" db:"editor_id"`
+	Note      string     `json:"note" db:"note"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+
+	// Relaciones
+	Editor *User `json:"editor,omitempty"`
+}
+
+// PostRevisionDiff representa las diferencias entre dos revisiones de un post
+type PostRevisionDiff struct {
+	PostID  uuid.UUID  `json:"post_id"`
+	From    int        `json:"from"`
+	To      int        `json:"to"`
+	Mode    string     `json:"mode"`
+	Title   []diff.Op  `json:"title"`
+	Excerpt []diff.Op  `json:"excerpt"`
+	Content []diff.Op  `json:"content"`
+	Stats   diff.Stats `json:"stats"`
+}
+
+// PostRevisionRestoreRequest representa la solicitud para restaurar una revisión
+type PostRevisionRestoreRequest struct {
+	Note string `json:"note" validate:"max=500"`
+}
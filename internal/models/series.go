@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Series representa una serie de posts con partes ordenadas (ej: un tutorial en varios posts)
+type Series struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	Title       string     `json:"title" db:"title"`
+	Slug        string     `json:"slug" db:"slug"`
+	Description string     `json:"description" db:"description"`
+	CreatedBy   *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	PartCount   int        `json:"part_count" db:"part_count"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+
+	// Relaciones
+	Parts []SeriesPart `json:"parts,omitempty"`
+}
+
+// SeriesPart representa un post dentro de una serie
+type SeriesPart struct {
+	Position int       `json:"position" db:"position"`
+	PostID   uuid.UUID `json:"post_id" db:"post_id"`
+	Title    string    `json:"title" db:"title"`
+	Slug     string    `json:"slug" db:"slug"`
+	Status   string    `json:"status,omitempty" db:"status"`
+}
+
+// SeriesNavigation representa la navegación de un post dentro de su serie. Part y Total
+// cuentan solo las partes publicadas (y el propio post).
+type SeriesNavigation struct {
+	ID       uuid.UUID   `json:"id"`
+	Title    string      `json:"title"`
+	Slug     string      `json:"slug"`
+	Part     int         `json:"part"`
+	Total    int         `json:"total"`
+	Previous *SeriesPart `json:"previous"`
+	Next     *SeriesPart `json:"next"`
+}
+
+// SeriesCreateRequest representa la solicitud para crear una serie
+type SeriesCreateRequest struct {
+	Title       string `json:"title" validate:"required,min=1,max=255"`
+	Slug        string `json:"slug" validate:"omitempty,min=1,max=255"`
+	Description string `json:"description"`
+}
+
+// SeriesUpdateRequest representa la solicitud para actualizar una serie
+type SeriesUpdateRequest struct {
+	Title       string  `json:"title" validate:"omitempty,min=1,max=255"`
+	Slug        string  `json:"slug" validate:"omitempty,min=1,max=255"`
+	Description *string `json:"description"`
+}
+
+// SeriesAddPostRequest representa la solicitud para añadir un post a una serie. Si Position
+// es 0 el post se añade al final.
+type SeriesAddPostRequest struct {
+	PostID   uuid.UUID `json:"post_id" validate:"required"`
+	Position int       `json:"position"`
+}
+
+// SeriesReorderRequest representa el nuevo orden completo de las partes de una serie
+type SeriesReorderRequest struct {
+	PostIDs []uuid.UUID `json:"post_ids" validate:"required"`
+}
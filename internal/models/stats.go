@@ -3,6 +3,7 @@ package models
 import (
 	"time"
 
+	"github.com/alan.bermudez/goasync/pkg/cursor"
 	"github.com/google/uuid"
 )
 
@@ -17,20 +18,30 @@ type DatabaseStats struct {
 
 // ActivityLog representa un log de actividad del sistema
 type ActivityLog struct {
-	ID           uuid.UUID              `json:"id" db:"id"`
-	UserID       *uuid.UUID             `json:"user_id,omitempty" db:"user_id"`
-	Action       string                 `json:"action" db:"action"`
-	ResourceType string                 `json:"resource_type" db:"resource_type"`
-	ResourceID   *uuid.UUID             `json:"resource_id,omitempty" db:"resource_id"`
-	Details      map[string]interface{} `json:"details" db:"details"`
-	IPAddress    string                 `json:"ip_address" db:"ip_address"`
-	UserAgent    string                 `json:"user_agent" db:"user_agent"`
-	CreatedAt    time.Time              `json:"created_at" db:"created_at"`
+	ID     uuid.UUID  `json:"id" db:"id"`
+	UserID *uuid.UUID `json:"user_id,omitempty" db:"user_id"`
+	// ImpersonatorID es el administrador que actuaba como UserID, si la acción se realizó suplantándolo
+	ImpersonatorID *uuid.UUID             `json:"impersonator_id,omitempty" db:"impersonator_id"`
+	Action         string                 `json:"action" db:"action"`
+	ResourceType   string                 `json:"resource_type" db:"resource_type"`
+	ResourceID     *uuid.UUID             `json:"resource_id,omitempty" db:"resource_id"`
+	Details        map[string]interface{} `json:"details" db:"details"`
+	IPAddress      string                 `json:"ip_address" db:"ip_address"`
+	UserAgent      string                 `json:"user_agent" db:"user_agent"`
+	CreatedAt      time.Time              `json:"created_at" db:"created_at"`
 
 	// Relaciones
 	User *User `json:"user,omitempty"`
 }
 
+// RequestInfo contiene los datos de la petición que se registran junto a cada log de actividad
+type RequestInfo struct {
+	IPAddress       string
+	UserAgent       string
+	ImpersonatorID  *uuid.UUID
+	ImpersonationID *uuid.UUID
+}
+
 // ActivityLogFilter representa los filtros para listar logs de actividad
 type ActivityLogFilter struct {
 	UserID       uuid.UUID `json:"user_id"`
@@ -40,6 +51,18 @@ type ActivityLogFilter struct {
 	EndDate      time.Time `json:"end_date"`
 	Page         int       `json:"page"`
 	PerPage      int       `json:"per_page"`
+
+	// Cursor activa la paginación por cursor en lugar de page/per_page
+	Cursor *cursor.Page `json:"-"`
+}
+
+// ActivityLogListResponse representa un listado de logs de actividad
+type ActivityLogListResponse struct {
+	Logs []ActivityLog `json:"logs"`
+
+	// Cursores de la página siguiente y anterior en modo cursor
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
 }
 
 // PostStats representa estadísticas de un post específico
@@ -47,6 +70,15 @@ type PostStats struct {
 	PostID       uuid.UUID `json:"post_id" db:"post_id"`
 	Title        string    `json:"title" db:"title"`
 	CommentCount int       `json:"comment_count" db:"comment_count"`
-	ViewCount    int       `json:"view_count" db:"view_count"`
+	ViewCount    int64     `json:"view_count" db:"view_count"`
 	PublishedAt  time.Time `json:"published_at" db:"published_at"`
+
+	// DailyViews contiene las visitas de cada día del período consultado, incluidos los días sin visitas
+	DailyViews []DailyViews `json:"daily_views"`
+}
+
+// DailyViews representa las visitas de un post en un día (UTC)
+type DailyViews struct {
+	Date  string `json:"date"`
+	Views int64  `json:"views"`
 }
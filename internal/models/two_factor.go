@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+)
+
+// TwoFactorStatus representa el estado de la autenticación de dos factores de un usuario
+type TwoFactorStatus struct {
+	Enabled                bool `json:"enabled"`
+	Required               bool `json:"required"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorEnrollment contiene el secreto TOTP pendiente de confirmar
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// TwoFactorChallenge representa el desafío emitido cuando el inicio de sesión requiere un segundo factor
+type TwoFactorChallenge struct {
+	ChallengeToken string    `json:"challenge_token"`
+	ExpiresAt      time.Time `json:"expires_at"`
+
+	// SetupRequired indica que el rol del usuario exige 2FA y aún no lo ha configurado.
+	// En ese caso Enrollment contiene el secreto a registrar.
+	SetupRequired bool                 `json:"setup_required"`
+	Enrollment    *TwoFactorEnrollment `json:"enrollment,omitempty"`
+}
+
+// TwoFactorCodeRequest representa un código TOTP o un código de recuperación
+type TwoFactorCodeRequest struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// TwoFactorChallengeRequest representa la respuesta a un desafío de dos factores
+type TwoFactorChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+}
+
+// RoleTwoFactorRequest representa la solicitud para exigir 2FA a un rol
+type RoleTwoFactorRequest struct {
+	Required *bool `json:"required" validate:"required"`
+}
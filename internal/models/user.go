@@ -15,6 +15,8 @@ type User struct {
 	FirstName    string    `json:"first_name" db:"first_name"`
 	LastName     string    `json:"last_name" db:"last_name"`
 	IsActive     bool      `json:"is_active" db:"is_active"`
+	Role         string    `json:"role" db:"role"`
+	IsVerified   bool      `json:"is_verified" db:"is_verified"`
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
 
@@ -53,6 +55,9 @@ type UserCreateRequest struct {
 	Password  string `json:"password" validate:"required,min=6"`
 	FirstName string `json:"first_name" validate:"required,min=1,max=100"`
 	LastName  string `json:"last_name" validate:"required,min=1,max=100"`
+
+	// InviteCode es obligatorio cuando el registro es solo por invitación
+	InviteCode string `json:"invite_code"`
 }
 
 // UserUpdateRequest representa la solicitud para actualizar un usuario
@@ -62,6 +67,11 @@ type UserUpdateRequest struct {
 	IsActive  *bool  `json:"is_active"`
 }
 
+// UserRoleUpdateRequest representa la solicitud para cambiar el rol de un usuario
+type UserRoleUpdateRequest struct {
+	Role string `json:"role" validate:"required,oneof=admin editor author commenter reader"`
+}
+
 // UserProfileUpdateRequest representa la solicitud para actualizar un perfil
 type UserProfileUpdateRequest struct {
 	Bio         string     `json:"bio"`
@@ -70,3 +80,37 @@ type UserProfileUpdateRequest struct {
 	Phone       string     `json:"phone"`
 	Address     string     `json:"address"`
 }
+
+// PasswordChangeRequest representa la solicitud para cambiar la contraseña
+type PasswordChangeRequest struct {
+	OldPassword string `json:"old_password" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=6"`
+}
+
+// LoginRequest representa la solicitud de inicio de sesión
+type LoginRequest struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Password string `json:"password" validate:"required"`
+}
+
+// RefreshRequest representa la solicitud para renovar los tokens
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// PasswordResetRequest representa la solicitud de un enlace para restablecer la contraseña
+type PasswordResetRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// PasswordResetConfirmRequest representa la solicitud para restablecer la contraseña con un token
+type PasswordResetConfirmRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=6"`
+}
+
+// EmailVerifyRequest representa la solicitud para verificar un email con un token
+type EmailVerifyRequest struct {
+	Token string `json:"token" validate:"required"`
+}
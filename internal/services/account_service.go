@@ -0,0 +1,270 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/pkg/auth"
+	"github.com/alan.bermudez/goasync/pkg/mailer"
+	"github.com/alan.bermudez/goasync/pkg/password"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// Propósitos de los tokens de un solo uso
+const (
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailVerification = "email_verification"
+)
+
+// AccountService maneja la recuperación de cuentas y la verificación de email
+type AccountService struct {
+	db              *sql.DB
+	userService     *UserService
+	sessionService  *SessionService
+	mailer          mailer.Mailer
+	resetTTL        time.Duration
+	verificationTTL time.Duration
+	linkBaseURL     string
+	logger          *logrus.Logger
+}
+
+// NewAccountService crea una nueva instancia del servicio de cuentas
+func NewAccountService(db *sql.DB, userService *UserService, sessionService *SessionService, mail mailer.Mailer, resetTTL, verificationTTL time.Duration, linkBaseURL string, logger *logrus.Logger) *AccountService {
+	return &AccountService{
+		db:              db,
+		userService:     userService,
+		sessionService:  sessionService,
+		mailer:          mail,
+		resetTTL:        resetTTL,
+		verificationTTL: verificationTTL,
+		linkBaseURL:     strings.TrimRight(linkBaseURL, "/"),
+		logger:          logger,
+	}
+}
+
+// RequestPasswordReset envía un enlace para restablecer la contraseña. Si el email no
+// pertenece a un usuario activo no hace nada y retorna nil, para no revelar qué cuentas existen.
+func (s *AccountService) RequestPasswordReset(email string) (*models.User, error) {
+	user, err := s.userService.GetUserByEmail(email)
+	if err != nil {
+		if err.Error() == "usuario no encontrado" {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if !user.IsActive {
+		return nil, nil
+	}
+
+	token, err := s.issueToken(user.ID, TokenPurposePasswordReset, s.resetTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	s.send(mailer.Message{
+		To:      user.Email,
+		Subject: "Restablece tu contraseña",
+		Body: fmt.Sprintf("Hola %s,\n\nRecibimos una solicitud para restablecer tu contraseña. "+
+			"Usa el siguiente enlace antes de %d minutos:\n\n%s\n\n"+
+			"Si no solicitaste este cambio puedes ignorar este correo.",
+			user.Username, int(s.resetTTL.Minutes()), s.link("/reset-password", token)),
+	})
+
+	return user, nil
+}
+
+// ResetPassword consume un token de restablecimiento, guarda la nueva contraseña y
+// revoca todas las sesiones y tokens de refresco del usuario. Todo ocurre en una
+// transacción, así que si algo falla el token sigue siendo válido.
+func (s *AccountService) ResetPassword(req models.PasswordResetConfirmRequest) (uuid.UUID, error) {
+	if len(req.NewPassword) < 6 {
+		return uuid.Nil, fmt.Errorf("la nueva contraseña debe tener al menos 6 caracteres")
+	}
+	if len(req.NewPassword) > password.MaxLength {
+		return uuid.Nil, password.ErrTooLong
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		s.logger.Errorf("Error iniciando transacción: %v", err)
+		return uuid.Nil, err
+	}
+	defer tx.Rollback()
+
+	userID, err := s.consumeToken(tx, req.Token, TokenPurposePasswordReset)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := s.userService.setPassword(tx, userID, req.NewPassword, true); err != nil {
+		return uuid.Nil, err
+	}
+
+	if _, err := s.sessionService.revokeSessions(tx, userID, uuid.Nil); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.Errorf("Error confirmando transacción: %v", err)
+		return uuid.Nil, err
+	}
+
+	return userID, nil
+}
+
+// ChangePassword cambia la contraseña de un usuario verificando la actual y revoca sus
+// tokens de refresco y todas sus sesiones salvo currentSessionID, la de la petición (uuid.Nil
+// si se autenticó con un token JWT).
+func (s *AccountService) ChangePassword(userID, currentSessionID uuid.UUID, req models.PasswordChangeRequest) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		s.logger.Errorf("Error iniciando transacción: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.userService.changePassword(tx, userID, req); err != nil {
+		return err
+	}
+
+	if _, err := s.sessionService.revokeSessions(tx, userID, currentSessionID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.Errorf("Error confirmando transacción: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// RequestEmailVerification envía un enlace de verificación al email de un usuario
+func (s *AccountService) RequestEmailVerification(userID uuid.UUID) error {
+	user, err := s.userService.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	return s.SendEmailVerification(user)
+}
+
+// SendEmailVerification envía un enlace de verificación al email del usuario indicado
+func (s *AccountService) SendEmailVerification(user *models.User) error {
+	if user.IsVerified {
+		return fmt.Errorf("el email ya está verificado")
+	}
+
+	token, err := s.issueToken(user.ID, TokenPurposeEmailVerification, s.verificationTTL)
+	if err != nil {
+		return err
+	}
+
+	s.send(mailer.Message{
+		To:      user.Email,
+		Subject: "Verifica tu email",
+		Body: fmt.Sprintf("Hola %s,\n\nConfirma tu dirección de email con el siguiente enlace "+
+			"antes de %d horas:\n\n%s",
+			user.Username, int(s.verificationTTL.Hours()), s.link("/verify-email", token)),
+	})
+
+	return nil
+}
+
+// VerifyEmail consume un token de verificación y marca el email del usuario como verificado
+func (s *AccountService) VerifyEmail(token string) (uuid.UUID, error) {
+	userID, err := s.consumeToken(s.db, token, TokenPurposeEmailVerification)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := s.userService.MarkEmailVerified(userID); err != nil {
+		return uuid.Nil, err
+	}
+
+	return userID, nil
+}
+
+// issueToken invalida los tokens pendientes del mismo propósito y emite uno nuevo.
+// Solo el hash del token se guarda en la base de datos.
+func (s *AccountService) issueToken(userID uuid.UUID, purpose string, ttl time.Duration) (string, error) {
+	token, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		s.logger.Errorf("Error generando token: %v", err)
+		return "", err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		s.logger.Errorf("Error iniciando transacción: %v", err)
+		return "", err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE user_tokens SET used_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL
+	`, userID, purpose)
+	if err != nil {
+		s.logger.Errorf("Error invalidando tokens anteriores: %v", err)
+		return "", err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO user_tokens (user_id, purpose, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`, userID, purpose, auth.HashToken(token), time.Now().Add(ttl))
+	if err != nil {
+		s.logger.Errorf("Error creando token: %v", err)
+		return "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.Errorf("Error confirmando transacción: %v", err)
+		return "", err
+	}
+
+	return token, nil
+}
+
+// consumeToken marca un token como usado de forma atómica y retorna su usuario
+func (s *AccountService) consumeToken(q queryRower, token, purpose string) (uuid.UUID, error) {
+	query := `
+		UPDATE user_tokens
+		SET used_at = CURRENT_TIMESTAMP
+		WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+		RETURNING user_id
+	`
+
+	var userID uuid.UUID
+	err := q.QueryRow(query, auth.HashToken(token), purpose).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, fmt.Errorf("token inválido o expirado")
+		}
+		s.logger.Errorf("Error consumiendo token: %v", err)
+		return uuid.Nil, err
+	}
+
+	return userID, nil
+}
+
+// link construye el enlace incluido en los correos
+func (s *AccountService) link(path, token string) string {
+	return s.linkBaseURL + path + "?token=" + url.QueryEscape(token)
+}
+
+// send envía un correo en segundo plano para no exponer diferencias de tiempo de respuesta
+func (s *AccountService) send(msg mailer.Message) {
+	go func() {
+		if err := s.mailer.Send(msg); err != nil {
+			s.logger.Errorf("Error enviando correo: %v", err)
+		}
+	}()
+}
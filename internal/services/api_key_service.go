@@ -0,0 +1,187 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/pkg/auth"
+	"github.com/alan.bermudez/goasync/pkg/rbac"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// apiKeyPrefix identifica visualmente las API keys emitidas por la aplicación
+const apiKeyPrefix = "gak_"
+
+// apiKeyDisplayLength es la cantidad de caracteres de la key que se guardan en claro para identificarla
+const apiKeyDisplayLength = 12
+
+// APIKeyService maneja la lógica de negocio para API keys
+type APIKeyService struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+// NewAPIKeyService crea una nueva instancia del servicio de API keys
+func NewAPIKeyService(db *sql.DB, logger *logrus.Logger) *APIKeyService {
+	return &APIKeyService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// CreateAPIKey emite una nueva API key y retorna la key en claro.
+// Solo el hash de la key se guarda en la base de datos.
+func (s *APIKeyService) CreateAPIKey(req models.APIKeyCreateRequest, createdBy uuid.UUID) (string, *models.APIKey, error) {
+	if len(req.Scopes) == 0 {
+		return "", nil, fmt.Errorf("se requiere al menos un scope")
+	}
+	for _, scope := range req.Scopes {
+		if !rbac.IsValidScope(scope) {
+			return "", nil, fmt.Errorf("scope inválido: %s", scope)
+		}
+	}
+
+	if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
+		return "", nil, fmt.Errorf("la fecha de expiración debe ser futura")
+	}
+
+	// Verificar que el usuario existe
+	var exists bool
+	err := s.db.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)", req.UserID).Scan(&exists)
+	if err != nil {
+		s.logger.Errorf("Error verificando usuario de la API key: %v", err)
+		return "", nil, err
+	}
+	if !exists {
+		return "", nil, fmt.Errorf("usuario no encontrado")
+	}
+
+	token, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		s.logger.Errorf("Error generando API key: %v", err)
+		return "", nil, err
+	}
+	key := apiKeyPrefix + token
+
+	query := `
+		INSERT INTO api_keys (user_id, name, key_prefix, key_hash, scopes, expires_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, user_id, name, key_prefix, key_hash, scopes, expires_at,
+		          last_used_at, revoked_at, created_by, created_at
+	`
+
+	apiKey, err := s.scanAPIKey(s.db.QueryRow(query, req.UserID, req.Name, key[:apiKeyDisplayLength],
+		auth.HashToken(key), pq.Array(req.Scopes), req.ExpiresAt, createdBy))
+	if err != nil {
+		s.logger.Errorf("Error creando API key: %v", err)
+		return "", nil, err
+	}
+
+	return key, apiKey, nil
+}
+
+// GetAPIKeys obtiene las API keys, opcionalmente filtradas por usuario
+func (s *APIKeyService) GetAPIKeys(userID uuid.UUID) ([]models.APIKey, error) {
+	query := `
+		SELECT id, user_id, name, key_prefix, key_hash, scopes, expires_at,
+		       last_used_at, revoked_at, created_by, created_at
+		FROM api_keys
+		WHERE ($1::uuid IS NULL OR user_id = $1)
+		ORDER BY created_at DESC
+	`
+
+	var filter interface{}
+	if userID != uuid.Nil {
+		filter = userID
+	}
+
+	rows, err := s.db.Query(query, filter)
+	if err != nil {
+		s.logger.Errorf("Error obteniendo API keys: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var apiKeys []models.APIKey
+	for rows.Next() {
+		apiKey, err := s.scanAPIKey(rows)
+		if err != nil {
+			s.logger.Errorf("Error escaneando API key: %v", err)
+			continue
+		}
+		apiKeys = append(apiKeys, *apiKey)
+	}
+
+	return apiKeys, nil
+}
+
+// RevokeAPIKey revoca una API key
+func (s *APIKeyService) RevokeAPIKey(id uuid.UUID) error {
+	query := "UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND revoked_at IS NULL"
+
+	result, err := s.db.Exec(query, id)
+	if err != nil {
+		s.logger.Errorf("Error revocando API key: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("API key no encontrada")
+	}
+
+	return nil
+}
+
+// ValidateAPIKey valida una API key y registra su último uso. Las keys revocadas,
+// expiradas o de usuarios inactivos son rechazadas.
+func (s *APIKeyService) ValidateAPIKey(key string) (uuid.UUID, uuid.UUID, []string, error) {
+	query := `
+		UPDATE api_keys k
+		SET last_used_at = CURRENT_TIMESTAMP
+		FROM users u
+		WHERE k.user_id = u.id
+		  AND u.is_active = true
+		  AND k.key_hash = $1
+		  AND k.revoked_at IS NULL
+		  AND (k.expires_at IS NULL OR k.expires_at > CURRENT_TIMESTAMP)
+		RETURNING k.id, k.user_id, k.scopes
+	`
+
+	var keyID, userID uuid.UUID
+	var scopes []string
+	err := s.db.QueryRow(query, auth.HashToken(key)).Scan(&keyID, &userID, pq.Array(&scopes))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, uuid.Nil, nil, fmt.Errorf("API key inválida")
+		}
+		s.logger.Errorf("Error validando API key: %v", err)
+		return uuid.Nil, uuid.Nil, nil, err
+	}
+
+	return userID, keyID, scopes, nil
+}
+
+// scanAPIKey escanea una fila de api_keys
+func (s *APIKeyService) scanAPIKey(row interface{ Scan(...interface{}) error }) (*models.APIKey, error) {
+	var apiKey models.APIKey
+
+	err := row.Scan(
+		&apiKey.ID, &apiKey.UserID, &apiKey.Name, &apiKey.KeyPrefix, &apiKey.KeyHash,
+		pq.Array(&apiKey.Scopes), &apiKey.ExpiresAt, &apiKey.LastUsedAt, &apiKey.RevokedAt,
+		&apiKey.CreatedBy, &apiKey.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apiKey, nil
+}
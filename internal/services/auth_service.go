@@ -0,0 +1,288 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/pkg/auth"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// Mecanismos de inicio de sesión que puede completar un desafío de dos factores
+const (
+	LoginMethodToken   = "token"
+	LoginMethodSession = "session"
+)
+
+// AuthService maneja la lógica de negocio para autenticación
+type AuthService struct {
+	userService      *UserService
+	sessionService   *SessionService
+	twoFactorService *TwoFactorService
+	throttle         *LoginThrottleService
+	tokens           *auth.TokenManager
+	// requireVerified bloquea el inicio de sesión de cuentas con email sin verificar
+	requireVerified bool
+	challengeTTL    time.Duration
+	logger          *logrus.Logger
+}
+
+// NewAuthService crea una nueva instancia del servicio de autenticación
+func NewAuthService(userService *UserService, sessionService *SessionService, twoFactorService *TwoFactorService, throttle *LoginThrottleService, tokens *auth.TokenManager, requireVerified bool, challengeTTL time.Duration, logger *logrus.Logger) *AuthService {
+	return &AuthService{
+		userService:      userService,
+		sessionService:   sessionService,
+		twoFactorService: twoFactorService,
+		throttle:         throttle,
+		tokens:           tokens,
+		requireVerified:  requireVerified,
+		challengeTTL:     challengeTTL,
+		logger:           logger,
+	}
+}
+
+// Login verifica las credenciales y emite un par de tokens. Si el usuario requiere
+// un segundo factor retorna un desafío en lugar de los tokens.
+func (s *AuthService) Login(req models.LoginRequest, ipAddress, userAgent string) (*models.User, *auth.TokenPair, *models.TwoFactorChallenge, error) {
+	user, err := s.authenticate(req, ipAddress, userAgent)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	challenge, err := s.challengeIfNeeded(user, LoginMethodToken)
+	if err != nil || challenge != nil {
+		return user, nil, challenge, err
+	}
+
+	s.throttle.RecordSuccess(user.ID)
+
+	pair, err := s.IssueTokens(user.ID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return user, pair, nil, nil
+}
+
+// LoginWithSession verifica las credenciales y crea una sesión de servidor. Si el usuario
+// requiere un segundo factor retorna un desafío en lugar de la sesión.
+func (s *AuthService) LoginWithSession(req models.LoginRequest, ipAddress, userAgent string) (*models.User, string, *models.UserSession, *models.TwoFactorChallenge, error) {
+	user, err := s.authenticate(req, ipAddress, userAgent)
+	if err != nil {
+		return nil, "", nil, nil, err
+	}
+
+	challenge, err := s.challengeIfNeeded(user, LoginMethodSession)
+	if err != nil || challenge != nil {
+		return user, "", nil, challenge, err
+	}
+
+	s.throttle.RecordSuccess(user.ID)
+
+	token, session, err := s.sessionService.CreateSession(user.ID, ipAddress, userAgent)
+	if err != nil {
+		return nil, "", nil, nil, err
+	}
+
+	return user, token, session, nil, nil
+}
+
+// CompleteTwoFactor resuelve un desafío de dos factores. Retorna el usuario, el mecanismo
+// de inicio de sesión a completar y, si el desafío incluía la inscripción obligatoria,
+// los códigos de recuperación generados.
+func (s *AuthService) CompleteTwoFactor(req models.TwoFactorChallengeRequest, ipAddress, userAgent string) (*models.User, string, []string, error) {
+	if err := s.throttle.CheckIP(ipAddress); err != nil {
+		return nil, "", nil, err
+	}
+
+	claims, err := s.tokens.Parse(req.ChallengeToken, auth.TokenTypeTwoFactor)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("desafío de dos factores inválido")
+	}
+
+	userID, _ := claims.UserID()
+	user, err := s.userService.GetUserByID(userID)
+	if err != nil {
+		if err.Error() == "usuario no encontrado" {
+			return nil, "", nil, fmt.Errorf("desafío de dos factores inválido")
+		}
+		return nil, "", nil, err
+	}
+
+	if !user.IsActive {
+		return nil, "", nil, fmt.Errorf("usuario inactivo")
+	}
+
+	if err := s.throttle.CheckAccount(user.ID); err != nil {
+		return nil, "", nil, err
+	}
+
+	enabled, err := s.twoFactorService.IsEnabled(user.ID)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	var recoveryCodes []string
+	if enabled {
+		err = s.twoFactorService.Verify(user.ID, models.TwoFactorCodeRequest{
+			Code:         req.Code,
+			RecoveryCode: req.RecoveryCode,
+		})
+	} else {
+		// Inscripción obligatoria iniciada en el inicio de sesión
+		recoveryCodes, err = s.twoFactorService.Activate(user.ID, req.Code)
+		if err != nil && err.Error() == "no hay una inscripción de dos factores pendiente" {
+			err = fmt.Errorf("desafío de dos factores inválido")
+		}
+	}
+	if err != nil {
+		if err.Error() == "código de verificación inválido" {
+			s.throttle.RecordFailure(&user.ID, ipAddress, userAgent)
+		}
+		return nil, "", nil, err
+	}
+
+	s.throttle.RecordSuccess(user.ID)
+
+	return user, claims.Method, recoveryCodes, nil
+}
+
+// IssueTokens emite un par de tokens para un usuario ya autenticado
+func (s *AuthService) IssueTokens(userID uuid.UUID) (*auth.TokenPair, error) {
+	version, err := s.userService.GetTokenVersion(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	pair, err := s.tokens.GeneratePair(userID, version)
+	if err != nil {
+		s.logger.Errorf("Error generando tokens: %v", err)
+		return nil, err
+	}
+
+	return pair, nil
+}
+
+// StartSession crea una sesión de servidor para un usuario ya autenticado
+func (s *AuthService) StartSession(userID uuid.UUID, ipAddress, userAgent string) (string, *models.UserSession, error) {
+	return s.sessionService.CreateSession(userID, ipAddress, userAgent)
+}
+
+// Refresh valida un token de refresco y emite un nuevo par de tokens. Los tokens emitidos
+// antes del último cambio de contraseña se rechazan.
+func (s *AuthService) Refresh(refreshToken string) (*models.User, *auth.TokenPair, error) {
+	claims, err := s.tokens.Parse(refreshToken, auth.TokenTypeRefresh)
+	if err != nil {
+		return nil, nil, fmt.Errorf("token de refresco inválido")
+	}
+
+	userID, _ := claims.UserID()
+	user, err := s.userService.GetUserByID(userID)
+	if err != nil {
+		if err.Error() == "usuario no encontrado" {
+			return nil, nil, fmt.Errorf("token de refresco inválido")
+		}
+		return nil, nil, err
+	}
+
+	if !user.IsActive {
+		return nil, nil, fmt.Errorf("usuario inactivo")
+	}
+
+	if s.requireVerified && !user.IsVerified {
+		return nil, nil, fmt.Errorf("email no verificado")
+	}
+
+	version, err := s.userService.GetTokenVersion(user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if claims.Version != version {
+		return nil, nil, fmt.Errorf("token de refresco inválido")
+	}
+
+	pair, err := s.IssueTokens(user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, pair, nil
+}
+
+// authenticate verifica las credenciales de una solicitud de inicio de sesión aplicando
+// la protección contra fuerza bruta por IP y por cuenta
+func (s *AuthService) authenticate(req models.LoginRequest, ipAddress, userAgent string) (*models.User, error) {
+	if err := s.throttle.CheckIP(ipAddress); err != nil {
+		return nil, err
+	}
+
+	login := req.Username
+	if login == "" {
+		login = req.Email
+	}
+
+	user, err := s.userService.GetUserByLogin(login)
+	if err != nil {
+		if err.Error() == "usuario no encontrado" {
+			s.userService.VerifyDummyPassword(req.Password)
+			s.throttle.RecordFailure(nil, ipAddress, userAgent)
+			return nil, fmt.Errorf("credenciales inválidas")
+		}
+		return nil, err
+	}
+
+	if err := s.throttle.CheckAccount(user.ID); err != nil {
+		return nil, err
+	}
+
+	if !s.userService.VerifyPassword(user, req.Password) {
+		s.throttle.RecordFailure(&user.ID, ipAddress, userAgent)
+		return nil, fmt.Errorf("credenciales inválidas")
+	}
+
+	if !user.IsActive {
+		return nil, fmt.Errorf("usuario inactivo")
+	}
+
+	if s.requireVerified && !user.IsVerified {
+		return nil, fmt.Errorf("email no verificado")
+	}
+
+	return user, nil
+}
+
+// challengeIfNeeded emite un desafío de dos factores si el usuario tiene 2FA activado o
+// si su rol lo exige. En el segundo caso el desafío incluye el secreto a registrar.
+func (s *AuthService) challengeIfNeeded(user *models.User, method string) (*models.TwoFactorChallenge, error) {
+	enabled, err := s.twoFactorService.IsEnabled(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge := &models.TwoFactorChallenge{}
+	if !enabled {
+		required, err := s.twoFactorService.IsRequiredForRole(user.Role)
+		if err != nil {
+			return nil, err
+		}
+		if !required {
+			return nil, nil
+		}
+
+		challenge.SetupRequired = true
+		challenge.Enrollment, err = s.twoFactorService.Enroll(user)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	challenge.ChallengeToken, challenge.ExpiresAt, err = s.tokens.GenerateChallenge(user.ID, method, s.challengeTTL)
+	if err != nil {
+		s.logger.Errorf("Error generando desafío de dos factores: %v", err)
+		return nil, err
+	}
+
+	return challenge, nil
+}
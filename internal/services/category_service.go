@@ -3,12 +3,17 @@ package services
 import (
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/pkg/slug"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
 
+// maxCategorySlugLength es la longitud de la columna categories.slug
+const maxCategorySlugLength = 100
+
 // CategoryService maneja la lógica de negocio para categorías
 type CategoryService struct {
 	db     *sql.DB
@@ -28,7 +33,7 @@ func (s *CategoryService) GetAllCategories() ([]models.Category, error) {
 	query := `
 		SELECT id, name, description, slug, is_active, created_at, updated_at
 		FROM categories 
-		WHERE is_active = true
+		WHERE is_active = true AND deleted_at IS NULL
 		ORDER BY name
 	`
 
@@ -61,7 +66,7 @@ func (s *CategoryService) GetCategoryByID(id uuid.UUID) (*models.Category, error
 	query := `
 		SELECT id, name, description, slug, is_active, created_at, updated_at
 		FROM categories 
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	var category models.Category
@@ -86,7 +91,7 @@ func (s *CategoryService) GetCategoryBySlug(slug string) (*models.Category, erro
 	query := `
 		SELECT id, name, description, slug, is_active, created_at, updated_at
 		FROM categories 
-		WHERE slug = $1
+		WHERE slug = $1 AND deleted_at IS NULL
 	`
 
 	var category models.Category
@@ -116,9 +121,10 @@ func (s *CategoryService) GetCategoryWithPosts(id uuid.UUID) (*models.Category,
 	// Obtener posts de la categoría
 	postsQuery := `
 		SELECT p.id, p.title, p.slug, p.content, p.excerpt, p.author_id, p.category_id,
-		       p.status, p.published_at, p.created_at, p.updated_at
+		       p.status, p.published_at, p.scheduled_at, p.created_at, p.updated_at,
+		       p.featured_image_id, p.content_html, p.toc, p.word_count, p.reading_time, p.render_version
 		FROM posts p
-		WHERE p.category_id = $1 AND p.status = 'published'
+		WHERE p.category_id = $1 AND p.status = 'published' AND p.deleted_at IS NULL
 		ORDER BY p.published_at DESC
 	`
 
@@ -132,15 +138,21 @@ func (s *CategoryService) GetCategoryWithPosts(id uuid.UUID) (*models.Category,
 	var posts []models.Post
 	for rows.Next() {
 		var post models.Post
+		var render postRender
 		err := rows.Scan(
 			&post.ID, &post.Title, &post.Slug, &post.Content, &post.Excerpt,
-			&post.AuthorID, &post.CategoryID, &post.Status, &post.PublishedAt,
+			&post.AuthorID, &post.CategoryID, &post.Status, &post.PublishedAt, &post.ScheduledAt,
 			&post.CreatedAt, &post.UpdatedAt,
+			&post.FeaturedImageID, &render.html, &render.toc, &render.words, &render.minutes, &render.version,
 		)
 		if err != nil {
 			s.logger.Errorf("Error escaneando post: %v", err)
 			continue
 		}
+		if err := render.apply(&post); err != nil {
+			s.logger.Errorf("Error renderizando post: %v", err)
+			continue
+		}
 		posts = append(posts, post)
 	}
 
@@ -148,18 +160,33 @@ func (s *CategoryService) GetCategoryWithPosts(id uuid.UUID) (*models.Category,
 	return category, nil
 }
 
-// CreateCategory crea una nueva categoría
+// CreateCategory crea una nueva categoría. Si otra petición ocupa a la vez el slug elegido se
+// reintenta con uno nuevo.
 func (s *CategoryService) CreateCategory(req models.CategoryCreateRequest) (*models.Category, error) {
-	// Verificar si el nombre ya existe
-	existingCategory, _ := s.GetCategoryBySlug(req.Slug)
-	if existingCategory != nil {
-		return nil, fmt.Errorf("el slug de categoría ya existe")
+	var category *models.Category
+	err := retrySlugConflict(s.logger, "categories_slug_key", func() error {
+		var err error
+		category, err = s.insertCategory(s.db, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Generar slug si no se proporciona
-	slug := req.Slug
-	if slug == "" {
-		slug = req.Name
+	return category, nil
+}
+
+// CreateCategoryTx crea una nueva categoría dentro de una transacción
+func (s *CategoryService) CreateCategoryTx(tx *sql.Tx, req models.CategoryCreateRequest) (*models.Category, error) {
+	return s.insertCategory(tx, req)
+}
+
+// insertCategory inserta una categoría usando la conexión o transacción recibida
+func (s *CategoryService) insertCategory(q queryRower, req models.CategoryCreateRequest) (*models.Category, error) {
+	// Un slug explícito debe estar libre; uno generado desde el nombre recibe un sufijo numérico
+	categorySlug, err := s.resolveSlug(req.Slug, req.Name, uuid.Nil)
+	if err != nil {
+		return nil, err
 	}
 
 	query := `
@@ -169,21 +196,24 @@ func (s *CategoryService) CreateCategory(req models.CategoryCreateRequest) (*mod
 	`
 
 	var category models.Category
-	err := s.db.QueryRow(query, req.Name, req.Description, slug).Scan(
+	err = q.QueryRow(query, req.Name, req.Description, categorySlug).Scan(
 		&category.ID, &category.Name, &category.Description, &category.Slug,
 		&category.IsActive, &category.CreatedAt, &category.UpdatedAt,
 	)
 
 	if err != nil {
-		s.logger.Errorf("Error creando categoría: %v", err)
+		if !isConstraintViolation(err, "categories_slug_key") {
+			s.logger.Errorf("Error creando categoría: %v", err)
+		}
 		return nil, err
 	}
 
 	return &category, nil
 }
 
-// UpdateCategory actualiza una categoría existente
-func (s *CategoryService) UpdateCategory(id uuid.UUID, req models.CategoryUpdateRequest) (*models.Category, error) {
+// UpdateCategory actualiza una categoría existente. ifMatch condiciona la actualización a la
+// versión de la categoría (ver versionsParam).
+func (s *CategoryService) UpdateCategory(id uuid.UUID, req models.CategoryUpdateRequest, ifMatch []time.Time) (*models.Category, error) {
 	// Verificar que la categoría existe
 	existingCategory, err := s.GetCategoryByID(id)
 	if err != nil {
@@ -198,7 +228,10 @@ func (s *CategoryService) UpdateCategory(id uuid.UUID, req models.CategoryUpdate
 		existingCategory.Description = req.Description
 	}
 	if req.Slug != "" {
-		existingCategory.Slug = req.Slug
+		existingCategory.Slug, err = s.resolveSlug(req.Slug, "", id)
+		if err != nil {
+			return nil, err
+		}
 	}
 	if req.IsActive != nil {
 		existingCategory.IsActive = *req.IsActive
@@ -207,18 +240,27 @@ func (s *CategoryService) UpdateCategory(id uuid.UUID, req models.CategoryUpdate
 	query := `
 		UPDATE categories 
 		SET name = $1, description = $2, slug = $3, is_active = $4, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $5
+		WHERE id = $5 AND deleted_at IS NULL AND ($6::timestamptz[] IS NULL OR updated_at = ANY($6::timestamptz[]))
 		RETURNING id, name, description, slug, is_active, created_at, updated_at
 	`
 
 	var category models.Category
 	err = s.db.QueryRow(query, existingCategory.Name, existingCategory.Description,
-		existingCategory.Slug, existingCategory.IsActive, id).Scan(
+		existingCategory.Slug, existingCategory.IsActive, id, versionsParam(ifMatch)).Scan(
 		&category.ID, &category.Name, &category.Description, &category.Slug,
 		&category.IsActive, &category.CreatedAt, &category.UpdatedAt,
 	)
 
 	if err != nil {
+		if err == sql.ErrNoRows {
+			if ifMatch != nil {
+				return nil, fmt.Errorf("el recurso fue modificado por otra petición")
+			}
+			return nil, fmt.Errorf("categoría no encontrada")
+		}
+		if isConstraintViolation(err, "categories_slug_key") {
+			return nil, fmt.Errorf("el slug de categoría ya existe")
+		}
 		s.logger.Errorf("Error actualizando categoría: %v", err)
 		return nil, err
 	}
@@ -226,11 +268,41 @@ func (s *CategoryService) UpdateCategory(id uuid.UUID, req models.CategoryUpdate
 	return &category, nil
 }
 
-// DeleteCategory elimina una categoría
+// resolveSlug normaliza el slug explícito y verifica que no lo use otra categoría o, si no
+// se proporciona, genera uno único a partir del nombre
+func (s *CategoryService) resolveSlug(explicit, name string, excludeID uuid.UUID) (string, error) {
+	exists := func(candidate string) (bool, error) {
+		var taken bool
+		err := s.db.QueryRow(
+			"SELECT EXISTS (SELECT 1 FROM categories WHERE slug = $1 AND id <> $2)", candidate, excludeID,
+		).Scan(&taken)
+		if err != nil {
+			s.logger.Errorf("Error verificando slug de categoría: %v", err)
+		}
+		return taken, err
+	}
+
+	if explicit == "" {
+		return slug.Unique(slug.Make(name), maxCategorySlugLength, exists)
+	}
+
+	categorySlug := slug.Truncate(slug.Make(explicit), maxCategorySlugLength)
+	taken, err := exists(categorySlug)
+	if err != nil {
+		return "", err
+	}
+	if taken {
+		return "", fmt.Errorf("el slug de categoría ya existe")
+	}
+
+	return categorySlug, nil
+}
+
+// DeleteCategory mueve una categoría a la papelera. Los posts en la papelera no impiden eliminarla.
 func (s *CategoryService) DeleteCategory(id uuid.UUID) error {
 	// Verificar si hay posts asociados
 	var count int
-	err := s.db.QueryRow("SELECT COUNT(*) FROM posts WHERE category_id = $1", id).Scan(&count)
+	err := s.db.QueryRow("SELECT COUNT(*) FROM posts WHERE category_id = $1 AND deleted_at IS NULL", id).Scan(&count)
 	if err != nil {
 		s.logger.Errorf("Error verificando posts de categoría: %v", err)
 		return err
@@ -240,7 +312,7 @@ func (s *CategoryService) DeleteCategory(id uuid.UUID) error {
 		return fmt.Errorf("no se puede eliminar la categoría porque tiene posts asociados")
 	}
 
-	query := "DELETE FROM categories WHERE id = $1"
+	query := "UPDATE categories SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL"
 
 	result, err := s.db.Exec(query, id)
 	if err != nil {
@@ -259,3 +331,82 @@ func (s *CategoryService) DeleteCategory(id uuid.UUID) error {
 
 	return nil
 }
+
+// GetDeletedCategories obtiene las categorías de la papelera, de la eliminada más
+// recientemente a la más antigua
+func (s *CategoryService) GetDeletedCategories() ([]models.Category, error) {
+	query := `
+		SELECT id, name, description, slug, is_active, created_at, updated_at, deleted_at
+		FROM categories
+		WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+	`
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		s.logger.Errorf("Error obteniendo categorías de la papelera: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []models.Category{}
+	for rows.Next() {
+		var category models.Category
+		err := rows.Scan(
+			&category.ID, &category.Name, &category.Description, &category.Slug,
+			&category.IsActive, &category.CreatedAt, &category.UpdatedAt, &category.DeletedAt,
+		)
+		if err != nil {
+			s.logger.Errorf("Error escaneando categoría de la papelera: %v", err)
+			continue
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, nil
+}
+
+// RestoreCategory saca una categoría de la papelera
+func (s *CategoryService) RestoreCategory(id uuid.UUID) (*models.Category, error) {
+	query := `
+		UPDATE categories SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND deleted_at IS NOT NULL
+		RETURNING id, name, description, slug, is_active, created_at, updated_at
+	`
+
+	var category models.Category
+	err := s.db.QueryRow(query, id).Scan(
+		&category.ID, &category.Name, &category.Description, &category.Slug,
+		&category.IsActive, &category.CreatedAt, &category.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("categoría no encontrada en la papelera")
+		}
+		s.logger.Errorf("Error restaurando categoría: %v", err)
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+// PurgeCategory elimina definitivamente una categoría de la papelera. Los posts que aún la
+// referencian quedan sin categoría.
+func (s *CategoryService) PurgeCategory(id uuid.UUID) error {
+	result, err := s.db.Exec("DELETE FROM categories WHERE id = $1 AND deleted_at IS NOT NULL", id)
+	if err != nil {
+		s.logger.Errorf("Error purgando categoría: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("categoría no encontrada en la papelera")
+	}
+
+	return nil
+}
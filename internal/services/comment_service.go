@@ -3,9 +3,13 @@ package services
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/pkg/cursor"
+	"github.com/alan.bermudez/goasync/pkg/markdown"
+	"github.com/alan.bermudez/goasync/pkg/rbac"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
@@ -30,7 +34,11 @@ func (s *CommentService) GetCommentsByPostID(postID uuid.UUID, page, perPage int
 
 	// Obtener total de comentarios
 	var total int
-	err := s.db.QueryRow("SELECT COUNT(*) FROM comments WHERE post_id = $1 AND is_approved = true", postID).Scan(&total)
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM comments
+		WHERE post_id = $1 AND is_approved = true AND deleted_at IS NULL
+		  AND EXISTS (SELECT 1 FROM posts WHERE id = $1 AND deleted_at IS NULL)
+	`, postID).Scan(&total)
 	if err != nil {
 		s.logger.Errorf("Error contando comentarios: %v", err)
 		return nil, err
@@ -39,11 +47,12 @@ func (s *CommentService) GetCommentsByPostID(postID uuid.UUID, page, perPage int
 	// Obtener comentarios principales (sin parent_id)
 	query := `
 		SELECT c.id, c.post_id, c.author_id, c.parent_id, c.content, c.is_approved, 
-		       c.created_at, c.updated_at,
+		       c.created_at, c.updated_at, c.content_html, c.render_version,
 		       u.username as author_username, u.first_name as author_first_name, u.last_name as author_last_name
 		FROM comments c
 		LEFT JOIN users u ON c.author_id = u.id
-		WHERE c.post_id = $1 AND c.parent_id IS NULL AND c.is_approved = true
+		WHERE c.post_id = $1 AND c.parent_id IS NULL AND c.is_approved = true AND c.deleted_at IS NULL
+		  AND EXISTS (SELECT 1 FROM posts WHERE id = $1 AND deleted_at IS NULL)
 		ORDER BY c.created_at DESC
 		LIMIT $2 OFFSET $3
 	`
@@ -59,16 +68,22 @@ func (s *CommentService) GetCommentsByPostID(postID uuid.UUID, page, perPage int
 	for rows.Next() {
 		var comment models.Comment
 		var authorUsername, authorFirstName, authorLastName sql.NullString
+		var render commentRender
 
 		err := rows.Scan(
 			&comment.ID, &comment.PostID, &comment.AuthorID, &comment.ParentID,
 			&comment.Content, &comment.IsApproved, &comment.CreatedAt, &comment.UpdatedAt,
+			&render.html, &render.version,
 			&authorUsername, &authorFirstName, &authorLastName,
 		)
 		if err != nil {
 			s.logger.Errorf("Error escaneando comentario: %v", err)
 			continue
 		}
+		if err := render.apply(&comment); err != nil {
+			s.logger.Errorf("Error renderizando comentario: %v", err)
+			continue
+		}
 
 		// Construir autor
 		if authorUsername.Valid {
@@ -103,23 +118,81 @@ func (s *CommentService) GetCommentsByPostID(postID uuid.UUID, page, perPage int
 	}, nil
 }
 
+// GetApprovedCommentsByPostID obtiene todos los comentarios aprobados de un post sin anidar,
+// del más antiguo al más reciente, de modo que cada respuesta aparece después de su padre
+func (s *CommentService) GetApprovedCommentsByPostID(postID uuid.UUID) ([]models.Comment, error) {
+	query := `
+		SELECT c.id, c.post_id, c.author_id, c.parent_id, c.content, c.is_approved,
+		       c.created_at, c.updated_at, c.content_html, c.render_version,
+		       u.username as author_username, u.first_name as author_first_name, u.last_name as author_last_name
+		FROM comments c
+		LEFT JOIN users u ON c.author_id = u.id
+		WHERE c.post_id = $1 AND c.is_approved = true AND c.deleted_at IS NULL
+		ORDER BY c.created_at, c.id
+	`
+
+	rows, err := s.db.Query(query, postID)
+	if err != nil {
+		s.logger.Errorf("Error obteniendo comentarios aprobados: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []models.Comment
+	for rows.Next() {
+		var comment models.Comment
+		var authorUsername, authorFirstName, authorLastName sql.NullString
+		var render commentRender
+
+		err := rows.Scan(
+			&comment.ID, &comment.PostID, &comment.AuthorID, &comment.ParentID,
+			&comment.Content, &comment.IsApproved, &comment.CreatedAt, &comment.UpdatedAt,
+			&render.html, &render.version,
+			&authorUsername, &authorFirstName, &authorLastName,
+		)
+		if err != nil {
+			s.logger.Errorf("Error escaneando comentario: %v", err)
+			continue
+		}
+		if err := render.apply(&comment); err != nil {
+			s.logger.Errorf("Error renderizando comentario: %v", err)
+			continue
+		}
+
+		if authorUsername.Valid {
+			comment.Author = &models.User{
+				ID:        comment.AuthorID,
+				Username:  authorUsername.String,
+				FirstName: authorFirstName.String,
+				LastName:  authorLastName.String,
+			}
+		}
+
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
+}
+
 // GetCommentByID obtiene un comentario por su ID
 func (s *CommentService) GetCommentByID(id uuid.UUID) (*models.Comment, error) {
 	query := `
 		SELECT c.id, c.post_id, c.author_id, c.parent_id, c.content, c.is_approved, 
-		       c.created_at, c.updated_at,
+		       c.created_at, c.updated_at, c.content_html, c.render_version,
 		       u.username as author_username, u.first_name as author_first_name, u.last_name as author_last_name
 		FROM comments c
 		LEFT JOIN users u ON c.author_id = u.id
-		WHERE c.id = $1
+		WHERE c.id = $1 AND c.deleted_at IS NULL
 	`
 
 	var comment models.Comment
 	var authorUsername, authorFirstName, authorLastName sql.NullString
+	var render commentRender
 
 	err := s.db.QueryRow(query, id).Scan(
 		&comment.ID, &comment.PostID, &comment.AuthorID, &comment.ParentID,
 		&comment.Content, &comment.IsApproved, &comment.CreatedAt, &comment.UpdatedAt,
+		&render.html, &render.version,
 		&authorUsername, &authorFirstName, &authorLastName,
 	)
 
@@ -131,6 +204,11 @@ func (s *CommentService) GetCommentByID(id uuid.UUID) (*models.Comment, error) {
 		return nil, err
 	}
 
+	if err := render.apply(&comment); err != nil {
+		s.logger.Errorf("Error renderizando comentario: %v", err)
+		return nil, err
+	}
+
 	// Construir autor
 	if authorUsername.Valid {
 		comment.Author = &models.User{
@@ -144,14 +222,15 @@ func (s *CommentService) GetCommentByID(id uuid.UUID) (*models.Comment, error) {
 	return &comment, nil
 }
 
-// GetAllComments obtiene todos los comentarios con filtros
-func (s *CommentService) GetAllComments(page, perPage int, approvedOnly bool) (*models.CommentListResponse, error) {
+// GetAllComments obtiene todos los comentarios con filtros. Si pageCursor no es nil se
+// pagina por cursor sobre (created_at, id) en lugar de page/perPage.
+func (s *CommentService) GetAllComments(page, perPage int, approvedOnly bool, pageCursor *cursor.Page) (*models.CommentListResponse, error) {
 	offset := (page - 1) * perPage
 
 	// Construir query base
 	baseQuery := `
 		SELECT c.id, c.post_id, c.author_id, c.parent_id, c.content, c.is_approved, 
-		       c.created_at, c.updated_at,
+		       c.created_at, c.updated_at, c.content_html, c.render_version,
 		       u.username as author_username, u.first_name as author_first_name, u.last_name as author_last_name,
 		       p.title as post_title, p.slug as post_slug
 		FROM comments c
@@ -159,29 +238,57 @@ func (s *CommentService) GetAllComments(page, perPage int, approvedOnly bool) (*
 		LEFT JOIN posts p ON c.post_id = p.id
 	`
 
-	whereClause := ""
+	// Se excluyen los comentarios en la papelera y los de posts en la papelera
+	whereConditions := []string{"c.deleted_at IS NULL", "p.deleted_at IS NULL"}
+	args := []interface{}{}
 	if approvedOnly {
-		whereClause = "WHERE c.is_approved = true"
+		whereConditions = append(whereConditions, "c.is_approved = true")
+	}
+
+	if pageCursor != nil {
+		arg := func(v interface{}) string {
+			args = append(args, v)
+			return fmt.Sprintf("$%d", len(args))
+		}
+		if condition := pageCursor.Where("c.created_at", "c.id", arg); condition != "" {
+			whereConditions = append(whereConditions, condition)
+		}
 	}
 
-	// Query para contar total
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM comments c %s", whereClause)
+	whereClause := "WHERE " + strings.Join(whereConditions, " AND ")
+
+	// Query para contar total; el modo cursor lo omite para no recorrer toda la tabla
 	var total int
-	err := s.db.QueryRow(countQuery).Scan(&total)
-	if err != nil {
-		s.logger.Errorf("Error contando comentarios: %v", err)
-		return nil, err
+	if pageCursor == nil {
+		countQuery := fmt.Sprintf("SELECT COUNT(*) FROM comments c LEFT JOIN posts p ON c.post_id = p.id %s", whereClause)
+		err := s.db.QueryRow(countQuery, args...).Scan(&total)
+		if err != nil {
+			s.logger.Errorf("Error contando comentarios: %v", err)
+			return nil, err
+		}
 	}
 
 	// Query para obtener comentarios
-	query := fmt.Sprintf(`
-		%s
-		%s
-		ORDER BY c.created_at DESC
-		LIMIT $1 OFFSET $2
-	`, baseQuery, whereClause)
+	var query string
+	if pageCursor != nil {
+		args = append(args, pageCursor.Fetch())
+		query = fmt.Sprintf(`
+			%s
+			%s
+			ORDER BY %s
+			LIMIT $%d
+		`, baseQuery, whereClause, pageCursor.OrderBy("c.created_at", "c.id"), len(args))
+	} else {
+		args = append(args, perPage, offset)
+		query = fmt.Sprintf(`
+			%s
+			%s
+			ORDER BY c.created_at DESC
+			LIMIT $%d OFFSET $%d
+		`, baseQuery, whereClause, len(args)-1, len(args))
+	}
 
-	rows, err := s.db.Query(query, perPage, offset)
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		s.logger.Errorf("Error obteniendo comentarios: %v", err)
 		return nil, err
@@ -192,11 +299,13 @@ func (s *CommentService) GetAllComments(page, perPage int, approvedOnly bool) (*
 	for rows.Next() {
 		var comment models.Comment
 		var authorUsername, authorFirstName, authorLastName sql.NullString
+		var render commentRender
 		var postTitle, postSlug sql.NullString
 
 		err := rows.Scan(
 			&comment.ID, &comment.PostID, &comment.AuthorID, &comment.ParentID,
 			&comment.Content, &comment.IsApproved, &comment.CreatedAt, &comment.UpdatedAt,
+			&render.html, &render.version,
 			&authorUsername, &authorFirstName, &authorLastName,
 			&postTitle, &postSlug,
 		)
@@ -204,6 +313,10 @@ func (s *CommentService) GetAllComments(page, perPage int, approvedOnly bool) (*
 			s.logger.Errorf("Error escaneando comentario: %v", err)
 			continue
 		}
+		if err := render.apply(&comment); err != nil {
+			s.logger.Errorf("Error renderizando comentario: %v", err)
+			continue
+		}
 
 		// Construir autor
 		if authorUsername.Valid {
@@ -227,6 +340,19 @@ func (s *CommentService) GetAllComments(page, perPage int, approvedOnly bool) (*
 		comments = append(comments, comment)
 	}
 
+	if pageCursor != nil {
+		comments, next, prev := cursor.Trim(pageCursor, comments, func(c models.Comment) cursor.Cursor {
+			return cursor.New(&c.CreatedAt, c.ID)
+		})
+
+		return &models.CommentListResponse{
+			Comments:   comments,
+			PerPage:    pageCursor.Limit,
+			NextCursor: next,
+			PrevCursor: prev,
+		}, nil
+	}
+
 	// Calcular total de páginas
 	totalPages := (total + perPage - 1) / perPage
 
@@ -243,7 +369,7 @@ func (s *CommentService) GetAllComments(page, perPage int, approvedOnly bool) (*
 func (s *CommentService) CreateComment(req models.CommentCreateRequest, authorID uuid.UUID) (*models.Comment, error) {
 	// Verificar que el post existe y está publicado
 	var postStatus string
-	err := s.db.QueryRow("SELECT status FROM posts WHERE id = $1", req.PostID).Scan(&postStatus)
+	err := s.db.QueryRow("SELECT status FROM posts WHERE id = $1 AND deleted_at IS NULL", req.PostID).Scan(&postStatus)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("post no encontrado")
@@ -256,10 +382,39 @@ func (s *CommentService) CreateComment(req models.CommentCreateRequest, authorID
 		return nil, fmt.Errorf("no se pueden agregar comentarios a posts no publicados")
 	}
 
+	// Por defecto, los comentarios no están aprobados
+	return s.insertComment(s.db, req, &authorID, false, nil)
+}
+
+// ImportComment crea un comentario importado desde otra plataforma dentro de una transacción,
+// con su estado de aprobación y su fecha original. A diferencia de CreateComment admite posts
+// no publicados.
+func (s *CommentService) ImportComment(tx *sql.Tx, req models.CommentImportRequest) (*models.Comment, error) {
+	var postExists bool
+	err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM posts WHERE id = $1 AND deleted_at IS NULL)", req.PostID).Scan(&postExists)
+	if err != nil {
+		s.logger.Errorf("Error verificando post: %v", err)
+		return nil, err
+	}
+	if !postExists {
+		return nil, fmt.Errorf("post no encontrado")
+	}
+
+	var createdAt *time.Time
+	if !req.CreatedAt.IsZero() {
+		createdAt = &req.CreatedAt
+	}
+
+	return s.insertComment(tx, req.CommentCreateRequest, req.AuthorID, req.IsApproved, createdAt)
+}
+
+// insertComment verifica el comentario padre e inserta el comentario usando la conexión o
+// transacción recibida. Sin createdAt se usa la fecha actual.
+func (s *CommentService) insertComment(q queryRower, req models.CommentCreateRequest, authorID *uuid.UUID, isApproved bool, createdAt *time.Time) (*models.Comment, error) {
 	// Verificar parent_id si se proporciona
 	if req.ParentID != nil {
 		var parentExists bool
-		err := s.db.QueryRow("SELECT EXISTS(SELECT 1 FROM comments WHERE id = $1 AND post_id = $2)",
+		err := q.QueryRow("SELECT EXISTS(SELECT 1 FROM comments WHERE id = $1 AND post_id = $2 AND deleted_at IS NULL)",
 			req.ParentID, req.PostID).Scan(&parentExists)
 		if err != nil {
 			s.logger.Errorf("Error verificando comentario padre: %v", err)
@@ -270,37 +425,59 @@ func (s *CommentService) CreateComment(req models.CommentCreateRequest, authorID
 		}
 	}
 
+	// Renderizar el Markdown para guardarlo junto al contenido
+	doc, err := markdown.Render(req.Content)
+	if err != nil {
+		s.logger.Errorf("Error renderizando comentario: %v", err)
+		return nil, err
+	}
+
 	query := `
-		INSERT INTO comments (post_id, author_id, parent_id, content, is_approved)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, post_id, author_id, parent_id, content, is_approved, created_at, updated_at
+		INSERT INTO comments (post_id, author_id, parent_id, content, is_approved, content_html, render_version,
+		                      created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, COALESCE($8, NOW()), COALESCE($8, NOW()))
+		RETURNING id, post_id, author_id, parent_id, content, is_approved, created_at, updated_at,
+		          content_html, render_version
 	`
 
-	// Por defecto, los comentarios no están aprobados
-	isApproved := false
-
 	var comment models.Comment
-	err = s.db.QueryRow(query, req.PostID, authorID, req.ParentID, req.Content, isApproved).Scan(
+	var render commentRender
+	err = q.QueryRow(query, req.PostID, authorID, req.ParentID, req.Content, isApproved,
+		doc.HTML, markdown.Version, createdAt).Scan(
 		&comment.ID, &comment.PostID, &comment.AuthorID, &comment.ParentID,
 		&comment.Content, &comment.IsApproved, &comment.CreatedAt, &comment.UpdatedAt,
+		&render.html, &render.version,
 	)
 
 	if err != nil {
 		s.logger.Errorf("Error creando comentario: %v", err)
 		return nil, err
 	}
+	comment.ContentHTML = render.html.String
 
 	return &comment, nil
 }
 
-// UpdateComment actualiza un comentario existente
-func (s *CommentService) UpdateComment(id uuid.UUID, req models.CommentUpdateRequest) (*models.Comment, error) {
+// UpdateComment actualiza un comentario existente. Solo moderadores pueden editar
+// comentarios ajenos o cambiar su estado de aprobación. ifMatch condiciona la actualización
+// a la versión del comentario (ver versionsParam).
+func (s *CommentService) UpdateComment(id uuid.UUID, req models.CommentUpdateRequest, ifMatch []time.Time, actorID uuid.UUID, actorRole string) (*models.Comment, error) {
 	// Verificar que el comentario existe
 	existingComment, err := s.GetCommentByID(id)
 	if err != nil {
 		return nil, err
 	}
 
+	// Verificar propiedad del comentario
+	if !rbac.IsModerator(actorRole) {
+		if existingComment.AuthorID != actorID {
+			return nil, fmt.Errorf("no tienes permiso para modificar este comentario")
+		}
+		if req.IsApproved != nil {
+			return nil, fmt.Errorf("no tienes permiso para aprobar comentarios")
+		}
+	}
+
 	// Actualizar campos
 	if req.Content != "" {
 		existingComment.Content = req.Content
@@ -309,30 +486,64 @@ func (s *CommentService) UpdateComment(id uuid.UUID, req models.CommentUpdateReq
 		existingComment.IsApproved = *req.IsApproved
 	}
 
+	doc, err := markdown.Render(existingComment.Content)
+	if err != nil {
+		s.logger.Errorf("Error renderizando comentario: %v", err)
+		return nil, err
+	}
+
 	query := `
 		UPDATE comments 
-		SET content = $1, is_approved = $2, updated_at = $3
-		WHERE id = $4
-		RETURNING id, post_id, author_id, parent_id, content, is_approved, created_at, updated_at
+		SET content = $1, is_approved = $2, updated_at = $3, content_html = $6, render_version = $7
+		WHERE id = $4 AND deleted_at IS NULL AND ($5::timestamptz[] IS NULL OR updated_at = ANY($5::timestamptz[]))
+		RETURNING id, post_id, author_id, parent_id, content, is_approved, created_at, updated_at,
+		          content_html, render_version
 	`
 
 	var comment models.Comment
-	err = s.db.QueryRow(query, existingComment.Content, existingComment.IsApproved, time.Now(), id).Scan(
+	var render commentRender
+	err = s.db.QueryRow(query, existingComment.Content, existingComment.IsApproved, time.Now(), id,
+		versionsParam(ifMatch), doc.HTML, markdown.Version).Scan(
 		&comment.ID, &comment.PostID, &comment.AuthorID, &comment.ParentID,
 		&comment.Content, &comment.IsApproved, &comment.CreatedAt, &comment.UpdatedAt,
+		&render.html, &render.version,
 	)
 
 	if err != nil {
+		if err == sql.ErrNoRows {
+			if ifMatch != nil {
+				return nil, fmt.Errorf("el recurso fue modificado por otra petición")
+			}
+			return nil, fmt.Errorf("comentario no encontrado")
+		}
 		s.logger.Errorf("Error actualizando comentario: %v", err)
 		return nil, err
 	}
+	comment.ContentHTML = render.html.String
 
 	return &comment, nil
 }
 
-// DeleteComment elimina un comentario
-func (s *CommentService) DeleteComment(id uuid.UUID) error {
-	query := "DELETE FROM comments WHERE id = $1"
+// DeleteComment mueve un comentario a la papelera. Sus respuestas se conservan y vuelven a
+// mostrarse si se restaura. Solo moderadores pueden eliminar comentarios ajenos.
+func (s *CommentService) DeleteComment(id uuid.UUID, actorID uuid.UUID, actorRole string) error {
+	// Verificar propiedad del comentario
+	if !rbac.IsModerator(actorRole) {
+		var authorID uuid.NullUUID
+		err := s.db.QueryRow("SELECT author_id FROM comments WHERE id = $1 AND deleted_at IS NULL", id).Scan(&authorID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("comentario no encontrado")
+			}
+			s.logger.Errorf("Error verificando autor del comentario: %v", err)
+			return err
+		}
+		if !authorID.Valid || authorID.UUID != actorID {
+			return fmt.Errorf("no tienes permiso para eliminar este comentario")
+		}
+	}
+
+	query := "UPDATE comments SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL"
 
 	result, err := s.db.Exec(query, id)
 	if err != nil {
@@ -352,11 +563,157 @@ func (s *CommentService) DeleteComment(id uuid.UUID) error {
 	return nil
 }
 
-// ApproveComment aprueba un comentario
-func (s *CommentService) ApproveComment(id uuid.UUID) error {
-	query := "UPDATE comments SET is_approved = true, updated_at = $1 WHERE id = $2"
+// GetDeletedComments obtiene los comentarios de la papelera, del eliminado más recientemente
+// al más antiguo. Si authorID no es uuid.Nil solo incluye los comentarios de ese autor.
+func (s *CommentService) GetDeletedComments(page, perPage int, authorID uuid.UUID) (*models.CommentListResponse, error) {
+	offset := (page - 1) * perPage
+
+	var author interface{}
+	if authorID != uuid.Nil {
+		author = authorID
+	}
+
+	var total int
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM comments WHERE deleted_at IS NOT NULL AND ($1::uuid IS NULL OR author_id = $1)
+	`, author).Scan(&total)
+	if err != nil {
+		s.logger.Errorf("Error contando comentarios de la papelera: %v", err)
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, post_id, author_id, parent_id, content, is_approved, created_at, updated_at, deleted_at,
+		       content_html, render_version
+		FROM comments
+		WHERE deleted_at IS NOT NULL AND ($1::uuid IS NULL OR author_id = $1)
+		ORDER BY deleted_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`, author, perPage, offset)
+	if err != nil {
+		s.logger.Errorf("Error obteniendo comentarios de la papelera: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []models.Comment{}
+	for rows.Next() {
+		var comment models.Comment
+		var render commentRender
+		err := rows.Scan(
+			&comment.ID, &comment.PostID, &comment.AuthorID, &comment.ParentID,
+			&comment.Content, &comment.IsApproved, &comment.CreatedAt, &comment.UpdatedAt, &comment.DeletedAt,
+			&render.html, &render.version,
+		)
+		if err != nil {
+			s.logger.Errorf("Error escaneando comentario de la papelera: %v", err)
+			continue
+		}
+		if err := render.apply(&comment); err != nil {
+			s.logger.Errorf("Error renderizando comentario de la papelera: %v", err)
+			continue
+		}
+		comments = append(comments, comment)
+	}
+
+	return &models.CommentListResponse{
+		Comments:   comments,
+		Total:      total,
+		Page:       page,
+		PerPage:    perPage,
+		TotalPages: (total + perPage - 1) / perPage,
+	}, nil
+}
+
+// RestoreComment saca un comentario de la papelera. Solo moderadores pueden restaurar
+// comentarios ajenos.
+func (s *CommentService) RestoreComment(id uuid.UUID, actorID uuid.UUID, actorRole string) (*models.Comment, error) {
+	if !rbac.IsModerator(actorRole) {
+		var authorID uuid.NullUUID
+		err := s.db.QueryRow("SELECT author_id FROM comments WHERE id = $1 AND deleted_at IS NOT NULL", id).Scan(&authorID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, fmt.Errorf("comentario no encontrado en la papelera")
+			}
+			s.logger.Errorf("Error verificando autor del comentario: %v", err)
+			return nil, err
+		}
+		if !authorID.Valid || authorID.UUID != actorID {
+			return nil, fmt.Errorf("no tienes permiso para restaurar este comentario")
+		}
+	}
+
+	query := `
+		UPDATE comments SET deleted_at = NULL
+		WHERE id = $1 AND deleted_at IS NOT NULL
+		RETURNING id, post_id, author_id, parent_id, content, is_approved, created_at, updated_at,
+		          content_html, render_version
+	`
+
+	var comment models.Comment
+	var render commentRender
+	err := s.db.QueryRow(query, id).Scan(
+		&comment.ID, &comment.PostID, &comment.AuthorID, &comment.ParentID,
+		&comment.Content, &comment.IsApproved, &comment.CreatedAt, &comment.UpdatedAt,
+		&render.html, &render.version,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("comentario no encontrado en la papelera")
+		}
+		s.logger.Errorf("Error restaurando comentario: %v", err)
+		return nil, err
+	}
+	if err := render.apply(&comment); err != nil {
+		s.logger.Errorf("Error renderizando comentario: %v", err)
+		return nil, err
+	}
+
+	return &comment, nil
+}
+
+// PurgeComment elimina definitivamente un comentario de la papelera. Sus respuestas no se
+// eliminan: pasan a responder al comentario padre o quedan como comentarios principales.
+func (s *CommentService) PurgeComment(id uuid.UUID) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var parentID uuid.NullUUID
+	err = tx.QueryRow("SELECT parent_id FROM comments WHERE id = $1 AND deleted_at IS NOT NULL FOR UPDATE", id).Scan(&parentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("comentario no encontrado en la papelera")
+		}
+		s.logger.Errorf("Error bloqueando comentario: %v", err)
+		return err
+	}
+
+	// Evitar que ON DELETE CASCADE elimine las respuestas
+	if _, err := tx.Exec("UPDATE comments SET parent_id = $1 WHERE parent_id = $2", parentID, id); err != nil {
+		s.logger.Errorf("Error reasignando respuestas del comentario: %v", err)
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM comments WHERE id = $1", id); err != nil {
+		s.logger.Errorf("Error purgando comentario: %v", err)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ApproveComment aprueba un comentario. ifMatch condiciona la aprobación a la versión del
+// comentario (ver versionsParam).
+func (s *CommentService) ApproveComment(id uuid.UUID, ifMatch []time.Time) error {
+	query := `
+		UPDATE comments SET is_approved = true, updated_at = $1
+		WHERE id = $2 AND deleted_at IS NULL AND ($3::timestamptz[] IS NULL OR updated_at = ANY($3::timestamptz[]))
+	`
 
-	result, err := s.db.Exec(query, time.Now(), id)
+	result, err := s.db.Exec(query, time.Now(), id, versionsParam(ifMatch))
 	if err != nil {
 		s.logger.Errorf("Error aprobando comentario: %v", err)
 		return err
@@ -368,21 +725,37 @@ func (s *CommentService) ApproveComment(id uuid.UUID) error {
 	}
 
 	if rowsAffected == 0 {
+		if ifMatch != nil {
+			return s.versionConflict(id)
+		}
 		return fmt.Errorf("comentario no encontrado")
 	}
 
 	return nil
 }
 
+// versionConflict distingue entre un comentario inexistente y uno cuya versión no coincide
+// con If-Match
+func (s *CommentService) versionConflict(id uuid.UUID) error {
+	var exists bool
+	if err := s.db.QueryRow("SELECT EXISTS (SELECT 1 FROM comments WHERE id = $1 AND deleted_at IS NULL)", id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("comentario no encontrado")
+	}
+	return fmt.Errorf("el recurso fue modificado por otra petición")
+}
+
 // getCommentReplies obtiene las respuestas de un comentario
 func (s *CommentService) getCommentReplies(commentID uuid.UUID) ([]models.Comment, error) {
 	query := `
 		SELECT c.id, c.post_id, c.author_id, c.parent_id, c.content, c.is_approved, 
-		       c.created_at, c.updated_at,
+		       c.created_at, c.updated_at, c.content_html, c.render_version,
 		       u.username as author_username, u.first_name as author_first_name, u.last_name as author_last_name
 		FROM comments c
 		LEFT JOIN users u ON c.author_id = u.id
-		WHERE c.parent_id = $1 AND c.is_approved = true
+		WHERE c.parent_id = $1 AND c.is_approved = true AND c.deleted_at IS NULL
 		ORDER BY c.created_at ASC
 	`
 
@@ -396,16 +769,22 @@ func (s *CommentService) getCommentReplies(commentID uuid.UUID) ([]models.Commen
 	for rows.Next() {
 		var reply models.Comment
 		var authorUsername, authorFirstName, authorLastName sql.NullString
+		var render commentRender
 
 		err := rows.Scan(
 			&reply.ID, &reply.PostID, &reply.AuthorID, &reply.ParentID,
 			&reply.Content, &reply.IsApproved, &reply.CreatedAt, &reply.UpdatedAt,
+			&render.html, &render.version,
 			&authorUsername, &authorFirstName, &authorLastName,
 		)
 		if err != nil {
 			s.logger.Errorf("Error escaneando respuesta: %v", err)
 			continue
 		}
+		if err := render.apply(&reply); err != nil {
+			s.logger.Errorf("Error renderizando respuesta: %v", err)
+			continue
+		}
 
 		// Construir autor
 		if authorUsername.Valid {
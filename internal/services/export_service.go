@@ -0,0 +1,268 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/pkg/archive"
+	"github.com/alan.bermudez/goasync/pkg/cursor"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// exportBatchSize es el número de posts que se leen por consulta durante el export
+const exportBatchSize = cursor.MaxLimit
+
+// ExportService exporta los posts publicados como un sitio estático en Markdown
+type ExportService struct {
+	postService    *PostService
+	tagService     *TagService
+	commentService *CommentService
+	logger         *logrus.Logger
+}
+
+// NewExportService crea una nueva instancia del servicio de export estático
+func NewExportService(postService *PostService, tagService *TagService, commentService *CommentService, logger *logrus.Logger) *ExportService {
+	return &ExportService{
+		postService:    postService,
+		tagService:     tagService,
+		commentService: commentService,
+		logger:         logger,
+	}
+}
+
+// exportLayout describe la estructura de directorios y el front matter de un generador de
+// sitios estáticos
+type exportLayout struct {
+	postPath     func(post *models.Post) string
+	commentsPath func(post *models.Post) string
+	frontMatter  func(post *models.Post, tags []string) interface{}
+}
+
+// hugoFrontMatter es el front matter de un post en Hugo
+type hugoFrontMatter struct {
+	Title      string    `yaml:"title"`
+	Slug       string    `yaml:"slug"`
+	Date       time.Time `yaml:"date"`
+	Lastmod    time.Time `yaml:"lastmod"`
+	Author     string    `yaml:"author,omitempty"`
+	Categories []string  `yaml:"categories,omitempty"`
+	Tags       []string  `yaml:"tags,omitempty"`
+	Summary    string    `yaml:"summary,omitempty"`
+}
+
+// jekyllFrontMatter es el front matter de un post en Jekyll
+type jekyllFrontMatter struct {
+	Layout         string    `yaml:"layout"`
+	Title          string    `yaml:"title"`
+	Slug           string    `yaml:"slug"`
+	Date           time.Time `yaml:"date"`
+	LastModifiedAt time.Time `yaml:"last_modified_at"`
+	Author         string    `yaml:"author,omitempty"`
+	Categories     []string  `yaml:"categories,omitempty"`
+	Tags           []string  `yaml:"tags,omitempty"`
+	Excerpt        string    `yaml:"excerpt,omitempty"`
+}
+
+// exportLayouts son los formatos de export disponibles. Los comentarios se guardan como
+// archivos de datos: .Site.Data.comments en Hugo y site.data.comments en Jekyll, por slug.
+var exportLayouts = map[string]exportLayout{
+	models.ExportFormatHugo: {
+		postPath: func(post *models.Post) string {
+			return "content/posts/" + post.Slug + ".md"
+		},
+		commentsPath: func(post *models.Post) string {
+			return "data/comments/" + post.Slug + ".json"
+		},
+		frontMatter: func(post *models.Post, tags []string) interface{} {
+			return hugoFrontMatter{
+				Title:      post.Title,
+				Slug:       post.Slug,
+				Date:       exportDate(publishedDate(post)),
+				Lastmod:    exportDate(post.UpdatedAt),
+				Author:     exportAuthor(post.Author),
+				Categories: exportCategories(post),
+				Tags:       tags,
+				Summary:    post.Excerpt,
+			}
+		},
+	},
+	models.ExportFormatJekyll: {
+		postPath: func(post *models.Post) string {
+			return "_posts/" + publishedDate(post).UTC().Format("2006-01-02") + "-" + post.Slug + ".md"
+		},
+		commentsPath: func(post *models.Post) string {
+			return "_data/comments/" + post.Slug + ".json"
+		},
+		frontMatter: func(post *models.Post, tags []string) interface{} {
+			return jekyllFrontMatter{
+				Layout:         "post",
+				Title:          post.Title,
+				Slug:           post.Slug,
+				Date:           exportDate(publishedDate(post)),
+				LastModifiedAt: exportDate(post.UpdatedAt),
+				Author:         exportAuthor(post.Author),
+				Categories:     exportCategories(post),
+				Tags:           tags,
+				Excerpt:        post.Excerpt,
+			}
+		},
+	},
+}
+
+// ValidExportFormat indica si format es un formato de export disponible
+func ValidExportFormat(format string) bool {
+	_, ok := exportLayouts[format]
+	return ok
+}
+
+// exportComment es un comentario en el archivo de datos de un post
+type exportComment struct {
+	ID          uuid.UUID  `json:"id"`
+	ParentID    *uuid.UUID `json:"parent_id,omitempty"`
+	Author      string     `json:"author,omitempty"`
+	Content     string     `json:"content"`
+	ContentHTML string     `json:"content_html"`
+	Date        time.Time  `json:"date"`
+}
+
+// ExportStatic escribe los posts publicados como archivos Markdown con front matter YAML en
+// la estructura del formato pedido. Los posts se leen por lotes con paginación por cursor y
+// cada archivo se escribe en cuanto se genera, así que la memoria no depende del número de
+// posts. Si el contexto se cancela el export se detiene.
+func (s *ExportService) ExportStatic(ctx context.Context, w archive.Writer, opts models.ExportOptions) (*models.ExportReport, error) {
+	layout, ok := exportLayouts[opts.Format]
+	if !ok {
+		return nil, fmt.Errorf("formato de export no válido")
+	}
+
+	report := &models.ExportReport{Format: opts.Format}
+	page := &cursor.Page{Limit: exportBatchSize}
+	for {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		response, err := s.postService.GetAllPosts(models.PostFilter{
+			Status: "published",
+			Cursor: page,
+		})
+		if err != nil {
+			return report, err
+		}
+
+		for i := range response.Posts {
+			if err := s.exportPost(w, layout, &response.Posts[i], opts.Comments, report); err != nil {
+				return report, err
+			}
+		}
+
+		if response.NextCursor == "" || len(response.Posts) == 0 {
+			return report, nil
+		}
+		last := response.Posts[len(response.Posts)-1]
+		after := cursor.New(last.PublishedAt, last.ID)
+		page = &cursor.Page{After: &after, Limit: exportBatchSize}
+	}
+}
+
+// exportPost escribe el archivo Markdown de un post y, si se pide, sus comentarios aprobados
+func (s *ExportService) exportPost(w archive.Writer, layout exportLayout, post *models.Post, withComments bool, report *models.ExportReport) error {
+	tags, err := s.tagService.GetTagsByPostID(post.ID)
+	if err != nil {
+		return err
+	}
+	tagNames := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tagNames = append(tagNames, tag.Name)
+	}
+
+	frontMatter, err := yaml.Marshal(layout.frontMatter(post, tagNames))
+	if err != nil {
+		return err
+	}
+
+	var doc bytes.Buffer
+	doc.WriteString("---\n")
+	doc.Write(frontMatter)
+	doc.WriteString("---\n\n")
+	doc.WriteString(strings.TrimSpace(post.Content))
+	doc.WriteString("\n")
+
+	if err := w.WriteFile(layout.postPath(post), doc.Bytes(), post.UpdatedAt); err != nil {
+		s.logger.Errorf("Error escribiendo post en el export: %v", err)
+		return err
+	}
+	report.Posts++
+
+	if !withComments {
+		return nil
+	}
+
+	comments, err := s.commentService.GetApprovedCommentsByPostID(post.ID)
+	if err != nil || len(comments) == 0 {
+		return err
+	}
+
+	data := make([]exportComment, 0, len(comments))
+	for _, comment := range comments {
+		data = append(data, exportComment{
+			ID:          comment.ID,
+			ParentID:    comment.ParentID,
+			Author:      exportAuthor(comment.Author),
+			Content:     comment.Content,
+			ContentHTML: comment.ContentHTML,
+			Date:        exportDate(comment.CreatedAt),
+		})
+	}
+
+	encoded, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := w.WriteFile(layout.commentsPath(post), append(encoded, '\n'), post.UpdatedAt); err != nil {
+		s.logger.Errorf("Error escribiendo comentarios en el export: %v", err)
+		return err
+	}
+	report.Comments += len(comments)
+
+	return nil
+}
+
+// publishedDate retorna la fecha de publicación del post o, si falta, la de creación
+func publishedDate(post *models.Post) time.Time {
+	if post.PublishedAt != nil {
+		return *post.PublishedAt
+	}
+	return post.CreatedAt
+}
+
+// exportDate normaliza una fecha a UTC sin fracciones de segundo
+func exportDate(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Second)
+}
+
+// exportAuthor retorna el nombre completo del usuario o su username
+func exportAuthor(user *models.User) string {
+	if user == nil {
+		return ""
+	}
+	if name := strings.TrimSpace(user.FirstName + " " + user.LastName); name != "" {
+		return name
+	}
+	return user.Username
+}
+
+// exportCategories retorna la categoría del post como lista, como esperan Hugo y Jekyll
+func exportCategories(post *models.Post) []string {
+	if post.Category == nil {
+		return nil
+	}
+	return []string{post.Category.Name}
+}
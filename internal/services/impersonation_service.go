@@ -0,0 +1,183 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/pkg/auth"
+	"github.com/alan.bermudez/goasync/pkg/rbac"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// ImpersonationService maneja las suplantaciones de usuarios por parte de administradores
+type ImpersonationService struct {
+	db     *sql.DB
+	ttl    time.Duration
+	logger *logrus.Logger
+}
+
+// NewImpersonationService crea una nueva instancia del servicio de suplantación
+func NewImpersonationService(db *sql.DB, ttl time.Duration, logger *logrus.Logger) *ImpersonationService {
+	return &ImpersonationService{
+		db:     db,
+		ttl:    ttl,
+		logger: logger,
+	}
+}
+
+// StartImpersonation emite un token de corta duración para que un administrador actúe como
+// otro usuario. Solo el hash del token se guarda en la base de datos.
+func (s *ImpersonationService) StartImpersonation(adminID, userID uuid.UUID, reason, ipAddress, userAgent string) (string, *models.Impersonation, error) {
+	if adminID == userID {
+		return "", nil, fmt.Errorf("no puedes suplantarte a ti mismo")
+	}
+
+	var role string
+	var isActive bool
+	err := s.db.QueryRow("SELECT role, is_active FROM users WHERE id = $1", userID).Scan(&role, &isActive)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil, fmt.Errorf("usuario no encontrado")
+		}
+		s.logger.Errorf("Error obteniendo usuario a suplantar: %v", err)
+		return "", nil, err
+	}
+	if !isActive {
+		return "", nil, fmt.Errorf("el usuario está inactivo")
+	}
+
+	// Suplantar a otro administrador permitiría actuar con sus privilegios sin dejar rastro propio
+	if role == rbac.RoleAdmin {
+		return "", nil, fmt.Errorf("no se puede suplantar a un administrador")
+	}
+
+	token, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		s.logger.Errorf("Error generando token de suplantación: %v", err)
+		return "", nil, err
+	}
+
+	query := `
+		INSERT INTO impersonations (admin_id, user_id, token_hash, reason, ip_address, user_agent, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, admin_id, user_id, token_hash, COALESCE(reason, ''), COALESCE(ip_address::text, ''),
+		          COALESCE(user_agent, ''), expires_at, ended_at, created_at
+	`
+
+	impersonation, err := s.scanImpersonation(s.db.QueryRow(query, adminID, userID, auth.HashToken(token),
+		reason, ipAddress, userAgent, time.Now().Add(s.ttl)))
+	if err != nil {
+		s.logger.Errorf("Error creando suplantación: %v", err)
+		return "", nil, err
+	}
+
+	return token, impersonation, nil
+}
+
+// ValidateImpersonation valida un token de suplantación. Se rechaza si la suplantación
+// finalizó o expiró, si el usuario suplantado está inactivo o si quien la inició ya no es administrador.
+func (s *ImpersonationService) ValidateImpersonation(token string) (uuid.UUID, uuid.UUID, uuid.UUID, error) {
+	query := `
+		SELECT i.id, i.user_id, i.admin_id
+		FROM impersonations i
+		JOIN users u ON u.id = i.user_id
+		JOIN users a ON a.id = i.admin_id
+		WHERE i.token_hash = $1
+		  AND i.ended_at IS NULL
+		  AND i.expires_at > CURRENT_TIMESTAMP
+		  AND u.is_active = true
+		  AND a.is_active = true
+		  AND a.role = $2
+	`
+
+	var impersonationID, userID, adminID uuid.UUID
+	err := s.db.QueryRow(query, auth.HashToken(token), rbac.RoleAdmin).Scan(&impersonationID, &userID, &adminID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, uuid.Nil, uuid.Nil, fmt.Errorf("suplantación inválida o expirada")
+		}
+		s.logger.Errorf("Error validando suplantación: %v", err)
+		return uuid.Nil, uuid.Nil, uuid.Nil, err
+	}
+
+	return userID, adminID, impersonationID, nil
+}
+
+// GetActiveImpersonations obtiene las suplantaciones que no han finalizado ni expirado
+func (s *ImpersonationService) GetActiveImpersonations() ([]models.Impersonation, error) {
+	query := `
+		SELECT i.id, i.admin_id, i.user_id, i.token_hash, COALESCE(i.reason, ''), COALESCE(i.ip_address::text, ''),
+		       COALESCE(i.user_agent, ''), i.expires_at, i.ended_at, i.created_at,
+		       a.username, u.username
+		FROM impersonations i
+		JOIN users a ON a.id = i.admin_id
+		JOIN users u ON u.id = i.user_id
+		WHERE i.ended_at IS NULL AND i.expires_at > CURRENT_TIMESTAMP
+		ORDER BY i.created_at DESC
+	`
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		s.logger.Errorf("Error obteniendo suplantaciones activas: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var impersonations []models.Impersonation
+	for rows.Next() {
+		var impersonation models.Impersonation
+		err := rows.Scan(
+			&impersonation.ID, &impersonation.AdminID, &impersonation.UserID, &impersonation.TokenHash,
+			&impersonation.Reason, &impersonation.IPAddress, &impersonation.UserAgent,
+			&impersonation.ExpiresAt, &impersonation.EndedAt, &impersonation.CreatedAt,
+			&impersonation.AdminUsername, &impersonation.Username,
+		)
+		if err != nil {
+			s.logger.Errorf("Error escaneando suplantación: %v", err)
+			continue
+		}
+		impersonations = append(impersonations, impersonation)
+	}
+
+	return impersonations, nil
+}
+
+// EndImpersonation finaliza una suplantación activa y retorna el usuario que estaba suplantado
+func (s *ImpersonationService) EndImpersonation(id uuid.UUID) (uuid.UUID, error) {
+	query := `
+		UPDATE impersonations SET ended_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND ended_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+		RETURNING user_id
+	`
+
+	var userID uuid.UUID
+	err := s.db.QueryRow(query, id).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, fmt.Errorf("suplantación no encontrada")
+		}
+		s.logger.Errorf("Error finalizando suplantación: %v", err)
+		return uuid.Nil, err
+	}
+
+	return userID, nil
+}
+
+// scanImpersonation escanea una fila de impersonations
+func (s *ImpersonationService) scanImpersonation(row interface{ Scan(...interface{}) error }) (*models.Impersonation, error) {
+	var impersonation models.Impersonation
+
+	err := row.Scan(
+		&impersonation.ID, &impersonation.AdminID, &impersonation.UserID, &impersonation.TokenHash,
+		&impersonation.Reason, &impersonation.IPAddress, &impersonation.UserAgent,
+		&impersonation.ExpiresAt, &impersonation.EndedAt, &impersonation.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &impersonation, nil
+}
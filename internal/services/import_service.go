@@ -0,0 +1,762 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/pkg/auth"
+	"github.com/alan.bermudez/goasync/pkg/htmlmd"
+	"github.com/alan.bermudez/goasync/pkg/rbac"
+	"github.com/alan.bermudez/goasync/pkg/slug"
+	"github.com/alan.bermudez/goasync/pkg/wxr"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// maxUsernameLength es la longitud de users.username
+	maxUsernameLength = 50
+
+	// importEmailDomain es el dominio de los emails generados para los usuarios importados
+	// sin email; .invalid está reservado y nunca recibe correo
+	importEmailDomain = "import.invalid"
+
+	// fallbackCategorySlug es la categoría de los posts importados sin categoría
+	fallbackCategorySlug = "sin-categoria"
+	fallbackCategoryName = "Sin categoría"
+)
+
+// ImportService importa contenido desde otras plataformas usando los servicios existentes.
+// Cada elemento importado se registra en import_mappings con su ID de origen, de modo que
+// repetir la importación omite lo ya importado.
+type ImportService struct {
+	db              *sql.DB
+	userService     *UserService
+	categoryService *CategoryService
+	tagService      *TagService
+	postService     *PostService
+	commentService  *CommentService
+	logger          *logrus.Logger
+}
+
+// NewImportService crea una nueva instancia del servicio de importación
+func NewImportService(db *sql.DB, userService *UserService, categoryService *CategoryService, tagService *TagService,
+	postService *PostService, commentService *CommentService, logger *logrus.Logger) *ImportService {
+	return &ImportService{
+		db:              db,
+		userService:     userService,
+		categoryService: categoryService,
+		tagService:      tagService,
+		postService:     postService,
+		commentService:  commentService,
+		logger:          logger,
+	}
+}
+
+// ImportWXR importa un export de WordPress: autores, categorías, etiquetas, posts y sus
+// comentarios. Cada elemento se crea en la misma transacción que su registro en
+// import_mappings, así que una importación interrumpida puede repetirse sin duplicados. Los
+// errores de un elemento se cuentan como fallidos en el informe y la importación continúa;
+// solo los errores de acceso a import_mappings la detienen.
+//
+// Los autores se asocian a un usuario existente con el mismo email o se crean con rol author y
+// una contraseña aleatoria. Los comentaristas registrados en WordPress se asocian a su autor y
+// los demás quedan anónimos, salvo con opts.CreateCommenters (ver commenter). En modo dry-run
+// no se escribe nada y el informe indica lo que se haría.
+func (s *ImportService) ImportWXR(export *wxr.Export, opts models.ImportOptions) (*models.ImportReport, error) {
+	source := opts.Source
+	if source == "" {
+		source = export.Source()
+	}
+	if source == "" {
+		return nil, fmt.Errorf("no se pudo determinar el sitio de origen del export")
+	}
+
+	run := &wxrImport{
+		ImportService: s,
+		source:        source,
+		dryRun:        opts.DryRun,
+		createUsers:   opts.CreateCommenters,
+		report: &models.ImportReport{
+			Source:   source,
+			DryRun:   opts.DryRun,
+			Ignored:  map[string]int{},
+			Warnings: []string{},
+		},
+		authors:    map[string]wxr.Author{},
+		authorIDs:  map[int]string{},
+		users:      map[string]uuid.UUID{},
+		commenters: map[string]*uuid.UUID{},
+		categories: map[string]uuid.UUID{},
+		tags:       map[string]uuid.UUID{},
+		comments:   map[int]uuid.UUID{},
+	}
+
+	for _, author := range export.Authors {
+		run.authors[author.Login] = author
+		run.authorIDs[author.ID] = author.Login
+	}
+
+	for _, author := range export.Authors {
+		if _, err := run.user(author.Login); err != nil {
+			return run.report, err
+		}
+	}
+	for _, category := range export.Categories {
+		if _, err := run.category(wxr.DecodeSlug(category.Slug), category.Name, category.Description); err != nil {
+			return run.report, err
+		}
+	}
+	for _, tag := range export.Tags {
+		if _, err := run.tag(wxr.DecodeSlug(tag.Slug), tag.Name, tag.Description); err != nil {
+			return run.report, err
+		}
+	}
+
+	for i := range export.Items {
+		if err := run.post(&export.Items[i]); err != nil {
+			return run.report, err
+		}
+	}
+
+	return run.report, nil
+}
+
+// wxrImport guarda el estado de una importación WXR. Los mapas cachean los IDs ya resueltos;
+// en modo dry-run los elementos que se crearían se guardan con uuid.Nil.
+type wxrImport struct {
+	*ImportService
+
+	source      string
+	dryRun      bool
+	createUsers bool // crear usuarios commenter para los comentaristas anónimos con email
+	report      *models.ImportReport
+
+	authors    map[string]wxr.Author // login -> autor del export
+	authorIDs  map[int]string        // ID de WordPress -> login
+	users      map[string]uuid.UUID  // login -> usuario
+	commenters map[string]*uuid.UUID // email -> usuario, nil si el comentario queda anónimo
+	categories map[string]uuid.UUID  // slug de origen -> categoría
+	tags       map[string]uuid.UUID  // slug de origen -> etiqueta
+	comments   map[int]uuid.UUID     // ID de WordPress -> comentario
+}
+
+// warn añade un aviso al informe
+func (r *wxrImport) warn(format string, args ...interface{}) {
+	r.report.Warnings = append(r.report.Warnings, fmt.Sprintf(format, args...))
+}
+
+// ignore cuenta un elemento que no se importa
+func (r *wxrImport) ignore(kind string) {
+	r.report.Ignored[kind]++
+}
+
+// user resuelve el usuario de un autor de WordPress por su login. Los autores que no
+// aparecen en la lista del export (WXR 1.0) se crean solo con el login.
+func (r *wxrImport) user(login string) (uuid.UUID, error) {
+	if id, ok := r.users[login]; ok {
+		return id, nil
+	}
+
+	id, found, err := r.lookupMapping(r.source, models.ImportTypeUser, login)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if found {
+		r.report.Users.Skipped++
+		r.users[login] = id
+		return id, nil
+	}
+
+	author, ok := r.authors[login]
+	if !ok {
+		author = wxr.Author{Login: login}
+	}
+
+	id, linked, failed, err := r.findOrCreateUser(models.ImportTypeUser, login,
+		author.Login, author.Email, author.FirstName, author.LastName, rbac.RoleAuthor)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if failed != nil {
+		r.report.Users.Failed++
+		r.warn("autor %q: %v", login, failed)
+		r.users[login] = uuid.Nil
+		return uuid.Nil, nil
+	}
+
+	r.countNew(&r.report.Users, linked)
+	r.users[login] = id
+	return id, nil
+}
+
+// commenter resuelve el autor de un comentario: el usuario importado si el comentarista era
+// un usuario de WordPress o nil si es anónimo. Con createUsers los comentaristas anónimos con
+// email se asocian a un usuario commenter o reader con ese email o se crean como commenter.
+// Un email no basta para atribuir comentarios a una cuenta con más permisos: en ese caso el
+// comentario queda anónimo.
+func (r *wxrImport) commenter(c *wxr.Comment) (*uuid.UUID, error) {
+	if login, ok := r.authorIDs[c.UserID]; c.UserID != 0 && ok {
+		id, err := r.user(login)
+		if err != nil {
+			return nil, err
+		}
+		if id == uuid.Nil {
+			return nil, nil
+		}
+		return &id, nil
+	}
+
+	email := strings.ToLower(strings.TrimSpace(c.AuthorEmail))
+	if email == "" {
+		return nil, nil
+	}
+	if id, ok := r.commenters[email]; ok {
+		return id, nil
+	}
+
+	id, found, err := r.lookupMapping(r.source, models.ImportTypeCommenter, email)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		r.report.Users.Skipped++
+		r.commenters[email] = &id
+		return &id, nil
+	}
+
+	if !r.createUsers {
+		r.commenters[email] = nil
+		return nil, nil
+	}
+
+	if existing, _ := r.userService.GetUserByEmail(email); existing != nil &&
+		existing.Role != rbac.RoleCommenter && existing.Role != rbac.RoleReader {
+		r.warn("comentarista %q: el email pertenece a un usuario con rol %s; sus comentarios quedan anónimos", email, existing.Role)
+		r.commenters[email] = nil
+		return nil, nil
+	}
+
+	id, linked, failed, err := r.findOrCreateUser(models.ImportTypeCommenter, email, c.Author, email, c.Author, "", rbac.RoleCommenter)
+	if err != nil {
+		return nil, err
+	}
+	if failed != nil {
+		r.report.Users.Failed++
+		r.warn("comentarista %q: %v; sus comentarios quedan anónimos", email, failed)
+		r.commenters[email] = nil
+		return nil, nil
+	}
+
+	r.countNew(&r.report.Users, linked)
+	r.commenters[email] = &id
+	return &id, nil
+}
+
+// findOrCreateUser retorna el usuario con el email dado o crea uno con un username libre
+// derivado de name y una contraseña aleatoria, y registra el mapeo del elemento de origen.
+// Los usuarios importados deben restablecer la contraseña para iniciar sesión. failed es el
+// error que impide resolver este usuario; err detiene la importación (ver createMapped).
+func (r *wxrImport) findOrCreateUser(sourceType, sourceID, name, email, firstName, lastName, role string) (id uuid.UUID, linked bool, failed, err error) {
+	email = strings.TrimSpace(email)
+	if email != "" {
+		if existing, _ := r.userService.GetUserByEmail(email); existing != nil {
+			return existing.ID, true, nil, r.saveMapping(r.source, sourceType, sourceID, existing.ID)
+		}
+	}
+
+	username, failed := r.availableUsername(name)
+	if failed != nil {
+		return uuid.Nil, false, failed, nil
+	}
+	if email == "" {
+		email = username + "@" + importEmailDomain
+	}
+	if r.dryRun {
+		return uuid.Nil, false, nil, nil
+	}
+
+	id, failed, err = r.createMapped(sourceType, sourceID, func(tx *sql.Tx) (uuid.UUID, error) {
+		plain, err := auth.GenerateOpaqueToken()
+		if err != nil {
+			return uuid.Nil, err
+		}
+
+		user, err := r.userService.CreateUserTx(tx, models.UserCreateRequest{
+			Username:  username,
+			Email:     email,
+			Password:  plain,
+			FirstName: truncateRunes(strings.TrimSpace(firstName), 100),
+			LastName:  truncateRunes(strings.TrimSpace(lastName), 100),
+		}, role)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		return user.ID, nil
+	})
+	return id, false, failed, err
+}
+
+// availableUsername genera un username libre a partir de un login o nombre de WordPress
+func (r *wxrImport) availableUsername(name string) (string, error) {
+	base := slug.Make(name)
+	if len(base) < 3 {
+		base = "usuario"
+	}
+
+	return slug.Unique(base, maxUsernameLength, func(candidate string) (bool, error) {
+		existing, _ := r.userService.GetUserByUsername(candidate)
+		return existing != nil, nil
+	})
+}
+
+// category resuelve una categoría por su slug de origen: la asociada en una importación
+// anterior, una existente con el mismo slug o nombre, o una nueva
+func (r *wxrImport) category(sourceSlug, name, description string) (uuid.UUID, error) {
+	return r.term(termImport{
+		sourceType: models.ImportTypeCategory,
+		table:      "categories",
+		maxLength:  maxCategorySlugLength,
+		slugTaken:  "el slug de categoría ya existe",
+		cache:      r.categories,
+		counts:     &r.report.Categories,
+		create: func(tx *sql.Tx, name, termSlug, description string) (uuid.UUID, error) {
+			category, err := r.categoryService.CreateCategoryTx(tx, models.CategoryCreateRequest{
+				Name:        name,
+				Slug:        termSlug,
+				Description: description,
+			})
+			if err != nil {
+				return uuid.Nil, err
+			}
+			return category.ID, nil
+		},
+	}, sourceSlug, name, description)
+}
+
+// tag resuelve una etiqueta por su slug de origen igual que category
+func (r *wxrImport) tag(sourceSlug, name, description string) (uuid.UUID, error) {
+	return r.term(termImport{
+		sourceType: models.ImportTypeTag,
+		table:      "tags",
+		maxLength:  maxTagSlugLength,
+		slugTaken:  "el slug de etiqueta ya existe",
+		cache:      r.tags,
+		counts:     &r.report.Tags,
+		create: func(tx *sql.Tx, name, termSlug, description string) (uuid.UUID, error) {
+			tag, err := r.tagService.CreateTagTx(tx, models.TagCreateRequest{
+				Name:        name,
+				Slug:        termSlug,
+				Description: description,
+			})
+			if err != nil {
+				return uuid.Nil, err
+			}
+			return tag.ID, nil
+		},
+	}, sourceSlug, name, description)
+}
+
+// termImport describe cómo importar una taxonomía (categorías o etiquetas)
+type termImport struct {
+	sourceType string
+	table      string
+	maxLength  int // longitud de las columnas name y slug
+	slugTaken  string
+	cache      map[string]uuid.UUID
+	counts     *models.ImportCounts
+	create     func(tx *sql.Tx, name, slug, description string) (uuid.UUID, error)
+}
+
+// term resuelve un término de una taxonomía. Si el slug de origen lo usa un término en la
+// papelera se crea con un slug generado a partir del nombre.
+func (r *wxrImport) term(t termImport, sourceSlug, name, description string) (uuid.UUID, error) {
+	name = truncateRunes(strings.TrimSpace(name), t.maxLength)
+	if name == "" {
+		name = sourceSlug
+	}
+	key := sourceSlug
+	if key == "" {
+		key = name
+	}
+	if id, ok := t.cache[key]; ok {
+		return id, nil
+	}
+
+	id, found, err := r.lookupMapping(r.source, t.sourceType, key)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if found {
+		t.counts.Skipped++
+		t.cache[key] = id
+		return id, nil
+	}
+
+	termSlug := slug.Truncate(slug.Make(sourceSlug), t.maxLength)
+	err = r.db.QueryRow(`
+		SELECT id FROM `+t.table+`
+		WHERE deleted_at IS NULL AND (slug = $1 OR LOWER(name) = LOWER($2))
+		ORDER BY slug = $1 DESC
+		LIMIT 1
+	`, termSlug, name).Scan(&id)
+	switch {
+	case err == sql.ErrNoRows:
+		if !r.dryRun {
+			var failed error
+			id, failed, err = r.createMapped(t.sourceType, key, func(tx *sql.Tx) (uuid.UUID, error) {
+				id, err := t.create(tx, name, termSlug, description)
+				if err != nil && err.Error() == t.slugTaken {
+					r.warn("%s %q: el slug %q está en uso en la papelera; se genera otro", t.sourceType, name, termSlug)
+					id, err = t.create(tx, name, "", description)
+				}
+				return id, err
+			})
+			if err != nil {
+				return uuid.Nil, err
+			}
+			if failed != nil {
+				t.counts.Failed++
+				r.warn("%s %q: %v", t.sourceType, name, failed)
+				t.cache[key] = uuid.Nil
+				return uuid.Nil, nil
+			}
+		}
+		r.countNew(t.counts, false)
+	case err != nil:
+		r.logger.Errorf("Error buscando %s existente: %v", t.sourceType, err)
+		return uuid.Nil, err
+	default:
+		if err := r.saveMapping(r.source, t.sourceType, key, id); err != nil {
+			return uuid.Nil, err
+		}
+		r.countNew(t.counts, true)
+	}
+
+	t.cache[key] = id
+	return id, nil
+}
+
+// post importa un item del export si es un post, y después sus comentarios. Los comentarios
+// se procesan también si el post se importó antes, para recoger los nuevos.
+func (r *wxrImport) post(item *wxr.Item) error {
+	if item.PostType != "post" {
+		r.ignore(item.PostType)
+		return nil
+	}
+
+	sourceID := strconv.Itoa(item.ID)
+	postID, found, err := r.lookupMapping(r.source, models.ImportTypePost, sourceID)
+	if err != nil {
+		return err
+	}
+	if found {
+		r.report.Posts.Skipped++
+		return r.postComments(item, postID)
+	}
+
+	req, ok, err := r.postRequest(item)
+	if err != nil || !ok {
+		return err
+	}
+	authorID, err := r.user(item.Creator)
+	if err != nil {
+		return err
+	}
+	if r.dryRun {
+		r.report.Posts.Created++
+		return r.postComments(item, uuid.Nil)
+	}
+	if authorID == uuid.Nil {
+		r.report.Posts.Failed++
+		r.warn("post %d (%q): autor %q no importado", item.ID, item.Title, item.Creator)
+		return nil
+	}
+	if req.CategoryID == uuid.Nil {
+		r.report.Posts.Failed++
+		r.warn("post %d (%q): categoría no importada", item.ID, item.Title)
+		return nil
+	}
+
+	var post *models.Post
+	_, failed, err := r.createMapped(models.ImportTypePost, sourceID, func(tx *sql.Tx) (uuid.UUID, error) {
+		var err error
+		post, err = r.postService.ImportPost(tx, req, authorID)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		return post.ID, nil
+	})
+	if err != nil {
+		return err
+	}
+	if failed != nil {
+		r.report.Posts.Failed++
+		r.warn("post %d (%q): %v", item.ID, item.Title, failed)
+		return nil
+	}
+	if req.Slug != "" && post.Slug != slug.Make(req.Slug) {
+		r.warn("post %d (%q): el slug %q está en uso; se usa %q", item.ID, item.Title, req.Slug, post.Slug)
+	}
+
+	r.report.Posts.Created++
+	return r.postComments(item, post.ID)
+}
+
+// postRequest construye la solicitud de importación de un item. ok es false si el estado del
+// item no se importa (borradores automáticos y papelera).
+func (r *wxrImport) postRequest(item *wxr.Item) (models.PostImportRequest, bool, error) {
+	var req models.PostImportRequest
+
+	now := time.Now()
+	published, hasDate := item.Published()
+	if !hasDate {
+		published = now
+	}
+	modified, ok := item.Modified()
+	if !ok || modified.Before(published) {
+		modified = published
+	}
+
+	switch item.Status {
+	case "publish":
+		req.Status = "published"
+		req.PublishedAt = &published
+	case "future":
+		if published.After(now) {
+			req.Status = "scheduled"
+			req.PublishAt = &published
+		} else {
+			req.Status = "published"
+			req.PublishedAt = &published
+		}
+	case "draft", "pending", "private":
+		req.Status = "draft"
+	default:
+		r.ignore(item.Status)
+		return req, false, nil
+	}
+
+	req.Title = truncateRunes(strings.TrimSpace(item.Title), 255)
+	if req.Title == "" {
+		req.Title = "Sin título"
+		r.warn("post %d: sin título", item.ID)
+	}
+	req.Slug = item.Slug()
+	req.Content = htmlmd.Convert(item.Content())
+	req.Excerpt = htmlmd.Convert(item.Excerpt())
+	req.CreatedAt = published
+	req.UpdatedAt = modified
+
+	// Las categorías de GoAsync no son múltiples: se usa la primera
+	categories := item.CategoryTerms()
+	var err error
+	if len(categories) == 0 {
+		req.CategoryID, err = r.category(fallbackCategorySlug, fallbackCategoryName, "")
+	} else {
+		if len(categories) > 1 {
+			r.warn("post %d (%q): tiene %d categorías; se usa %q", item.ID, item.Title, len(categories), categories[0].Name)
+		}
+		req.CategoryID, err = r.category(wxr.DecodeSlug(categories[0].Nicename), categories[0].Name, "")
+	}
+	if err != nil {
+		return req, false, err
+	}
+
+	for _, term := range item.TagTerms() {
+		tagID, err := r.tag(wxr.DecodeSlug(term.Nicename), term.Name, "")
+		if err != nil {
+			return req, false, err
+		}
+		// Varias etiquetas de origen pueden asociarse a la misma etiqueta existente
+		if tagID != uuid.Nil && !containsID(req.TagIDs, tagID) {
+			req.TagIDs = append(req.TagIDs, tagID)
+		}
+	}
+
+	return req, true, nil
+}
+
+// postComments importa los comentarios de un item en orden, de modo que cada respuesta se
+// crea después de su padre. Los pingbacks, trackbacks, spam y comentarios en la papelera no
+// se importan.
+func (r *wxrImport) postComments(item *wxr.Item, postID uuid.UUID) error {
+	for _, c := range item.SortedComments() {
+		switch {
+		case c.Type == "pingback" || c.Type == "trackback":
+			r.ignore(c.Type)
+			continue
+		case c.Approved == "spam" || c.Approved == "trash":
+			r.ignore(c.Approved)
+			continue
+		}
+
+		sourceID := strconv.Itoa(c.ID)
+		id, found, err := r.lookupMapping(r.source, models.ImportTypeComment, sourceID)
+		if err != nil {
+			return err
+		}
+		if found {
+			r.report.Comments.Skipped++
+			r.comments[c.ID] = id
+			continue
+		}
+
+		req := models.CommentImportRequest{
+			IsApproved: c.Approved == "1",
+		}
+		req.PostID = postID
+		req.Content = htmlmd.Convert(c.Content)
+		if created, ok := c.Created(); ok {
+			req.CreatedAt = created
+		}
+		if strings.TrimSpace(req.Content) == "" {
+			r.report.Comments.Failed++
+			r.warn("comentario %d del post %d: sin contenido", c.ID, item.ID)
+			continue
+		}
+
+		if c.Parent != 0 {
+			parentID, ok := r.comments[c.Parent]
+			if !ok {
+				r.warn("comentario %d del post %d: el comentario padre %d no se importó; queda en el primer nivel", c.ID, item.ID, c.Parent)
+			} else if !r.dryRun {
+				req.ParentID = &parentID
+			}
+		}
+
+		req.AuthorID, err = r.commenter(&c)
+		if err != nil {
+			return err
+		}
+
+		if r.dryRun {
+			r.report.Comments.Created++
+			r.comments[c.ID] = uuid.Nil
+			continue
+		}
+
+		commentID, failed, err := r.createMapped(models.ImportTypeComment, sourceID, func(tx *sql.Tx) (uuid.UUID, error) {
+			comment, err := r.commentService.ImportComment(tx, req)
+			if err != nil {
+				return uuid.Nil, err
+			}
+			return comment.ID, nil
+		})
+		if err != nil {
+			return err
+		}
+		if failed != nil {
+			r.report.Comments.Failed++
+			r.warn("comentario %d del post %d: %v", c.ID, item.ID, failed)
+			continue
+		}
+
+		r.report.Comments.Created++
+		r.comments[c.ID] = commentID
+	}
+
+	return nil
+}
+
+// countNew cuenta un elemento asociado a uno existente o creado
+func (r *wxrImport) countNew(counts *models.ImportCounts, linked bool) {
+	if linked {
+		counts.Linked++
+	} else {
+		counts.Created++
+	}
+}
+
+// createMapped crea un elemento con create y registra su mapeo en la misma transacción, de
+// modo que ningún elemento queda creado sin mapeo. failed es el error de create, que solo
+// hace fallar a ese elemento; err es un error de la transacción o del mapeo, que detiene la
+// importación.
+func (r *wxrImport) createMapped(sourceType, sourceID string, create func(tx *sql.Tx) (uuid.UUID, error)) (id uuid.UUID, failed, err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		r.logger.Errorf("Error iniciando transacción: %v", err)
+		return uuid.Nil, nil, err
+	}
+	defer tx.Rollback()
+
+	id, failed = create(tx)
+	if failed != nil {
+		return uuid.Nil, failed, nil
+	}
+
+	if err := r.ImportService.saveMapping(tx, r.source, sourceType, sourceID, id); err != nil {
+		return uuid.Nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Errorf("Error confirmando transacción: %v", err)
+		return uuid.Nil, nil, err
+	}
+
+	return id, nil, nil
+}
+
+// saveMapping registra el ID de destino de un elemento de origen asociado a uno existente; en
+// modo dry-run no hace nada
+func (r *wxrImport) saveMapping(source, sourceType, sourceID string, targetID uuid.UUID) error {
+	if r.dryRun {
+		return nil
+	}
+	return r.ImportService.saveMapping(r.db, source, sourceType, sourceID, targetID)
+}
+
+// lookupMapping obtiene el ID de destino de un elemento importado anteriormente
+func (s *ImportService) lookupMapping(source, sourceType, sourceID string) (uuid.UUID, bool, error) {
+	var targetID uuid.UUID
+	err := s.db.QueryRow(`
+		SELECT target_id FROM import_mappings
+		WHERE source = $1 AND source_type = $2 AND source_id = $3
+	`, source, sourceType, sourceID).Scan(&targetID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, false, nil
+		}
+		s.logger.Errorf("Error obteniendo mapeo de importación: %v", err)
+		return uuid.Nil, false, err
+	}
+
+	return targetID, true, nil
+}
+
+// saveMapping registra el ID de destino de un elemento importado usando la conexión o
+// transacción recibida
+func (s *ImportService) saveMapping(q execer, source, sourceType, sourceID string, targetID uuid.UUID) error {
+	_, err := q.Exec(`
+		INSERT INTO import_mappings (source, source_type, source_id, target_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (source, source_type, source_id) DO UPDATE SET target_id = EXCLUDED.target_id
+	`, source, sourceType, sourceID, targetID)
+	if err != nil {
+		s.logger.Errorf("Error guardando mapeo de importación: %v", err)
+	}
+	return err
+}
+
+// containsID indica si ids contiene id
+func containsID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, existing := range ids {
+		if existing == id {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateRunes recorta un texto a maxLen caracteres
+func truncateRunes(text string, maxLen int) string {
+	if utf8.RuneCountInString(text) <= maxLen {
+		return text
+	}
+	return string([]rune(text)[:maxLen])
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/pkg/auth"
+	"github.com/alan.bermudez/goasync/pkg/rbac"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// Modos de registro de usuarios
+const (
+	RegistrationModeOpen   = "open"
+	RegistrationModeInvite = "invite"
+)
+
+// invitePrefix identifica visualmente los códigos de invitación emitidos por la aplicación
+const invitePrefix = "inv_"
+
+// inviteDisplayLength es la cantidad de caracteres del código que se guardan en claro para identificarlo
+const inviteDisplayLength = 10
+
+// InviteService maneja las invitaciones y el registro de usuarios
+type InviteService struct {
+	db               *sql.DB
+	userService      *UserService
+	registrationMode string
+	logger           *logrus.Logger
+}
+
+// NewInviteService crea una nueva instancia del servicio de invitaciones
+func NewInviteService(db *sql.DB, userService *UserService, registrationMode string, logger *logrus.Logger) *InviteService {
+	return &InviteService{
+		db:               db,
+		userService:      userService,
+		registrationMode: registrationMode,
+		logger:           logger,
+	}
+}
+
+// RequiresInvite indica si el registro requiere un código de invitación
+func (s *InviteService) RequiresInvite() bool {
+	return s.registrationMode == RegistrationModeInvite
+}
+
+// CreateInvite emite un nuevo código de invitación y retorna el código en claro.
+// Solo el hash del código se guarda en la base de datos.
+func (s *InviteService) CreateInvite(req models.InviteCreateRequest, createdBy uuid.UUID) (string, *models.Invite, error) {
+	role := req.Role
+	if role == "" {
+		role = rbac.DefaultRole
+	}
+	if !rbac.IsValidRole(role) {
+		return "", nil, fmt.Errorf("rol inválido")
+	}
+
+	maxUses := req.MaxUses
+	if maxUses == 0 {
+		maxUses = 1
+	}
+	if maxUses < 0 {
+		return "", nil, fmt.Errorf("el número máximo de usos debe ser positivo")
+	}
+
+	if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
+		return "", nil, fmt.Errorf("la fecha de expiración debe ser futura")
+	}
+
+	var email *string
+	if trimmed := strings.TrimSpace(req.Email); trimmed != "" {
+		email = &trimmed
+	}
+
+	token, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		s.logger.Errorf("Error generando código de invitación: %v", err)
+		return "", nil, err
+	}
+	code := invitePrefix + token
+
+	query := `
+		INSERT INTO invites (code_prefix, code_hash, email, role, max_uses, expires_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, code_prefix, code_hash, email, role, max_uses, uses,
+		          expires_at, revoked_at, created_by, created_at
+	`
+
+	invite, err := s.scanInvite(s.db.QueryRow(query, code[:inviteDisplayLength], auth.HashToken(code),
+		email, role, maxUses, req.ExpiresAt, createdBy))
+	if err != nil {
+		s.logger.Errorf("Error creando invitación: %v", err)
+		return "", nil, err
+	}
+
+	return code, invite, nil
+}
+
+// GetInvites obtiene todas las invitaciones
+func (s *InviteService) GetInvites() ([]models.Invite, error) {
+	query := `
+		SELECT id, code_prefix, code_hash, email, role, max_uses, uses,
+		       expires_at, revoked_at, created_by, created_at
+		FROM invites
+		ORDER BY created_at DESC
+	`
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		s.logger.Errorf("Error obteniendo invitaciones: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invites []models.Invite
+	for rows.Next() {
+		invite, err := s.scanInvite(rows)
+		if err != nil {
+			s.logger.Errorf("Error escaneando invitación: %v", err)
+			continue
+		}
+		invites = append(invites, *invite)
+	}
+
+	return invites, nil
+}
+
+// RevokeInvite revoca una invitación
+func (s *InviteService) RevokeInvite(id uuid.UUID) error {
+	query := "UPDATE invites SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND revoked_at IS NULL"
+
+	result, err := s.db.Exec(query, id)
+	if err != nil {
+		s.logger.Errorf("Error revocando invitación: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("invitación no encontrada")
+	}
+
+	return nil
+}
+
+// Register registra un nuevo usuario aplicando el modo de registro. Si se envía un código
+// de invitación, este se canjea en la misma transacción que crea al usuario y el usuario
+// recibe el rol de la invitación. Retorna la invitación canjeada, si la hubo.
+func (s *InviteService) Register(req models.UserCreateRequest) (*models.User, *models.Invite, error) {
+	code := strings.TrimSpace(req.InviteCode)
+	if code == "" {
+		if s.RequiresInvite() {
+			return nil, nil, fmt.Errorf("se requiere un código de invitación")
+		}
+		user, err := s.userService.CreateUser(req)
+		return user, nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	// Canjear la invitación de forma atómica: el UPDATE bloquea la fila, por lo que
+	// dos registros concurrentes no pueden superar max_uses
+	query := `
+		UPDATE invites SET uses = uses + 1
+		WHERE code_hash = $1
+		  AND revoked_at IS NULL
+		  AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+		  AND uses < max_uses
+		  AND (email IS NULL OR LOWER(email) = LOWER($2))
+		RETURNING id, code_prefix, code_hash, email, role, max_uses, uses,
+		          expires_at, revoked_at, created_by, created_at
+	`
+
+	invite, err := s.scanInvite(tx.QueryRow(query, auth.HashToken(code), req.Email))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, fmt.Errorf("código de invitación inválido o expirado")
+		}
+		s.logger.Errorf("Error canjeando invitación: %v", err)
+		return nil, nil, err
+	}
+
+	user, err := s.userService.CreateUserTx(tx, req, invite.Role)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.Errorf("Error confirmando registro con invitación: %v", err)
+		return nil, nil, err
+	}
+
+	return user, invite, nil
+}
+
+// scanInvite escanea una fila de invites y calcula su estado
+func (s *InviteService) scanInvite(row interface{ Scan(...interface{}) error }) (*models.Invite, error) {
+	var invite models.Invite
+
+	err := row.Scan(
+		&invite.ID, &invite.CodePrefix, &invite.CodeHash, &invite.Email, &invite.Role,
+		&invite.MaxUses, &invite.Uses, &invite.ExpiresAt, &invite.RevokedAt,
+		&invite.CreatedBy, &invite.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case invite.RevokedAt != nil:
+		invite.Status = "revoked"
+	case invite.ExpiresAt != nil && invite.ExpiresAt.Before(time.Now()):
+		invite.Status = "expired"
+	case invite.Uses >= invite.MaxUses:
+		invite.Status = "exhausted"
+	default:
+		invite.Status = "active"
+	}
+
+	return &invite, nil
+}
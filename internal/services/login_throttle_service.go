@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/alan.bermudez/goasync/internal/config"
+	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// Ámbitos del seguimiento de intentos fallidos
+const (
+	throttleScopeAccount = "account"
+	throttleScopeIP      = "ip"
+)
+
+// LoginThrottledError se retorna cuando un intento de inicio de sesión es rechazado
+// por exceso de fallos previos
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+// Error implementa la interfaz error
+func (e *LoginThrottledError) Error() string {
+	if e.Locked {
+		return "acceso bloqueado temporalmente por demasiados intentos fallidos"
+	}
+	return "demasiados intentos de inicio de sesión, espera antes de reintentar"
+}
+
+// LoginThrottleService registra los intentos fallidos de inicio de sesión por cuenta y por IP,
+// aplica esperas exponenciales entre intentos y bloquea temporalmente tras N fallos
+type LoginThrottleService struct {
+	db           *sql.DB
+	cfg          config.SecurityConfig
+	statsService *StatsService
+	logger       *logrus.Logger
+}
+
+// NewLoginThrottleService crea una nueva instancia del servicio de protección contra fuerza bruta
+func NewLoginThrottleService(db *sql.DB, cfg config.SecurityConfig, statsService *StatsService, logger *logrus.Logger) *LoginThrottleService {
+	return &LoginThrottleService{
+		db:           db,
+		cfg:          cfg,
+		statsService: statsService,
+		logger:       logger,
+	}
+}
+
+// CheckIP verifica si la IP puede intentar iniciar sesión
+func (s *LoginThrottleService) CheckIP(ip string) error {
+	return s.check(throttleScopeIP, ip)
+}
+
+// CheckAccount verifica si la cuenta puede intentar iniciar sesión
+func (s *LoginThrottleService) CheckAccount(userID uuid.UUID) error {
+	return s.check(throttleScopeAccount, userID.String())
+}
+
+// RecordFailure registra un intento fallido para la IP y, si se conoce, para la cuenta.
+// Cuando la cuenta queda bloqueada se registra la actividad user_locked.
+func (s *LoginThrottleService) RecordFailure(userID *uuid.UUID, ip, userAgent string) error {
+	if _, _, err := s.recordFailure(throttleScopeIP, ip, s.cfg.MaxFailedLoginsPerIP); err != nil {
+		return err
+	}
+
+	if userID == nil {
+		return nil
+	}
+
+	lockedUntil, failures, err := s.recordFailure(throttleScopeAccount, userID.String(), s.cfg.MaxFailedLogins)
+	if err != nil {
+		return err
+	}
+
+	if lockedUntil != nil {
+		s.logger.Warnf("Cuenta %s bloqueada hasta %s tras %d intentos fallidos", userID, lockedUntil.Format(time.RFC3339), failures)
+
+		// Crear log de actividad
+		s.statsService.CreateActivityLog(
+			userID,
+			"user_locked",
+			"user",
+			userID,
+			map[string]interface{}{
+				"failures":     failures,
+				"locked_until": lockedUntil.Format(time.RFC3339),
+			},
+			models.RequestInfo{IPAddress: ip, UserAgent: userAgent},
+		)
+	}
+
+	return nil
+}
+
+// RecordSuccess limpia los intentos fallidos de una cuenta tras un inicio de sesión exitoso
+func (s *LoginThrottleService) RecordSuccess(userID uuid.UUID) error {
+	_, err := s.db.Exec("DELETE FROM login_throttles WHERE scope = $1 AND key = $2",
+		throttleScopeAccount, userID.String())
+	if err != nil {
+		s.logger.Errorf("Error limpiando intentos fallidos: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// Unlock desbloquea una cuenta y limpia sus intentos fallidos. Retorna si la cuenta tenía un bloqueo activo.
+func (s *LoginThrottleService) Unlock(userID uuid.UUID) (bool, error) {
+	var lockedUntil sql.NullTime
+	err := s.db.QueryRow(`
+		DELETE FROM login_throttles WHERE scope = $1 AND key = $2
+		RETURNING locked_until
+	`, throttleScopeAccount, userID.String()).Scan(&lockedUntil)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		s.logger.Errorf("Error desbloqueando cuenta: %v", err)
+		return false, err
+	}
+
+	return lockedUntil.Valid && lockedUntil.Time.After(time.Now()), nil
+}
+
+// PurgeStale elimina los registros sin fallos recientes ni bloqueos activos
+func (s *LoginThrottleService) PurgeStale() (int64, error) {
+	now := time.Now()
+	result, err := s.db.Exec(`
+		DELETE FROM login_throttles
+		WHERE last_failure_at < $1 AND (locked_until IS NULL OR locked_until < $2)
+	`, now.Add(-s.cfg.FailureWindow), now)
+	if err != nil {
+		s.logger.Errorf("Error eliminando intentos fallidos antiguos: %v", err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// StartSweeper elimina periódicamente los registros antiguos hasta que el contexto se cancele
+func (s *LoginThrottleService) StartSweeper(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.PurgeStale()
+		}
+	}
+}
+
+// check rechaza el intento si hay un bloqueo o una espera pendiente
+func (s *LoginThrottleService) check(scope, key string) error {
+	var nextAttemptAt, lockedUntil sql.NullTime
+	err := s.db.QueryRow(`
+		SELECT next_attempt_at, locked_until FROM login_throttles WHERE scope = $1 AND key = $2
+	`, scope, key).Scan(&nextAttemptAt, &lockedUntil)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		s.logger.Errorf("Error verificando intentos fallidos: %v", err)
+		return err
+	}
+
+	now := time.Now()
+	if lockedUntil.Valid && lockedUntil.Time.After(now) {
+		return &LoginThrottledError{RetryAfter: lockedUntil.Time.Sub(now), Locked: true}
+	}
+	if nextAttemptAt.Valid && nextAttemptAt.Time.After(now) {
+		return &LoginThrottledError{RetryAfter: nextAttemptAt.Time.Sub(now)}
+	}
+
+	return nil
+}
+
+// recordFailure incrementa el contador de fallos de forma atómica y aplica la espera
+// exponencial o el bloqueo. Retorna la fecha de fin del bloqueo si este intento lo provocó.
+func (s *LoginThrottleService) recordFailure(scope, key string, maxFailures int) (*time.Time, int, error) {
+	now := time.Now()
+
+	var failures int
+	var alreadyLocked bool
+	err := s.db.QueryRow(`
+		INSERT INTO login_throttles (scope, key, failures, last_failure_at)
+		VALUES ($1, $2, 1, $3)
+		ON CONFLICT (scope, key) DO UPDATE SET
+			failures = CASE
+				WHEN login_throttles.last_failure_at < $4 THEN 1
+				ELSE login_throttles.failures + 1
+			END,
+			last_failure_at = $3
+		RETURNING failures, COALESCE(locked_until > $3, false)
+	`, scope, key, now, now.Add(-s.cfg.FailureWindow)).Scan(&failures, &alreadyLocked)
+	if err != nil {
+		s.logger.Errorf("Error registrando intento fallido: %v", err)
+		return nil, 0, err
+	}
+
+	if alreadyLocked {
+		return nil, failures, nil
+	}
+
+	var lockedUntil *time.Time
+	nextAttemptAt := now.Add(s.backoff(failures))
+	if maxFailures > 0 && failures >= maxFailures {
+		until := now.Add(s.cfg.LockoutDuration)
+		lockedUntil = &until
+		nextAttemptAt = until
+	}
+
+	_, err = s.db.Exec(`
+		UPDATE login_throttles SET next_attempt_at = $1, locked_until = $2
+		WHERE scope = $3 AND key = $4
+	`, nextAttemptAt, lockedUntil, scope, key)
+	if err != nil {
+		s.logger.Errorf("Error aplicando espera de inicio de sesión: %v", err)
+		return nil, failures, err
+	}
+
+	return lockedUntil, failures, nil
+}
+
+// backoff calcula la espera exponencial tras n fallos consecutivos
+func (s *LoginThrottleService) backoff(failures int) time.Duration {
+	if s.cfg.BackoffBase <= 0 || failures <= 0 {
+		return 0
+	}
+
+	delay := s.cfg.BackoffBase
+	for i := 1; i < failures; i++ {
+		delay *= 2
+		if s.cfg.BackoffMax > 0 && delay >= s.cfg.BackoffMax {
+			return s.cfg.BackoffMax
+		}
+	}
+
+	return delay
+}
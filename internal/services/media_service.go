@@ -0,0 +1,465 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/pkg/imaging"
+	"github.com/alan.bermudez/goasync/pkg/rbac"
+	"github.com/alan.bermudez/goasync/pkg/storage"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// mediaTypes son los tipos de archivo aceptados, detectados por contenido, con su extensión.
+// SVG no se acepta porque puede contener scripts.
+var mediaTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+// mediaVariants son las versiones redimensionadas que se generan de cada imagen, con el
+// tamaño máximo de su lado mayor
+var mediaVariants = []struct {
+	name    string
+	maxSize int
+}{
+	{"thumbnail", 150},
+	{"medium", 640},
+	{"large", 1280},
+}
+
+// maxImagePixels limita las dimensiones de las imágenes para no decodificar imágenes
+// enormes comprimidas en pocos bytes
+const maxImagePixels = 50_000_000
+
+// MediaService maneja la lógica de negocio de la biblioteca de medios
+type MediaService struct {
+	db            *sql.DB
+	storage       storage.Storage
+	postService   *PostService
+	maxUploadSize int64
+	logger        *logrus.Logger
+}
+
+// NewMediaService crea una nueva instancia del servicio de medios
+func NewMediaService(db *sql.DB, store storage.Storage, postService *PostService, maxUploadSize int64, logger *logrus.Logger) *MediaService {
+	return &MediaService{
+		db:            db,
+		storage:       store,
+		postService:   postService,
+		maxUploadSize: maxUploadSize,
+		logger:        logger,
+	}
+}
+
+// MaxUploadSize retorna el tamaño máximo de un archivo en bytes
+func (s *MediaService) MaxUploadSize() int64 {
+	return s.maxUploadSize
+}
+
+// Upload guarda un archivo en el almacenamiento y lo registra en la biblioteca. El tipo se
+// detecta por el contenido, no por el nombre ni por la cabecera del cliente. De las imágenes
+// se generan las variantes que sean más pequeñas que el original. Si se indica postID el
+// usuario debe poder modificar ese post.
+func (s *MediaService) Upload(ctx context.Context, r io.Reader, filename, altText string, postID *uuid.UUID, uploaderID uuid.UUID, uploaderRole string) (*models.Media, error) {
+	if postID != nil {
+		if err := s.postService.checkOwnership(*postID, uploaderID, uploaderRole); err != nil {
+			return nil, err
+		}
+	}
+
+	// Leer un byte más del máximo para detectar archivos demasiado grandes
+	data, err := io.ReadAll(io.LimitReader(r, s.maxUploadSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.maxUploadSize {
+		return nil, fmt.Errorf("el archivo supera el tamaño máximo")
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("el archivo está vacío")
+	}
+
+	contentType := strings.TrimSpace(strings.Split(http.DetectContentType(data), ";")[0])
+	ext, ok := mediaTypes[contentType]
+	if !ok {
+		return nil, fmt.Errorf("tipo de archivo no permitido")
+	}
+
+	id := uuid.New()
+	base := time.Now().UTC().Format("2006/01") + "/" + id.String()
+
+	media := &models.Media{
+		ID:          id,
+		UploaderID:  &uploaderID,
+		PostID:      postID,
+		StorageKey:  base + ext,
+		Filename:    cleanFilename(filename, ext),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		AltText:     altText,
+		Variants:    []models.MediaVariant{},
+	}
+
+	// Si algo falla después de empezar a guardar, eliminar lo que ya se subió
+	var stored []string
+	success := false
+	defer func() {
+		if !success {
+			s.deleteObjects(stored)
+		}
+	}()
+
+	if strings.HasPrefix(contentType, "image/") {
+		variants, err := s.storeVariants(ctx, media, data, base, &stored)
+		if err != nil {
+			return nil, err
+		}
+		media.Variants = variants
+	}
+
+	if err := s.storage.Put(ctx, media.StorageKey, bytes.NewReader(data), media.Size, contentType); err != nil {
+		s.logger.Errorf("Error guardando archivo: %v", err)
+		return nil, err
+	}
+	stored = append(stored, media.StorageKey)
+
+	variantsJSON, err := json.Marshal(media.Variants)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO media (id, uploader_id, post_id, storage_key, filename, content_type, size_bytes,
+		                   width, height, alt_text, variants)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING created_at
+	`
+
+	err = s.db.QueryRowContext(ctx, query, media.ID, media.UploaderID, media.PostID, media.StorageKey,
+		media.Filename, media.ContentType, media.Size, media.Width, media.Height, media.AltText,
+		variantsJSON).Scan(&media.CreatedAt)
+	if err != nil {
+		s.logger.Errorf("Error registrando archivo: %v", err)
+		return nil, err
+	}
+
+	success = true
+	s.setURLs(media)
+	return media, nil
+}
+
+// storeVariants decodifica una imagen, guarda sus dimensiones en media y sube sus variantes.
+// Las claves guardadas se añaden a stored.
+func (s *MediaService) storeVariants(ctx context.Context, media *models.Media, data []byte, base string, stored *[]string) ([]models.MediaVariant, error) {
+	config, _, err := imaging.DecodeConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("la imagen no es válida")
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return nil, fmt.Errorf("la imagen es demasiado grande")
+	}
+
+	img, format, err := imaging.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("la imagen no es válida")
+	}
+
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	media.Width, media.Height = &width, &height
+
+	variants := []models.MediaVariant{}
+	for _, size := range mediaVariants {
+		resized, ok := imaging.Fit(img, size.maxSize)
+		if !ok {
+			continue
+		}
+
+		encoded, contentType, err := imaging.Encode(resized, format)
+		if err != nil {
+			s.logger.Errorf("Error codificando variante %s: %v", size.name, err)
+			return nil, err
+		}
+
+		key := base + "-" + size.name + mediaTypes[contentType]
+		if err := s.storage.Put(ctx, key, bytes.NewReader(encoded), int64(len(encoded)), contentType); err != nil {
+			s.logger.Errorf("Error guardando variante %s: %v", size.name, err)
+			return nil, err
+		}
+		*stored = append(*stored, key)
+
+		variants = append(variants, models.MediaVariant{
+			Name:        size.name,
+			StorageKey:  key,
+			ContentType: contentType,
+			Width:       resized.Bounds().Dx(),
+			Height:      resized.Bounds().Dy(),
+			Size:        int64(len(encoded)),
+		})
+	}
+
+	return variants, nil
+}
+
+// GetMediaByID obtiene un archivo de la biblioteca por su ID
+func (s *MediaService) GetMediaByID(id uuid.UUID) (*models.Media, error) {
+	query := `
+		SELECT id, uploader_id, post_id, storage_key, filename, content_type, size_bytes,
+		       width, height, alt_text, variants, created_at
+		FROM media
+		WHERE id = $1
+	`
+
+	media, err := s.scanMedia(s.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("archivo no encontrado")
+		}
+		s.logger.Errorf("Error obteniendo archivo: %v", err)
+		return nil, err
+	}
+
+	return media, nil
+}
+
+// GetAllMedia obtiene los archivos de la biblioteca con filtros y paginación, del más reciente
+// al más antiguo
+func (s *MediaService) GetAllMedia(filter models.MediaFilter) (*models.MediaListResponse, error) {
+	var conditions []string
+	var args []interface{}
+	argIndex := 1
+
+	if filter.UploaderID != uuid.Nil {
+		conditions = append(conditions, fmt.Sprintf("uploader_id = $%d", argIndex))
+		args = append(args, filter.UploaderID)
+		argIndex++
+	}
+	if filter.PostID != uuid.Nil {
+		conditions = append(conditions, fmt.Sprintf("post_id = $%d", argIndex))
+		args = append(args, filter.PostID)
+		argIndex++
+	}
+	if filter.ContentType != "" {
+		conditions = append(conditions, fmt.Sprintf("content_type LIKE $%d", argIndex))
+		args = append(args, strings.ReplaceAll(filter.ContentType, "%", "")+"%")
+		argIndex++
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM media "+whereClause, args...).Scan(&total)
+	if err != nil {
+		s.logger.Errorf("Error contando archivos: %v", err)
+		return nil, err
+	}
+
+	offset := (filter.Page - 1) * filter.PerPage
+	query := fmt.Sprintf(`
+		SELECT id, uploader_id, post_id, storage_key, filename, content_type, size_bytes,
+		       width, height, alt_text, variants, created_at
+		FROM media
+		%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, whereClause, argIndex, argIndex+1)
+	args = append(args, filter.PerPage, offset)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		s.logger.Errorf("Error obteniendo archivos: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	media := []models.Media{}
+	for rows.Next() {
+		item, err := s.scanMedia(rows)
+		if err != nil {
+			s.logger.Errorf("Error escaneando archivo: %v", err)
+			continue
+		}
+		media = append(media, *item)
+	}
+
+	return &models.MediaListResponse{
+		Media:      media,
+		Total:      total,
+		Page:       filter.Page,
+		PerPage:    filter.PerPage,
+		TotalPages: (total + filter.PerPage - 1) / filter.PerPage,
+	}, nil
+}
+
+// GetUsage obtiene dónde se usa un archivo. El contenido de los posts se revisa buscando la
+// clave del original y de sus variantes, que aparecen en cualquier URL del archivo; los posts
+// en la papelera también cuentan porque pueden restaurarse.
+func (s *MediaService) GetUsage(media *models.Media) (*models.MediaUsage, error) {
+	usage := &models.MediaUsage{
+		FeaturedIn: []uuid.UUID{},
+		ContentIn:  []uuid.UUID{},
+		AvatarOf:   []uuid.UUID{},
+	}
+
+	keys := []string{media.StorageKey}
+	urls := []string{media.URL}
+	for _, variant := range media.Variants {
+		keys = append(keys, variant.StorageKey)
+		urls = append(urls, variant.URL)
+	}
+
+	queries := []struct {
+		query string
+		arg   interface{}
+		dest  *[]uuid.UUID
+	}{
+		{"SELECT id FROM posts WHERE featured_image_id = $1", media.ID, &usage.FeaturedIn},
+		{`SELECT p.id FROM posts p
+		  WHERE EXISTS (SELECT 1 FROM unnest($1::text[]) AS k(key) WHERE strpos(p.content, k.key) > 0)`,
+			pq.Array(keys), &usage.ContentIn},
+		{"SELECT user_id FROM user_profiles WHERE avatar_url = ANY($1::text[])", pq.Array(urls), &usage.AvatarOf},
+	}
+
+	for _, q := range queries {
+		rows, err := s.db.Query(q.query, q.arg)
+		if err != nil {
+			s.logger.Errorf("Error obteniendo usos del archivo: %v", err)
+			return nil, err
+		}
+
+		for rows.Next() {
+			var id uuid.UUID
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			*q.dest = append(*q.dest, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return usage, nil
+}
+
+// DeleteMedia elimina un archivo y sus variantes si no se usa en ningún sitio. Solo quien lo
+// subió o un moderador pueden eliminarlo. Si el archivo está en uso se retornan sus usos.
+func (s *MediaService) DeleteMedia(ctx context.Context, id uuid.UUID, actorID uuid.UUID, actorRole string) (*models.MediaUsage, error) {
+	media, err := s.GetMediaByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !rbac.IsModerator(actorRole) && (media.UploaderID == nil || *media.UploaderID != actorID) {
+		return nil, fmt.Errorf("no tienes permiso para eliminar este archivo")
+	}
+
+	usage, err := s.GetUsage(media)
+	if err != nil {
+		return nil, err
+	}
+	if usage.InUse() {
+		return usage, fmt.Errorf("el archivo está en uso")
+	}
+
+	_, err = s.db.ExecContext(ctx, "DELETE FROM media WHERE id = $1", id)
+	if err != nil {
+		// La clave foránea de posts.featured_image_id protege de una asignación concurrente
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			return nil, fmt.Errorf("el archivo está en uso")
+		}
+		s.logger.Errorf("Error eliminando archivo: %v", err)
+		return nil, err
+	}
+
+	// El registro ya no existe; un fallo al borrar los objetos solo deja archivos huérfanos
+	keys := []string{media.StorageKey}
+	for _, variant := range media.Variants {
+		keys = append(keys, variant.StorageKey)
+	}
+	s.deleteObjects(keys)
+
+	return nil, nil
+}
+
+// deleteObjects elimina objetos del almacenamiento registrando los errores
+func (s *MediaService) deleteObjects(keys []string) {
+	for _, key := range keys {
+		if err := s.storage.Delete(context.Background(), key); err != nil {
+			s.logger.Errorf("Error eliminando objeto %s del almacenamiento: %v", key, err)
+		}
+	}
+}
+
+// scanMedia escanea una fila de media y completa las URLs públicas
+func (s *MediaService) scanMedia(row interface{ Scan(...interface{}) error }) (*models.Media, error) {
+	var media models.Media
+	var altText sql.NullString
+	var variants []byte
+
+	err := row.Scan(
+		&media.ID, &media.UploaderID, &media.PostID, &media.StorageKey, &media.Filename,
+		&media.ContentType, &media.Size, &media.Width, &media.Height, &altText, &variants,
+		&media.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	media.AltText = altText.String
+
+	media.Variants = []models.MediaVariant{}
+	if len(variants) > 0 {
+		if err := json.Unmarshal(variants, &media.Variants); err != nil {
+			return nil, err
+		}
+	}
+
+	s.setURLs(&media)
+	return &media, nil
+}
+
+// setURLs completa las URLs públicas del archivo y sus variantes. No se guardan en la base de
+// datos para que cambiar MEDIA_BASE_URL o el almacenamiento no deje URLs obsoletas.
+func (s *MediaService) setURLs(media *models.Media) {
+	media.URL = s.storage.URL(media.StorageKey)
+	for i := range media.Variants {
+		media.Variants[i].URL = s.storage.URL(media.Variants[i].StorageKey)
+	}
+}
+
+// cleanFilename conserva solo el nombre base del archivo original, con la extensión del tipo
+// detectado si no tiene ninguna
+func cleanFilename(filename, ext string) string {
+	name := filepath.Base(strings.ReplaceAll(filename, "\\", "/"))
+	if name == "." || name == "/" {
+		name = "archivo"
+	}
+	if filepath.Ext(name) == "" {
+		name += ext
+	}
+	for len(name) > 255 {
+		_, size := utf8.DecodeRuneInString(name)
+		name = name[size:]
+	}
+	return name
+}
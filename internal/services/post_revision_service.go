@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/alan.bermudez/goasync/internal/config"
+	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/pkg/diff"
+	"github.com/alan.bermudez/goasync/pkg/rbac"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// originalRevisionNote es la nota de la revisión que conserva la versión previa a la primera edición
+const originalRevisionNote = "Versión original"
+
+// PostRevisionService maneja el historial de revisiones de los posts
+type PostRevisionService struct {
+	db     *sql.DB
+	cfg    config.ContentConfig
+	logger *logrus.Logger
+}
+
+// NewPostRevisionService crea una nueva instancia del servicio de revisiones
+func NewPostRevisionService(db *sql.DB, cfg config.ContentConfig, logger *logrus.Logger) *PostRevisionService {
+	return &PostRevisionService{
+		db:     db,
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// GetRevisions obtiene las revisiones de un post, de la más reciente a la más antigua,
+// sin el contenido. Los autores solo pueden ver las revisiones de sus propios posts.
+func (s *PostRevisionService) GetRevisions(postID, actorID uuid.UUID, actorRole string) ([]models.PostRevision, error) {
+	if err := s.authorize(postID, actorID, actorRole); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT r.id, r.post_id, r.revision, r.title, '', '', r.editor_id, COALESCE(r.note, ''), r.created_at,
+		       u.username as editor_username, u.first_name as editor_first_name, u.last_name as editor_last_name
+		FROM post_revisions r
+		LEFT JOIN users u ON r.editor_id = u.id
+		WHERE r.post_id = $1
+		ORDER BY r.revision DESC
+	`
+
+	rows, err := s.db.Query(query, postID)
+	if err != nil {
+		s.logger.Errorf("Error obteniendo revisiones: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []models.PostRevision
+	for rows.Next() {
+		revision, err := s.scanRevision(rows)
+		if err != nil {
+			s.logger.Errorf("Error escaneando revisión: %v", err)
+			continue
+		}
+		revisions = append(revisions, *revision)
+	}
+
+	return revisions, nil
+}
+
+// GetRevision obtiene una revisión completa de un post
+func (s *PostRevisionService) GetRevision(postID uuid.UUID, number int, actorID uuid.UUID, actorRole string) (*models.PostRevision, error) {
+	if err := s.authorize(postID, actorID, actorRole); err != nil {
+		return nil, err
+	}
+
+	return s.getRevision(postID, number)
+}
+
+// DiffRevisions calcula las diferencias de título, extracto y contenido entre dos revisiones
+func (s *PostRevisionService) DiffRevisions(postID uuid.UUID, from, to int, mode string, actorID uuid.UUID, actorRole string) (*models.PostRevisionDiff, error) {
+	if !diff.IsValidMode(mode) {
+		return nil, fmt.Errorf("modo de diff inválido")
+	}
+
+	if err := s.authorize(postID, actorID, actorRole); err != nil {
+		return nil, err
+	}
+
+	fromRevision, err := s.getRevision(postID, from)
+	if err != nil {
+		return nil, err
+	}
+	toRevision, err := s.getRevision(postID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.PostRevisionDiff{
+		PostID:  postID,
+		From:    from,
+		To:      to,
+		Mode:    mode,
+		Title:   diff.Words(fromRevision.Title, toRevision.Title),
+		Excerpt: diff.Text(fromRevision.Excerpt, toRevision.Excerpt, mode),
+		Content: diff.Text(fromRevision.Content, toRevision.Content, mode),
+	}
+	result.Stats = diff.Summarize(result.Content, mode)
+
+	return result, nil
+}
+
+// PurgeExpired elimina las revisiones más antiguas que la antigüedad máxima configurada.
+// La última revisión de cada post siempre se conserva.
+func (s *PostRevisionService) PurgeExpired() (int64, error) {
+	if s.cfg.RevisionMaxAge <= 0 {
+		return 0, nil
+	}
+
+	result, err := s.db.Exec(`
+		DELETE FROM post_revisions r
+		WHERE r.created_at < $1
+		  AND r.revision < (SELECT MAX(revision) FROM post_revisions WHERE post_id = r.post_id)
+	`, time.Now().Add(-s.cfg.RevisionMaxAge))
+	if err != nil {
+		s.logger.Errorf("Error eliminando revisiones antiguas: %v", err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// StartSweeper elimina periódicamente las revisiones antiguas hasta que el contexto se cancele
+func (s *PostRevisionService) StartSweeper(ctx context.Context, interval time.Duration) {
+	if interval <= 0 || s.cfg.RevisionMaxAge <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.PurgeExpired()
+		}
+	}
+}
+
+// record guarda una nueva revisión con el estado actual del post dentro de la transacción
+// y aplica los límites de retención. Si el post aún no tiene revisiones, antes se guarda
+// su versión previa para no perderla. La fila del post debe estar bloqueada por la transacción.
+func (s *PostRevisionService) record(tx *sql.Tx, previous, current *models.Post, editorID uuid.UUID, note string) error {
+	_, err := tx.Exec(`
+		INSERT INTO post_revisions (post_id, revision, title, content, excerpt, editor_id, note, created_at)
+		SELECT $1, 1, $2, $3, $4, $5, $6, $7
+		WHERE NOT EXISTS (SELECT 1 FROM post_revisions WHERE post_id = $1)
+	`, previous.ID, previous.Title, previous.Content, previous.Excerpt, previous.AuthorID,
+		originalRevisionNote, previous.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("error guardando versión original: %w", err)
+	}
+
+	var number int
+	err = tx.QueryRow(`
+		INSERT INTO post_revisions (post_id, revision, title, content, excerpt, editor_id, note)
+		SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4, $5, $6
+		FROM post_revisions WHERE post_id = $1
+		RETURNING revision
+	`, current.ID, current.Title, current.Content, current.Excerpt, editorID, note).Scan(&number)
+	if err != nil {
+		return fmt.Errorf("error guardando revisión: %w", err)
+	}
+
+	if s.cfg.MaxRevisions > 0 {
+		_, err = tx.Exec("DELETE FROM post_revisions WHERE post_id = $1 AND revision <= $2",
+			current.ID, number-s.cfg.MaxRevisions)
+		if err != nil {
+			return fmt.Errorf("error aplicando retención de revisiones: %w", err)
+		}
+	}
+
+	if s.cfg.RevisionMaxAge > 0 {
+		_, err = tx.Exec("DELETE FROM post_revisions WHERE post_id = $1 AND revision < $2 AND created_at < $3",
+			current.ID, number, time.Now().Add(-s.cfg.RevisionMaxAge))
+		if err != nil {
+			return fmt.Errorf("error aplicando retención de revisiones: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// getRevision obtiene una revisión completa sin verificar permisos
+func (s *PostRevisionService) getRevision(postID uuid.UUID, number int) (*models.PostRevision, error) {
+	query := `
+		SELECT r.id, r.post_id, r.revision, r.title, r.content, COALESCE(r.excerpt, ''), r.editor_id,
+		       COALESCE(r.note, ''), r.created_at,
+		       u.username as editor_username, u.first_name as editor_first_name, u.last_name as editor_last_name
+		FROM post_revisions r
+		LEFT JOIN users u ON r.editor_id = u.id
+		WHERE r.post_id = $1 AND r.revision = $2
+	`
+
+	revision, err := s.scanRevision(s.db.QueryRow(query, postID, number))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("revisión no encontrada")
+		}
+		s.logger.Errorf("Error obteniendo revisión: %v", err)
+		return nil, err
+	}
+
+	return revision, nil
+}
+
+// authorize verifica que el post exista y que el usuario pueda ver su historial
+func (s *PostRevisionService) authorize(postID, actorID uuid.UUID, actorRole string) error {
+	var authorID uuid.NullUUID
+	err := s.db.QueryRow("SELECT author_id FROM posts WHERE id = $1 AND deleted_at IS NULL", postID).Scan(&authorID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("post no encontrado")
+		}
+		s.logger.Errorf("Error verificando autor del post: %v", err)
+		return err
+	}
+
+	if !rbac.IsModerator(actorRole) && (!authorID.Valid || authorID.UUID != actorID) {
+		return fmt.Errorf("no tienes permiso para ver las revisiones de este post")
+	}
+
+	return nil
+}
+
+// scanRevision escanea una fila de post_revisions con los datos del editor
+func (s *PostRevisionService) scanRevision(row interface{ Scan(...interface{}) error }) (*models.PostRevision, error) {
+	var revision models.PostRevision
+	var editorUsername, editorFirstName, editorLastName sql.NullString
+
+	err := row.Scan(
+		&revision.ID, &revision.PostID, &revision.Revision, &revision.Title, &revision.Content,
+		&revision.Excerpt, &revision.EditorID, &revision.Note, &revision.CreatedAt,
+		&editorUsername, &editorFirstName, &editorLastName,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Construir editor si existe
+	if editorUsername.Valid && revision.EditorID != nil {
+		revision.Editor = &models.User{
+			ID:        *revision.EditorID,
+			Username:  editorUsername.String,
+			FirstName: editorFirstName.String,
+			LastName:  editorLastName.String,
+		}
+	}
+
+	return &revision, nil
+}
@@ -3,28 +3,58 @@ package services
 import (
 	"database/sql"
 	"fmt"
+	"html"
 	"strings"
 	"time"
 
 	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/pkg/cursor"
+	"github.com/alan.bermudez/goasync/pkg/markdown"
+	"github.com/alan.bermudez/goasync/pkg/rbac"
+	"github.com/alan.bermudez/goasync/pkg/slug"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 )
 
 // PostService maneja la lógica de negocio para posts
 type PostService struct {
-	db     *sql.DB
-	logger *logrus.Logger
+	db             *sql.DB
+	revisions      *PostRevisionService
+	searchLanguage string
+	logger         *logrus.Logger
 }
 
-// NewPostService crea una nueva instancia del servicio de posts
-func NewPostService(db *sql.DB, logger *logrus.Logger) *PostService {
+// NewPostService crea una nueva instancia del servicio de posts. searchLanguage es la
+// configuración de búsqueda de texto de Postgres usada para indexar y buscar (ej: spanish).
+func NewPostService(db *sql.DB, revisions *PostRevisionService, searchLanguage string, logger *logrus.Logger) *PostService {
 	return &PostService{
-		db:     db,
-		logger: logger,
+		db:             db,
+		revisions:      revisions,
+		searchLanguage: searchLanguage,
+		logger:         logger,
 	}
 }
 
+// maxPostSlugLength es la longitud de la columna posts.slug
+const maxPostSlugLength = 255
+
+// maxSlugAttempts es el número de intentos de crear o renombrar un post, una categoría o un
+// tag cuando otra petición ocupa a la vez el mismo slug
+const maxSlugAttempts = 5
+
+// Delimitadores que ts_headline coloca alrededor de los términos encontrados. Se reemplazan
+// por <mark> después de escapar el fragmento para que el contenido no pueda inyectar HTML.
+const (
+	headlineStart = "[[["
+	headlineStop  = "]]]"
+)
+
+// highlight escapa un fragmento de ts_headline y marca los términos encontrados con <mark>
+func highlight(fragment string) string {
+	return strings.NewReplacer(headlineStart, "<mark>", headlineStop, "</mark>").Replace(html.EscapeString(fragment))
+}
+
 // GetAllPosts obtiene todos los posts con paginación y filtros
 func (s *PostService) GetAllPosts(filter models.PostFilter) (*models.PostListResponse, error) {
 	offset := (filter.Page - 1) * filter.PerPage
@@ -32,7 +62,8 @@ func (s *PostService) GetAllPosts(filter models.PostFilter) (*models.PostListRes
 	// Construir query base
 	baseQuery := `
 		SELECT p.id, p.title, p.slug, p.content, p.excerpt, p.author_id, p.category_id,
-		       p.status, p.published_at, p.created_at, p.updated_at,
+		       p.status, p.published_at, p.scheduled_at, p.created_at, p.updated_at,
+		       p.featured_image_id, p.content_html, p.toc, p.word_count, p.reading_time, p.render_version,
 		       u.username as author_username, u.first_name as author_first_name, u.last_name as author_last_name,
 		       c.name as category_name, c.slug as category_slug
 		FROM posts p
@@ -40,7 +71,8 @@ func (s *PostService) GetAllPosts(filter models.PostFilter) (*models.PostListRes
 		LEFT JOIN categories c ON p.category_id = c.id
 	`
 
-	whereConditions := []string{}
+	// Los posts en la papelera nunca se listan
+	whereConditions := []string{"p.deleted_at IS NULL"}
 	args := []interface{}{}
 	argCount := 0
 
@@ -63,40 +95,144 @@ func (s *PostService) GetAllPosts(filter models.PostFilter) (*models.PostListRes
 		args = append(args, filter.AuthorID)
 	}
 
-	if filter.Search != "" {
+	// Los filtros de tags usan subconsultas para no duplicar posts y mantener el total correcto
+	if len(filter.Tags) > 0 {
+		tagIDs, complete, err := s.resolveTags(filter.Tags)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(tagIDs) == 0 || (filter.TagMatch == models.TagMatchAll && !complete) {
+			// Ningún post puede tener tags que no existen
+			whereConditions = append(whereConditions, "FALSE")
+		} else {
+			argCount++
+			if filter.TagMatch == models.TagMatchAll {
+				whereConditions = append(whereConditions, fmt.Sprintf(
+					"(SELECT COUNT(*) FROM post_tags pt WHERE pt.post_id = p.id AND pt.tag_id = ANY($%d::uuid[])) = %d",
+					argCount, len(tagIDs)))
+			} else {
+				whereConditions = append(whereConditions, fmt.Sprintf(
+					"EXISTS (SELECT 1 FROM post_tags pt WHERE pt.post_id = p.id AND pt.tag_id = ANY($%d::uuid[]))", argCount))
+			}
+			args = append(args, pq.Array(tagIDs))
+		}
+	}
+
+	if len(filter.ExcludeTags) > 0 {
+		tagIDs, _, err := s.resolveTags(filter.ExcludeTags)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(tagIDs) > 0 {
+			argCount++
+			whereConditions = append(whereConditions, fmt.Sprintf(
+				"NOT EXISTS (SELECT 1 FROM post_tags pt WHERE pt.post_id = p.id AND pt.tag_id = ANY($%d::uuid[]))", argCount))
+			args = append(args, pq.Array(tagIDs))
+		}
+	}
+
+	// La búsqueda de texto completo usa el índice de search_vector y ordena por relevancia;
+	// el modo simple conserva la búsqueda por subcadena
+	fullText := filter.Search != "" && filter.SearchMode != models.SearchModeSimple
+	if fullText && filter.Cursor != nil {
+		return nil, fmt.Errorf("la paginación por cursor no admite búsqueda de texto completo")
+	}
+
+	tsQuery := ""
+	if fullText {
+		argCount++
+		languageArg := argCount
+		argCount++
+		tsQuery = fmt.Sprintf("websearch_to_tsquery($%d::regconfig, $%d)", languageArg, argCount)
+		whereConditions = append(whereConditions, fmt.Sprintf("p.search_vector @@ %s", tsQuery))
+		args = append(args, s.searchLanguage, filter.Search)
+	} else if filter.Search != "" {
 		argCount++
 		whereConditions = append(whereConditions, fmt.Sprintf("(p.title ILIKE $%d OR p.content ILIKE $%d OR p.excerpt ILIKE $%d)", argCount, argCount, argCount))
 		args = append(args, "%"+filter.Search+"%")
 	}
 
-	// Construir WHERE clause
-	whereClause := ""
-	if len(whereConditions) > 0 {
-		whereClause = "WHERE " + strings.Join(whereConditions, " AND ")
+	// En modo cursor se continúa desde la clave (published_at, id) del último elemento visto
+	if filter.Cursor != nil {
+		arg := func(v interface{}) string {
+			argCount++
+			args = append(args, v)
+			return fmt.Sprintf("$%d", argCount)
+		}
+		if condition := filter.Cursor.Where("p.published_at", "p.id", arg); condition != "" {
+			whereConditions = append(whereConditions, condition)
+		}
 	}
 
-	// Query para contar total
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM posts p %s", whereClause)
+	// Construir WHERE clause
+	whereClause := "WHERE " + strings.Join(whereConditions, " AND ")
+
+	// Query para contar total; el modo cursor lo omite para no recorrer toda la tabla
 	var total int
-	err := s.db.QueryRow(countQuery, args...).Scan(&total)
-	if err != nil {
-		s.logger.Errorf("Error contando posts: %v", err)
-		return nil, err
+	if filter.Cursor == nil {
+		countQuery := fmt.Sprintf("SELECT COUNT(*) FROM posts p %s", whereClause)
+		err := s.db.QueryRow(countQuery, args...).Scan(&total)
+		if err != nil {
+			s.logger.Errorf("Error contando posts: %v", err)
+			return nil, err
+		}
 	}
 
 	// Query para obtener posts
-	argCount++
-	limitArg := fmt.Sprintf("$%d", argCount)
-	argCount++
-	offsetArg := fmt.Sprintf("$%d", argCount)
-	args = append(args, filter.PerPage, offset)
-
-	query := fmt.Sprintf(`
-		%s
-		%s
-		ORDER BY p.published_at DESC NULLS LAST, p.created_at DESC
-		LIMIT %s OFFSET %s
-	`, baseQuery, whereClause, limitArg, offsetArg)
+	var query, limitArg, offsetArg string
+	if filter.Cursor != nil {
+		argCount++
+		args = append(args, filter.Cursor.Fetch())
+
+		query = fmt.Sprintf(`
+			%s
+			%s
+			ORDER BY %s
+			LIMIT $%d
+		`, baseQuery, whereClause, filter.Cursor.OrderBy("p.published_at", "p.id"), argCount)
+	} else {
+		argCount++
+		limitArg = fmt.Sprintf("$%d", argCount)
+		argCount++
+		offsetArg = fmt.Sprintf("$%d", argCount)
+		args = append(args, filter.PerPage, offset)
+
+		query = fmt.Sprintf(`
+			%s
+			%s
+			ORDER BY p.published_at DESC NULLS LAST, p.created_at DESC
+			LIMIT %s OFFSET %s
+		`, baseQuery, whereClause, limitArg, offsetArg)
+	}
+
+	if fullText {
+		// Ordenar y paginar por relevancia primero para calcular ts_headline solo en la página pedida
+		query = fmt.Sprintf(`
+			SELECT p.id, p.title, p.slug, p.content, p.excerpt, p.author_id, p.category_id,
+			       p.status, p.published_at, p.scheduled_at, p.created_at, p.updated_at,
+			       p.featured_image_id, p.content_html, p.toc, p.word_count, p.reading_time, p.render_version,
+			       u.username as author_username, u.first_name as author_first_name, u.last_name as author_last_name,
+			       c.name as category_name, c.slug as category_slug,
+			       ranked.rank,
+			       ts_headline(p.search_language, p.title, %[1]s,
+			                   'HighlightAll=true, StartSel="%[5]s", StopSel="%[6]s"'),
+			       ts_headline(p.search_language, p.content, %[1]s,
+			                   'MaxFragments=2, MaxWords=30, MinWords=10, FragmentDelimiter=" … ", StartSel="%[5]s", StopSel="%[6]s"')
+			FROM (
+				SELECT p.id, p.published_at, p.created_at, ts_rank_cd(p.search_vector, %[1]s) AS rank
+				FROM posts p
+				%[2]s
+				ORDER BY rank DESC, p.published_at DESC NULLS LAST, p.created_at DESC
+				LIMIT %[3]s OFFSET %[4]s
+			) ranked
+			JOIN posts p ON p.id = ranked.id
+			LEFT JOIN users u ON p.author_id = u.id
+			LEFT JOIN categories c ON p.category_id = c.id
+			ORDER BY ranked.rank DESC, ranked.published_at DESC NULLS LAST, ranked.created_at DESC
+		`, tsQuery, whereClause, limitArg, offsetArg, headlineStart, headlineStop)
+	}
 
 	rows, err := s.db.Query(query, args...)
 	if err != nil {
@@ -110,18 +246,36 @@ func (s *PostService) GetAllPosts(filter models.PostFilter) (*models.PostListRes
 		var post models.Post
 		var authorUsername, authorFirstName, authorLastName sql.NullString
 		var categoryName, categorySlug sql.NullString
+		var match models.PostSearchMatch
+		var render postRender
 
-		err := rows.Scan(
+		dest := []interface{}{
 			&post.ID, &post.Title, &post.Slug, &post.Content, &post.Excerpt,
-			&post.AuthorID, &post.CategoryID, &post.Status, &post.PublishedAt,
+			&post.AuthorID, &post.CategoryID, &post.Status, &post.PublishedAt, &post.ScheduledAt,
 			&post.CreatedAt, &post.UpdatedAt,
+			&post.FeaturedImageID, &render.html, &render.toc, &render.words, &render.minutes, &render.version,
 			&authorUsername, &authorFirstName, &authorLastName,
 			&categoryName, &categorySlug,
-		)
+		}
+		if fullText {
+			dest = append(dest, &match.Rank, &match.Title, &match.Snippet)
+		}
+
+		err := rows.Scan(dest...)
 		if err != nil {
 			s.logger.Errorf("Error escaneando post: %v", err)
 			continue
 		}
+		if err := render.apply(&post); err != nil {
+			s.logger.Errorf("Error renderizando post: %v", err)
+			continue
+		}
+
+		if fullText {
+			match.Title = highlight(match.Title)
+			match.Snippet = highlight(match.Snippet)
+			post.Search = &match
+		}
 
 		// Construir relaciones
 		if authorUsername.Valid {
@@ -144,6 +298,19 @@ func (s *PostService) GetAllPosts(filter models.PostFilter) (*models.PostListRes
 		posts = append(posts, post)
 	}
 
+	if filter.Cursor != nil {
+		posts, next, prev := cursor.Trim(filter.Cursor, posts, func(p models.Post) cursor.Cursor {
+			return cursor.New(p.PublishedAt, p.ID)
+		})
+
+		return &models.PostListResponse{
+			Posts:      posts,
+			PerPage:    filter.Cursor.Limit,
+			NextCursor: next,
+			PrevCursor: prev,
+		}, nil
+	}
+
 	// Calcular total de páginas
 	totalPages := (total + filter.PerPage - 1) / filter.PerPage
 
@@ -160,23 +327,26 @@ func (s *PostService) GetAllPosts(filter models.PostFilter) (*models.PostListRes
 func (s *PostService) GetPostByID(id uuid.UUID) (*models.Post, error) {
 	query := `
 		SELECT p.id, p.title, p.slug, p.content, p.excerpt, p.author_id, p.category_id,
-		       p.status, p.published_at, p.created_at, p.updated_at,
+		       p.status, p.published_at, p.scheduled_at, p.created_at, p.updated_at,
+		       p.featured_image_id, p.content_html, p.toc, p.word_count, p.reading_time, p.render_version,
 		       u.username as author_username, u.first_name as author_first_name, u.last_name as author_last_name,
 		       c.name as category_name, c.slug as category_slug
 		FROM posts p
 		LEFT JOIN users u ON p.author_id = u.id
 		LEFT JOIN categories c ON p.category_id = c.id
-		WHERE p.id = $1
+		WHERE p.id = $1 AND p.deleted_at IS NULL
 	`
 
 	var post models.Post
 	var authorUsername, authorFirstName, authorLastName sql.NullString
 	var categoryName, categorySlug sql.NullString
+	var render postRender
 
 	err := s.db.QueryRow(query, id).Scan(
 		&post.ID, &post.Title, &post.Slug, &post.Content, &post.Excerpt,
-		&post.AuthorID, &post.CategoryID, &post.Status, &post.PublishedAt,
+		&post.AuthorID, &post.CategoryID, &post.Status, &post.PublishedAt, &post.ScheduledAt,
 		&post.CreatedAt, &post.UpdatedAt,
+		&post.FeaturedImageID, &render.html, &render.toc, &render.words, &render.minutes, &render.version,
 		&authorUsername, &authorFirstName, &authorLastName,
 		&categoryName, &categorySlug,
 	)
@@ -189,6 +359,11 @@ func (s *PostService) GetPostByID(id uuid.UUID) (*models.Post, error) {
 		return nil, err
 	}
 
+	if err := render.apply(&post); err != nil {
+		s.logger.Errorf("Error renderizando post: %v", err)
+		return nil, err
+	}
+
 	// Construir relaciones
 	if authorUsername.Valid {
 		post.Author = &models.User{
@@ -214,23 +389,26 @@ func (s *PostService) GetPostByID(id uuid.UUID) (*models.Post, error) {
 func (s *PostService) GetPostBySlug(slug string) (*models.Post, error) {
 	query := `
 		SELECT p.id, p.title, p.slug, p.content, p.excerpt, p.author_id, p.category_id,
-		       p.status, p.published_at, p.created_at, p.updated_at,
+		       p.status, p.published_at, p.scheduled_at, p.created_at, p.updated_at,
+		       p.featured_image_id, p.content_html, p.toc, p.word_count, p.reading_time, p.render_version,
 		       u.username as author_username, u.first_name as author_first_name, u.last_name as author_last_name,
 		       c.name as category_name, c.slug as category_slug
 		FROM posts p
 		LEFT JOIN users u ON p.author_id = u.id
 		LEFT JOIN categories c ON p.category_id = c.id
-		WHERE p.slug = $1
+		WHERE p.slug = $1 AND p.deleted_at IS NULL
 	`
 
 	var post models.Post
 	var authorUsername, authorFirstName, authorLastName sql.NullString
 	var categoryName, categorySlug sql.NullString
+	var render postRender
 
 	err := s.db.QueryRow(query, slug).Scan(
 		&post.ID, &post.Title, &post.Slug, &post.Content, &post.Excerpt,
-		&post.AuthorID, &post.CategoryID, &post.Status, &post.PublishedAt,
+		&post.AuthorID, &post.CategoryID, &post.Status, &post.PublishedAt, &post.ScheduledAt,
 		&post.CreatedAt, &post.UpdatedAt,
+		&post.FeaturedImageID, &render.html, &render.toc, &render.words, &render.minutes, &render.version,
 		&authorUsername, &authorFirstName, &authorLastName,
 		&categoryName, &categorySlug,
 	)
@@ -243,6 +421,11 @@ func (s *PostService) GetPostBySlug(slug string) (*models.Post, error) {
 		return nil, err
 	}
 
+	if err := render.apply(&post); err != nil {
+		s.logger.Errorf("Error renderizando post: %v", err)
+		return nil, err
+	}
+
 	// Construir relaciones
 	if authorUsername.Valid {
 		post.Author = &models.User{
@@ -273,10 +456,10 @@ func (s *PostService) GetPostWithTags(id uuid.UUID) (*models.Post, error) {
 
 	// Obtener tags del post
 	tagsQuery := `
-		SELECT t.id, t.name, t.slug, t.description, t.created_at
+		SELECT t.id, t.name, t.slug, t.description, t.created_at, t.updated_at
 		FROM tags t
 		JOIN post_tags pt ON t.id = pt.tag_id
-		WHERE pt.post_id = $1
+		WHERE pt.post_id = $1 AND t.deleted_at IS NULL
 		ORDER BY t.name
 	`
 
@@ -290,7 +473,7 @@ func (s *PostService) GetPostWithTags(id uuid.UUID) (*models.Post, error) {
 	var tags []models.Tag
 	for rows.Next() {
 		var tag models.Tag
-		err := rows.Scan(&tag.ID, &tag.Name, &tag.Slug, &tag.Description, &tag.CreatedAt)
+		err := rows.Scan(&tag.ID, &tag.Name, &tag.Slug, &tag.Description, &tag.CreatedAt, &tag.UpdatedAt)
 		if err != nil {
 			s.logger.Errorf("Error escaneando tag: %v", err)
 			continue
@@ -302,6 +485,130 @@ func (s *PostService) GetPostWithTags(id uuid.UUID) (*models.Post, error) {
 	return post, nil
 }
 
+// Pesos de la puntuación de posts relacionados. Cada tag compartido suma ln(1 + N/n), con N
+// posts publicados y n posts publicados con ese tag, así que los tags raros pesan más.
+const (
+	relatedCategoryWeight = 1.0
+	relatedTextWeight     = 10.0
+)
+
+// GetRelatedPosts obtiene los posts publicados más relacionados con un post según los tags
+// compartidos, la categoría y, si withText es true, la similitud del título con el texto.
+// Si no hay suficientes coincidencias se completa con los posts publicados más recientes.
+// La respuesta se cachea públicamente, así que un post no publicado o en la papelera se trata
+// como inexistente.
+func (s *PostService) GetRelatedPosts(id uuid.UUID, limit int, withText bool) ([]models.RelatedPost, error) {
+	// Verificar que el post existe y es público
+	var published bool
+	err := s.db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM posts WHERE id = $1 AND status = 'published' AND deleted_at IS NULL)
+	`, id).Scan(&published)
+	if err != nil {
+		s.logger.Errorf("Error verificando post: %v", err)
+		return nil, err
+	}
+	if !published {
+		return nil, fmt.Errorf("post no encontrado")
+	}
+
+	query := `
+		WITH source AS (
+			SELECT id, category_id, title, search_language FROM posts WHERE id = $1
+		),
+		published AS (
+			SELECT COUNT(*)::float8 AS total FROM posts WHERE status = 'published' AND deleted_at IS NULL
+		),
+		tag_weights AS (
+			SELECT pt.tag_id, ln(1 + (SELECT total FROM published) / COUNT(*)) AS weight
+			FROM post_tags pt
+			JOIN posts tp ON tp.id = pt.post_id AND tp.status = 'published' AND tp.deleted_at IS NULL
+			WHERE pt.tag_id IN (SELECT tag_id FROM post_tags WHERE post_id = $1)
+			GROUP BY pt.tag_id
+		),
+		tag_scores AS (
+			SELECT pt.post_id, SUM(tw.weight) AS score, COUNT(*) AS shared_tags
+			FROM post_tags pt
+			JOIN tag_weights tw ON tw.tag_id = pt.tag_id
+			WHERE pt.post_id <> $1
+			GROUP BY pt.post_id
+		)
+		SELECT p.id, p.title, p.slug, p.content, p.excerpt, p.author_id, p.category_id,
+		       p.status, p.published_at, p.scheduled_at, p.created_at, p.updated_at,
+		       p.featured_image_id, p.content_html, p.toc, p.word_count, p.reading_time, p.render_version,
+		       u.username as author_username, u.first_name as author_first_name, u.last_name as author_last_name,
+		       c.name as category_name, c.slug as category_slug,
+		       COALESCE(ts.shared_tags, 0),
+		       COALESCE(ts.score, 0)
+		         + CASE WHEN p.category_id = s.category_id THEN $2::float8 ELSE 0 END
+		         + CASE WHEN $3::boolean THEN $4::float8 * ts_rank_cd(p.search_vector,
+		             replace(plainto_tsquery(s.search_language, s.title)::text, '&', '|')::tsquery) ELSE 0 END
+		         AS score
+		FROM posts p
+		CROSS JOIN source s
+		LEFT JOIN tag_scores ts ON ts.post_id = p.id
+		LEFT JOIN users u ON p.author_id = u.id
+		LEFT JOIN categories c ON p.category_id = c.id
+		WHERE p.status = 'published' AND p.deleted_at IS NULL AND p.id <> s.id
+		ORDER BY score DESC, p.published_at DESC NULLS LAST, p.id DESC
+		LIMIT $5
+	`
+
+	rows, err := s.db.Query(query, id, relatedCategoryWeight, withText, relatedTextWeight, limit)
+	if err != nil {
+		s.logger.Errorf("Error obteniendo posts relacionados: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	related := []models.RelatedPost{}
+	for rows.Next() {
+		var item models.RelatedPost
+		var authorUsername, authorFirstName, authorLastName sql.NullString
+		var categoryName, categorySlug sql.NullString
+		var render postRender
+
+		err := rows.Scan(
+			&item.ID, &item.Title, &item.Slug, &item.Content, &item.Excerpt,
+			&item.AuthorID, &item.CategoryID, &item.Status, &item.PublishedAt, &item.ScheduledAt,
+			&item.CreatedAt, &item.UpdatedAt,
+			&item.FeaturedImageID, &render.html, &render.toc, &render.words, &render.minutes, &render.version,
+			&authorUsername, &authorFirstName, &authorLastName,
+			&categoryName, &categorySlug,
+			&item.SharedTags, &item.Score,
+		)
+		if err != nil {
+			s.logger.Errorf("Error escaneando post relacionado: %v", err)
+			continue
+		}
+		if err := render.apply(&item.Post); err != nil {
+			s.logger.Errorf("Error renderizando post relacionado: %v", err)
+			continue
+		}
+
+		// Construir relaciones
+		if authorUsername.Valid {
+			item.Author = &models.User{
+				ID:        item.AuthorID,
+				Username:  authorUsername.String,
+				FirstName: authorFirstName.String,
+				LastName:  authorLastName.String,
+			}
+		}
+
+		if categoryName.Valid {
+			item.Category = &models.Category{
+				ID:   item.CategoryID,
+				Name: categoryName.String,
+				Slug: categorySlug.String,
+			}
+		}
+
+		related = append(related, item)
+	}
+
+	return related, nil
+}
+
 // GetPublishedPosts obtiene solo posts publicados
 func (s *PostService) GetPublishedPosts(page, perPage int) (*models.PostListResponse, error) {
 	filter := models.PostFilter{
@@ -312,71 +619,151 @@ func (s *PostService) GetPublishedPosts(page, perPage int) (*models.PostListResp
 	return s.GetAllPosts(filter)
 }
 
-// CreatePost crea un nuevo post
-func (s *PostService) CreatePost(req models.PostCreateRequest, authorID uuid.UUID) (*models.Post, error) {
-	// Generar slug si no se proporciona
-	slug := req.Title
-	if slug == "" {
-		slug = "untitled"
+// CreatePost crea un nuevo post. actorRole es el rol del autor, que determina qué imágenes
+// de la biblioteca de medios puede usar como imagen destacada.
+func (s *PostService) CreatePost(req models.PostCreateRequest, authorID uuid.UUID, actorRole string) (*models.Post, error) {
+	if req.FeaturedImageID != nil {
+		if err := s.checkFeaturedImage(*req.FeaturedImageID, authorID, actorRole); err != nil {
+			return nil, err
+		}
+	}
+
+	// Determinar published_at o la fecha de publicación programada
+	var publishedAt, scheduledAt *time.Time
+	switch req.Status {
+	case "published":
+		now := time.Now()
+		publishedAt = &now
+	case "scheduled":
+		if err := validatePublishAt(req.PublishAt); err != nil {
+			return nil, err
+		}
+		scheduledAt = req.PublishAt
 	}
 
-	// Convertir a slug básico
-	slug = strings.ToLower(slug)
-	slug = strings.ReplaceAll(slug, " ", "-")
-	slug = strings.ReplaceAll(slug, "_", "-")
+	// Generar un slug único a partir del título. El post y sus tags se crean en una
+	// transacción para no dejar un post sin los tags pedidos.
+	return s.withUniqueSlug(slug.Make(req.Title), uuid.Nil, func(postSlug string) (*models.Post, error) {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return nil, err
+		}
+		defer tx.Rollback()
+
+		post, err := s.insertPost(tx, req, authorID, postSlug, publishedAt, scheduledAt, nil, nil)
+		if err != nil {
+			return nil, err
+		}
 
-	// Verificar si el slug ya existe
-	existingPost, _ := s.GetPostBySlug(slug)
-	if existingPost != nil {
-		// Agregar timestamp al slug para hacerlo único
-		slug = fmt.Sprintf("%s-%d", slug, time.Now().Unix())
+		if err := tx.Commit(); err != nil {
+			s.logger.Errorf("Error confirmando creación del post: %v", err)
+			return nil, err
+		}
+
+		return post, nil
+	})
+}
+
+// ImportPost crea un post importado desde otra plataforma dentro de una transacción,
+// conservando su slug (si está libre) y sus fechas originales. El estado se guarda tal cual:
+// un post importado como scheduled debe tener PublishAt en el futuro.
+func (s *PostService) ImportPost(tx *sql.Tx, req models.PostImportRequest, authorID uuid.UUID) (*models.Post, error) {
+	base := slug.Make(req.Slug)
+	if base == "" {
+		base = slug.Make(req.Title)
 	}
 
-	// Determinar published_at
-	var publishedAt *time.Time
-	if req.Status == "published" {
-		now := time.Now()
-		publishedAt = &now
+	var scheduledAt *time.Time
+	if req.Status == "scheduled" {
+		if err := validatePublishAt(req.PublishAt); err != nil {
+			return nil, err
+		}
+		scheduledAt = req.PublishAt
+	}
+
+	var createdAt, updatedAt *time.Time
+	if !req.CreatedAt.IsZero() {
+		createdAt = &req.CreatedAt
+	}
+	if !req.UpdatedAt.IsZero() {
+		updatedAt = &req.UpdatedAt
+	}
+
+	// El savepoint permite reintentar con otro slug sin abortar la transacción
+	return s.withUniqueSlug(base, uuid.Nil, func(postSlug string) (*models.Post, error) {
+		if _, err := tx.Exec("SAVEPOINT import_post"); err != nil {
+			return nil, err
+		}
+		post, err := s.insertPost(tx, req.PostCreateRequest, authorID, postSlug, req.PublishedAt, scheduledAt, createdAt, updatedAt)
+		if err != nil {
+			if _, rollbackErr := tx.Exec("ROLLBACK TO SAVEPOINT import_post"); rollbackErr != nil {
+				return nil, rollbackErr
+			}
+			return nil, err
+		}
+		return post, nil
+	})
+}
+
+// insertPost inserta un post ya validado y asocia sus tags usando la conexión o transacción
+// recibida. Sin createdAt ni updatedAt se usa la fecha actual.
+func (s *PostService) insertPost(q querier, req models.PostCreateRequest, authorID uuid.UUID, postSlug string, publishedAt, scheduledAt, createdAt, updatedAt *time.Time) (*models.Post, error) {
+	// Renderizar el Markdown para guardarlo junto al contenido
+	doc, toc, err := renderPostContent(req.Content)
+	if err != nil {
+		s.logger.Errorf("Error renderizando contenido del post: %v", err)
+		return nil, err
 	}
 
 	query := `
-		INSERT INTO posts (title, slug, content, excerpt, author_id, category_id, status, published_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		RETURNING id, title, slug, content, excerpt, author_id, category_id, status, published_at, created_at, updated_at
+		INSERT INTO posts (title, slug, content, excerpt, author_id, category_id, status, published_at, scheduled_at, search_language,
+		                   featured_image_id, content_html, toc, word_count, reading_time, render_version,
+		                   created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10::regconfig, $11, $12, $13, $14, $15, $16,
+		        COALESCE($17, NOW()), COALESCE($18, NOW()))
+		RETURNING id, title, slug, content, excerpt, author_id, category_id, status, published_at, scheduled_at, created_at, updated_at,
+		          featured_image_id
 	`
 
 	var post models.Post
-	err := s.db.QueryRow(query, req.Title, slug, req.Content, req.Excerpt,
-		authorID, req.CategoryID, req.Status, publishedAt).Scan(
+	err = q.QueryRow(query, req.Title, postSlug, req.Content, req.Excerpt,
+		authorID, req.CategoryID, req.Status, publishedAt, scheduledAt, s.searchLanguage, req.FeaturedImageID,
+		doc.HTML, toc, doc.WordCount, doc.ReadingTime, markdown.Version, createdAt, updatedAt).Scan(
 		&post.ID, &post.Title, &post.Slug, &post.Content, &post.Excerpt,
-		&post.AuthorID, &post.CategoryID, &post.Status, &post.PublishedAt,
-		&post.CreatedAt, &post.UpdatedAt,
+		&post.AuthorID, &post.CategoryID, &post.Status, &post.PublishedAt, &post.ScheduledAt,
+		&post.CreatedAt, &post.UpdatedAt, &post.FeaturedImageID,
 	)
 
 	if err != nil {
 		s.logger.Errorf("Error creando post: %v", err)
 		return nil, err
 	}
+	setPostRender(&post, doc)
 
 	// Asociar tags si se proporcionan
 	if len(req.TagIDs) > 0 {
-		err = s.associateTags(post.ID, req.TagIDs)
-		if err != nil {
-			s.logger.Errorf("Error asociando tags al post: %v", err)
+		if err := s.associateTags(q, post.ID, req.TagIDs); err != nil {
+			return nil, err
 		}
 	}
 
 	return &post, nil
 }
 
-// UpdatePost actualiza un post existente
-func (s *PostService) UpdatePost(id uuid.UUID, req models.PostUpdateRequest) (*models.Post, error) {
+// UpdatePost actualiza un post existente. Los autores solo pueden modificar sus propios posts.
+// Si ifMatch no es nil el post solo se actualiza si su updated_at es una de esas versiones.
+func (s *PostService) UpdatePost(id uuid.UUID, req models.PostUpdateRequest, ifMatch []time.Time, actorID uuid.UUID, actorRole string) (*models.Post, error) {
 	// Verificar que el post existe
 	existingPost, err := s.GetPostByID(id)
 	if err != nil {
 		return nil, err
 	}
 
+	// Verificar propiedad del post
+	if !rbac.IsModerator(actorRole) && existingPost.AuthorID != actorID {
+		return nil, fmt.Errorf("no tienes permiso para modificar este post")
+	}
+
 	// Actualizar campos
 	if req.Title != "" {
 		existingPost.Title = req.Title
@@ -399,49 +786,266 @@ func (s *PostService) UpdatePost(id uuid.UUID, req models.PostUpdateRequest) (*m
 		}
 	}
 
+	// Cambiar o quitar la imagen destacada
+	if req.RemoveFeaturedImage {
+		existingPost.FeaturedImageID = nil
+	} else if req.FeaturedImageID != nil {
+		if err := s.checkFeaturedImage(*req.FeaturedImageID, actorID, actorRole); err != nil {
+			return nil, err
+		}
+		existingPost.FeaturedImageID = req.FeaturedImageID
+	}
+
+	// Programar, reprogramar o desprogramar la publicación según el estado resultante
+	if existingPost.Status == "scheduled" {
+		if req.PublishAt != nil || existingPost.ScheduledAt == nil {
+			if err := validatePublishAt(req.PublishAt); err != nil {
+				return nil, err
+			}
+			existingPost.ScheduledAt = req.PublishAt
+		}
+		existingPost.PublishedAt = nil
+	} else {
+		existingPost.ScheduledAt = nil
+	}
+
+	// Regenerar el slug a partir del título; el anterior se conserva como redirección
+	var post *models.Post
+	if req.RegenerateSlug {
+		post, err = s.withUniqueSlug(slug.Make(existingPost.Title), id, func(postSlug string) (*models.Post, error) {
+			existingPost.Slug = postSlug
+			return s.save(existingPost, req.TagIDs, ifMatch, actorID, req.RevisionNote)
+		})
+	} else {
+		post, err = s.save(existingPost, req.TagIDs, ifMatch, actorID, req.RevisionNote)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return post, nil
+}
+
+// SchedulePost programa o reprograma la publicación de un post que aún no está publicado.
+// ifMatch condiciona la actualización a la versión del post (ver versionsParam).
+func (s *PostService) SchedulePost(id uuid.UUID, publishAt *time.Time, ifMatch []time.Time, actorID uuid.UUID, actorRole string) (*models.Post, error) {
+	if err := validatePublishAt(publishAt); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkOwnership(id, actorID, actorRole); err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE posts
+		SET status = 'scheduled', scheduled_at = $1, published_at = NULL, updated_at = $2
+		WHERE id = $3 AND status <> 'published' AND deleted_at IS NULL
+		  AND ($4::timestamptz[] IS NULL OR updated_at = ANY($4::timestamptz[]))
+		RETURNING id, title, slug, content, excerpt, author_id, category_id, status, published_at, scheduled_at, created_at, updated_at,
+		          featured_image_id, content_html, toc, word_count, reading_time, render_version
+	`
+
+	post, err := s.scanPost(s.db.QueryRow(query, publishAt, time.Now(), id, versionsParam(ifMatch)))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, s.scheduleConflict(id)
+		}
+		s.logger.Errorf("Error programando post: %v", err)
+		return nil, err
+	}
+
+	return post, nil
+}
+
+// scheduleConflict explica por qué no se pudo programar un post existente: ya estaba
+// publicado o su versión no coincidía con If-Match
+func (s *PostService) scheduleConflict(id uuid.UUID) error {
+	var status string
+	err := s.db.QueryRow("SELECT status FROM posts WHERE id = $1 AND deleted_at IS NULL", id).Scan(&status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("post no encontrado")
+		}
+		return err
+	}
+
+	if status == "published" {
+		return fmt.Errorf("el post ya está publicado")
+	}
+	return fmt.Errorf("el recurso fue modificado por otra petición")
+}
+
+// UnschedulePost cancela la publicación programada de un post y lo devuelve a borrador
+func (s *PostService) UnschedulePost(id uuid.UUID, actorID uuid.UUID, actorRole string) (*models.Post, error) {
+	if err := s.checkOwnership(id, actorID, actorRole); err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE posts
+		SET status = 'draft', scheduled_at = NULL, updated_at = $1
+		WHERE id = $2 AND status = 'scheduled' AND deleted_at IS NULL
+		RETURNING id, title, slug, content, excerpt, author_id, category_id, status, published_at, scheduled_at, created_at, updated_at,
+		          featured_image_id, content_html, toc, word_count, reading_time, render_version
+	`
+
+	post, err := s.scanPost(s.db.QueryRow(query, time.Now(), id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("el post no está programado")
+		}
+		s.logger.Errorf("Error desprogramando post: %v", err)
+		return nil, err
+	}
+
+	return post, nil
+}
+
+// RestoreRevision restaura el título, contenido y extracto de una revisión. La restauración
+// se guarda como una nueva revisión. Los autores solo pueden restaurar sus propios posts.
+func (s *PostService) RestoreRevision(id uuid.UUID, number int, note string, actorID uuid.UUID, actorRole string) (*models.Post, error) {
+	// Verificar que el post existe
+	existingPost, err := s.GetPostByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Verificar propiedad del post
+	if !rbac.IsModerator(actorRole) && existingPost.AuthorID != actorID {
+		return nil, fmt.Errorf("no tienes permiso para modificar este post")
+	}
+
+	revision, err := s.revisions.getRevision(id, number)
+	if err != nil {
+		return nil, err
+	}
+
+	existingPost.Title = revision.Title
+	existingPost.Content = revision.Content
+	existingPost.Excerpt = revision.Excerpt
+
+	if note == "" {
+		note = fmt.Sprintf("Restaurada desde la revisión %d", number)
+	}
+
+	return s.save(existingPost, nil, nil, actorID, note)
+}
+
+// save guarda los cambios de un post y, si cambió el título, el contenido o el extracto,
+// registra una revisión en la misma transacción. Si tagIDs no es nil reemplaza también los
+// tags del post. ifMatch condiciona el UPDATE a la versión del post (ver versionsParam).
+func (s *PostService) save(changes *models.Post, tagIDs []uuid.UUID, ifMatch []time.Time, editorID uuid.UUID, note string) (*models.Post, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Bloquear el post para que las ediciones concurrentes se registren en orden
+	var previous models.Post
+	err = tx.QueryRow(`
+		SELECT id, title, slug, content, excerpt, author_id, status, updated_at
+		FROM posts WHERE id = $1 AND deleted_at IS NULL FOR UPDATE
+	`, changes.ID).Scan(&previous.ID, &previous.Title, &previous.Slug, &previous.Content, &previous.Excerpt,
+		&previous.AuthorID, &previous.Status, &previous.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("post no encontrado")
+		}
+		s.logger.Errorf("Error bloqueando post: %v", err)
+		return nil, err
+	}
+
+	// El publicador pudo publicar el post después de leerlo; no volver a programarlo
+	if changes.Status == "scheduled" && previous.Status == "published" {
+		return nil, fmt.Errorf("el post ya está publicado")
+	}
+
+	doc, toc, err := renderPostContent(changes.Content)
+	if err != nil {
+		s.logger.Errorf("Error renderizando contenido del post: %v", err)
+		return nil, err
+	}
+
 	query := `
 		UPDATE posts 
-		SET title = $1, content = $2, excerpt = $3, category_id = $4, status = $5, published_at = $6, updated_at = $7
-		WHERE id = $8
-		RETURNING id, title, slug, content, excerpt, author_id, category_id, status, published_at, created_at, updated_at
+		SET title = $1, content = $2, excerpt = $3, category_id = $4, status = $5, published_at = $6,
+		    scheduled_at = $7, updated_at = $8, search_language = $10::regconfig, slug = $11,
+		    content_html = $13, toc = $14, word_count = $15, reading_time = $16, render_version = $17,
+		    featured_image_id = $18
+		WHERE id = $9 AND ($12::timestamptz[] IS NULL OR updated_at = ANY($12::timestamptz[]))
+		RETURNING id, title, slug, content, excerpt, author_id, category_id, status, published_at, scheduled_at, created_at, updated_at,
+		          featured_image_id
 	`
 
 	var post models.Post
-	err = s.db.QueryRow(query, existingPost.Title, existingPost.Content, existingPost.Excerpt,
-		existingPost.CategoryID, existingPost.Status, existingPost.PublishedAt, time.Now(), id).Scan(
+	err = tx.QueryRow(query, changes.Title, changes.Content, changes.Excerpt,
+		changes.CategoryID, changes.Status, changes.PublishedAt, changes.ScheduledAt, time.Now(), changes.ID,
+		s.searchLanguage, changes.Slug, versionsParam(ifMatch),
+		doc.HTML, toc, doc.WordCount, doc.ReadingTime, markdown.Version, changes.FeaturedImageID).Scan(
 		&post.ID, &post.Title, &post.Slug, &post.Content, &post.Excerpt,
-		&post.AuthorID, &post.CategoryID, &post.Status, &post.PublishedAt,
-		&post.CreatedAt, &post.UpdatedAt,
+		&post.AuthorID, &post.CategoryID, &post.Status, &post.PublishedAt, &post.ScheduledAt,
+		&post.CreatedAt, &post.UpdatedAt, &post.FeaturedImageID,
 	)
 
 	if err != nil {
+		if err == sql.ErrNoRows {
+			// El post está bloqueado, así que solo puede faltar por la precondición de versión
+			return nil, fmt.Errorf("el recurso fue modificado por otra petición")
+		}
 		s.logger.Errorf("Error actualizando post: %v", err)
 		return nil, err
 	}
+	setPostRender(&post, doc)
 
-	// Actualizar tags si se proporcionan
-	if req.TagIDs != nil {
-		// Eliminar tags existentes
-		err = s.removeAllTags(id)
-		if err != nil {
-			s.logger.Errorf("Error eliminando tags del post: %v", err)
+	if post.Slug != previous.Slug {
+		if err := s.recordSlugRedirect(tx, previous.Slug, &post); err != nil {
+			s.logger.Errorf("Error guardando redirección del slug anterior: %v", err)
+			return nil, err
 		}
+	}
 
-		// Agregar nuevos tags
-		if len(req.TagIDs) > 0 {
-			err = s.associateTags(id, req.TagIDs)
-			if err != nil {
-				s.logger.Errorf("Error asociando tags al post: %v", err)
-			}
+	if post.Title != previous.Title || post.Content != previous.Content || post.Excerpt != previous.Excerpt {
+		if err := s.revisions.record(tx, &previous, &post, editorID, note); err != nil {
+			s.logger.Errorf("Error registrando revisión del post: %v", err)
+			return nil, err
 		}
 	}
 
+	if tagIDs != nil {
+		if err := s.replaceTags(tx, post.ID, tagIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.Errorf("Error confirmando actualización del post: %v", err)
+		return nil, err
+	}
+
 	return &post, nil
 }
 
-// DeletePost elimina un post
-func (s *PostService) DeletePost(id uuid.UUID) error {
-	query := "DELETE FROM posts WHERE id = $1"
+// DeletePost mueve un post a la papelera. Los autores solo pueden eliminar sus propios posts.
+func (s *PostService) DeletePost(id uuid.UUID, actorID uuid.UUID, actorRole string) error {
+	// Verificar propiedad del post
+	if !rbac.IsModerator(actorRole) {
+		var authorID uuid.NullUUID
+		err := s.db.QueryRow("SELECT author_id FROM posts WHERE id = $1 AND deleted_at IS NULL", id).Scan(&authorID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("post no encontrado")
+			}
+			s.logger.Errorf("Error verificando autor del post: %v", err)
+			return err
+		}
+		if !authorID.Valid || authorID.UUID != actorID {
+			return fmt.Errorf("no tienes permiso para eliminar este post")
+		}
+	}
+
+	query := "UPDATE posts SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL"
 
 	result, err := s.db.Exec(query, id)
 	if err != nil {
@@ -461,13 +1065,354 @@ func (s *PostService) DeletePost(id uuid.UUID) error {
 	return nil
 }
 
-// associateTags asocia tags a un post
-func (s *PostService) associateTags(postID uuid.UUID, tagIDs []uuid.UUID) error {
-	query := "INSERT INTO post_tags (post_id, tag_id) VALUES ($1, $2)"
+// GetDeletedPosts obtiene los posts de la papelera, del eliminado más recientemente al más
+// antiguo. Si authorID no es uuid.Nil solo incluye los posts de ese autor.
+func (s *PostService) GetDeletedPosts(page, perPage int, authorID uuid.UUID) (*models.PostListResponse, error) {
+	offset := (page - 1) * perPage
+
+	var author interface{}
+	if authorID != uuid.Nil {
+		author = authorID
+	}
+
+	var total int
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM posts WHERE deleted_at IS NOT NULL AND ($1::uuid IS NULL OR author_id = $1)
+	`, author).Scan(&total)
+	if err != nil {
+		s.logger.Errorf("Error contando posts de la papelera: %v", err)
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, title, slug, content, excerpt, author_id, category_id, status, published_at, scheduled_at,
+		       created_at, updated_at, deleted_at, featured_image_id, content_html, toc, word_count, reading_time, render_version
+		FROM posts
+		WHERE deleted_at IS NOT NULL AND ($1::uuid IS NULL OR author_id = $1)
+		ORDER BY deleted_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`, author, perPage, offset)
+	if err != nil {
+		s.logger.Errorf("Error obteniendo posts de la papelera: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := []models.Post{}
+	for rows.Next() {
+		var post models.Post
+		var render postRender
+		err := rows.Scan(
+			&post.ID, &post.Title, &post.Slug, &post.Content, &post.Excerpt,
+			&post.AuthorID, &post.CategoryID, &post.Status, &post.PublishedAt, &post.ScheduledAt,
+			&post.CreatedAt, &post.UpdatedAt, &post.DeletedAt,
+			&post.FeaturedImageID, &render.html, &render.toc, &render.words, &render.minutes, &render.version,
+		)
+		if err != nil {
+			s.logger.Errorf("Error escaneando post de la papelera: %v", err)
+			continue
+		}
+		if err := render.apply(&post); err != nil {
+			s.logger.Errorf("Error renderizando post de la papelera: %v", err)
+			continue
+		}
+		posts = append(posts, post)
+	}
+
+	return &models.PostListResponse{
+		Posts:      posts,
+		Total:      total,
+		Page:       page,
+		PerPage:    perPage,
+		TotalPages: (total + perPage - 1) / perPage,
+	}, nil
+}
+
+// RestorePost saca un post de la papelera. Los autores solo pueden restaurar sus propios posts.
+func (s *PostService) RestorePost(id uuid.UUID, actorID uuid.UUID, actorRole string) (*models.Post, error) {
+	if !rbac.IsModerator(actorRole) {
+		var authorID uuid.NullUUID
+		err := s.db.QueryRow("SELECT author_id FROM posts WHERE id = $1 AND deleted_at IS NOT NULL", id).Scan(&authorID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, fmt.Errorf("post no encontrado en la papelera")
+			}
+			s.logger.Errorf("Error verificando autor del post: %v", err)
+			return nil, err
+		}
+		if !authorID.Valid || authorID.UUID != actorID {
+			return nil, fmt.Errorf("no tienes permiso para restaurar este post")
+		}
+	}
+
+	query := `
+		UPDATE posts SET deleted_at = NULL
+		WHERE id = $1 AND deleted_at IS NOT NULL
+		RETURNING id, title, slug, content, excerpt, author_id, category_id, status, published_at, scheduled_at, created_at, updated_at,
+		          featured_image_id, content_html, toc, word_count, reading_time, render_version
+	`
+
+	post, err := s.scanPost(s.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("post no encontrado en la papelera")
+		}
+		s.logger.Errorf("Error restaurando post: %v", err)
+		return nil, err
+	}
+
+	return post, nil
+}
+
+// PurgePost elimina definitivamente un post de la papelera junto con sus comentarios,
+// revisiones y visitas
+func (s *PostService) PurgePost(id uuid.UUID) error {
+	result, err := s.db.Exec("DELETE FROM posts WHERE id = $1 AND deleted_at IS NOT NULL", id)
+	if err != nil {
+		s.logger.Errorf("Error purgando post: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("post no encontrado en la papelera")
+	}
+
+	return nil
+}
+
+// uniqueSlug retorna el primer slug libre a partir de base, sin contar el del post excludeID.
+// Un slug está ocupado si lo usa otro post o si redirige a otro post.
+func (s *PostService) uniqueSlug(base string, excludeID uuid.UUID) (string, error) {
+	return slug.Unique(base, maxPostSlugLength, func(candidate string) (bool, error) {
+		var taken bool
+		err := s.db.QueryRow(`
+			SELECT EXISTS (SELECT 1 FROM posts WHERE slug = $1 AND id <> $2)
+			    OR EXISTS (SELECT 1 FROM post_slug_redirects WHERE slug = $1 AND post_id <> $2)
+		`, candidate, excludeID).Scan(&taken)
+		if err != nil {
+			s.logger.Errorf("Error verificando slug de post: %v", err)
+		}
+		return taken, err
+	})
+}
+
+// withUniqueSlug llama a write con el primer slug libre a partir de base, sin contar el del
+// post excludeID. Si otra petición ocupa ese slug entre la comprobación y la escritura
+// (violación de unicidad), calcula de nuevo el slug libre y reintenta hasta maxSlugAttempts
+// veces.
+func (s *PostService) withUniqueSlug(base string, excludeID uuid.UUID, write func(postSlug string) (*models.Post, error)) (*models.Post, error) {
+	var post *models.Post
+	err := retrySlugConflict(s.logger, "posts_slug_key", func() error {
+		postSlug, err := s.uniqueSlug(base, excludeID)
+		if err != nil {
+			return err
+		}
+
+		post, err = write(postSlug)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return post, nil
+}
+
+// retrySlugConflict llama a write, que debe calcular el slug libre en cada intento, y lo
+// repite hasta maxSlugAttempts veces mientras falle por una violación de la restricción de
+// unicidad constraint, es decir, mientras otra petición ocupe el slug entre la comprobación y
+// la escritura
+func retrySlugConflict(logger *logrus.Logger, constraint string, write func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := write()
+		if err == nil || !isConstraintViolation(err, constraint) || attempt == maxSlugAttempts {
+			return err
+		}
+		logger.Warnf("Slug ocupado por otra petición (%s); reintentando", constraint)
+	}
+}
+
+// recordSlugRedirect conserva el slug anterior de un post como redirección al actual y
+// elimina la redirección que pudiera existir con el slug nuevo
+func (s *PostService) recordSlugRedirect(tx *sql.Tx, oldSlug string, post *models.Post) error {
+	_, err := tx.Exec(`
+		INSERT INTO post_slug_redirects (slug, post_id) VALUES ($1, $2)
+		ON CONFLICT (slug) DO UPDATE SET post_id = EXCLUDED.post_id, created_at = CURRENT_TIMESTAMP
+	`, oldSlug, post.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM post_slug_redirects WHERE slug = $1", post.Slug)
+	return err
+}
+
+// ResolveSlugRedirect retorna el slug actual del post al que redirige un slug antiguo
+func (s *PostService) ResolveSlugRedirect(oldSlug string) (string, error) {
+	var current string
+	err := s.db.QueryRow(`
+		SELECT p.slug
+		FROM post_slug_redirects r
+		JOIN posts p ON p.id = r.post_id
+		WHERE r.slug = $1 AND p.deleted_at IS NULL
+	`, oldSlug).Scan(&current)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("post no encontrado")
+		}
+		s.logger.Errorf("Error resolviendo redirección de slug: %v", err)
+		return "", err
+	}
+
+	return current, nil
+}
+
+// checkOwnership verifica que el post exista y que el usuario pueda modificarlo
+func (s *PostService) checkOwnership(id uuid.UUID, actorID uuid.UUID, actorRole string) error {
+	var authorID uuid.NullUUID
+	err := s.db.QueryRow("SELECT author_id FROM posts WHERE id = $1 AND deleted_at IS NULL", id).Scan(&authorID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("post no encontrado")
+		}
+		s.logger.Errorf("Error verificando autor del post: %v", err)
+		return err
+	}
+
+	if !rbac.IsModerator(actorRole) && (!authorID.Valid || authorID.UUID != actorID) {
+		return fmt.Errorf("no tienes permiso para modificar este post")
+	}
+
+	return nil
+}
+
+// checkFeaturedImage verifica que un archivo de la biblioteca de medios exista, sea una imagen
+// y el actor pueda usarlo: los moderadores cualquiera, el resto solo los que subió. Un archivo
+// ajeno se trata como inexistente, igual que al consultarlo en la biblioteca.
+func (s *PostService) checkFeaturedImage(mediaID uuid.UUID, actorID uuid.UUID, actorRole string) error {
+	var contentType string
+	var uploaderID uuid.NullUUID
+	err := s.db.QueryRow("SELECT content_type, uploader_id FROM media WHERE id = $1", mediaID).Scan(&contentType, &uploaderID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("imagen destacada no encontrada")
+		}
+		s.logger.Errorf("Error verificando imagen destacada: %v", err)
+		return err
+	}
+
+	if !rbac.IsModerator(actorRole) && (!uploaderID.Valid || uploaderID.UUID != actorID) {
+		return fmt.Errorf("imagen destacada no encontrada")
+	}
+
+	if !strings.HasPrefix(contentType, "image/") {
+		return fmt.Errorf("la imagen destacada debe ser una imagen")
+	}
+
+	return nil
+}
+
+// scanPost escanea una fila de posts sin relaciones, seguida de las columnas del renderizado
+func (s *PostService) scanPost(row interface{ Scan(...interface{}) error }) (*models.Post, error) {
+	var post models.Post
+	var render postRender
+
+	err := row.Scan(
+		&post.ID, &post.Title, &post.Slug, &post.Content, &post.Excerpt,
+		&post.AuthorID, &post.CategoryID, &post.Status, &post.PublishedAt, &post.ScheduledAt,
+		&post.CreatedAt, &post.UpdatedAt,
+		&post.FeaturedImageID, &render.html, &render.toc, &render.words, &render.minutes, &render.version,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := render.apply(&post); err != nil {
+		return nil, err
+	}
+
+	return &post, nil
+}
+
+// validatePublishAt verifica la fecha de una publicación programada
+func validatePublishAt(publishAt *time.Time) error {
+	if publishAt == nil {
+		return fmt.Errorf("se requiere publish_at para programar un post")
+	}
+	if !publishAt.After(time.Now()) {
+		return fmt.Errorf("la fecha de publicación debe ser futura")
+	}
+	return nil
+}
+
+// resolveTags obtiene los IDs de los tags referenciados por ID o slug. complete indica
+// si todas las referencias corresponden a un tag existente.
+func (s *PostService) resolveTags(refs []string) ([]string, bool, error) {
+	var ids, slugs, keys []string
+	for _, ref := range refs {
+		if id, err := uuid.Parse(ref); err == nil {
+			ids = append(ids, id.String())
+			keys = append(keys, id.String())
+		} else {
+			slug := strings.ToLower(ref)
+			slugs = append(slugs, slug)
+			keys = append(keys, slug)
+		}
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, slug FROM tags WHERE (id = ANY($1::uuid[]) OR slug = ANY($2)) AND deleted_at IS NULL
+	`, pq.Array(ids), pq.Array(slugs))
+	if err != nil {
+		s.logger.Errorf("Error obteniendo tags del filtro: %v", err)
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	var tagIDs []string
+	found := make(map[string]bool)
+	for rows.Next() {
+		var id, slug string
+		if err := rows.Scan(&id, &slug); err != nil {
+			s.logger.Errorf("Error escaneando tag del filtro: %v", err)
+			return nil, false, err
+		}
+		tagIDs = append(tagIDs, id)
+		found[id] = true
+		found[slug] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	complete := true
+	for _, key := range keys {
+		if !found[key] {
+			complete = false
+			break
+		}
+	}
+
+	return tagIDs, complete, nil
+}
+
+// associateTags asocia tags a un post usando la conexión o transacción recibida. Los IDs
+// repetidos se ignoran y uno que no existe devuelve "tag no encontrado".
+func (s *PostService) associateTags(q execer, postID uuid.UUID, tagIDs []uuid.UUID) error {
+	query := "INSERT INTO post_tags (post_id, tag_id) VALUES ($1, $2) ON CONFLICT DO NOTHING"
 
 	for _, tagID := range tagIDs {
-		_, err := s.db.Exec(query, postID, tagID)
+		_, err := q.Exec(query, postID, tagID)
 		if err != nil {
+			if isForeignKeyViolation(err) {
+				return fmt.Errorf("tag no encontrado")
+			}
+			s.logger.Errorf("Error asociando tags al post: %v", err)
 			return err
 		}
 	}
@@ -475,9 +1420,12 @@ func (s *PostService) associateTags(postID uuid.UUID, tagIDs []uuid.UUID) error
 	return nil
 }
 
-// removeAllTags elimina todas las asociaciones de tags de un post
-func (s *PostService) removeAllTags(postID uuid.UUID) error {
-	query := "DELETE FROM post_tags WHERE post_id = $1"
-	_, err := s.db.Exec(query, postID)
-	return err
+// replaceTags reemplaza todos los tags de un post usando la conexión o transacción recibida
+func (s *PostService) replaceTags(q execer, postID uuid.UUID, tagIDs []uuid.UUID) error {
+	if _, err := q.Exec("DELETE FROM post_tags WHERE post_id = $1", postID); err != nil {
+		s.logger.Errorf("Error eliminando tags del post: %v", err)
+		return err
+	}
+
+	return s.associateTags(q, postID, tagIDs)
 }
@@ -0,0 +1,22 @@
+package services
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// versionsParam convierte las versiones aceptadas por If-Match en un parámetro timestamptz[]
+// para comparar con updated_at dentro del propio UPDATE. nil significa sin precondición y se
+// envía como NULL: las consultas usan ($n::timestamptz[] IS NULL OR updated_at = ANY($n)).
+func versionsParam(ifMatch []time.Time) interface{} {
+	if ifMatch == nil {
+		return nil
+	}
+
+	values := make([]string, len(ifMatch))
+	for i, version := range ifMatch {
+		values[i] = version.UTC().Format(time.RFC3339Nano)
+	}
+	return pq.Array(values)
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// PublisherService publica los posts programados cuya fecha de publicación ya llegó
+type PublisherService struct {
+	db           *sql.DB
+	statsService *StatsService
+	batchSize    int
+	logger       *logrus.Logger
+}
+
+// NewPublisherService crea una nueva instancia del publicador de posts programados
+func NewPublisherService(db *sql.DB, statsService *StatsService, batchSize int, logger *logrus.Logger) *PublisherService {
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+
+	return &PublisherService{
+		db:           db,
+		statsService: statsService,
+		batchSize:    batchSize,
+		logger:       logger,
+	}
+}
+
+// PublishDuePosts publica un lote de posts programados vencidos y registra la actividad
+// post_published de cada uno. El cambio de estado es atómico y las filas bloqueadas por
+// otra réplica se omiten, por lo que un post nunca se publica dos veces.
+func (s *PublisherService) PublishDuePosts() ([]models.Post, error) {
+	query := `
+		UPDATE posts
+		SET status = 'published', published_at = scheduled_at, scheduled_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE status = 'scheduled'
+		  AND id IN (
+			SELECT id FROM posts
+			WHERE status = 'scheduled' AND scheduled_at <= CURRENT_TIMESTAMP AND deleted_at IS NULL
+			ORDER BY scheduled_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		  )
+		RETURNING id, title, slug, author_id, published_at
+	`
+
+	rows, err := s.db.Query(query, s.batchSize)
+	if err != nil {
+		s.logger.Errorf("Error publicando posts programados: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []models.Post
+	var authors []uuid.NullUUID
+	for rows.Next() {
+		var post models.Post
+		var authorID uuid.NullUUID
+		if err := rows.Scan(&post.ID, &post.Title, &post.Slug, &authorID, &post.PublishedAt); err != nil {
+			s.logger.Errorf("Error escaneando post publicado: %v", err)
+			continue
+		}
+		if authorID.Valid {
+			post.AuthorID = authorID.UUID
+		}
+		post.Status = "published"
+		posts = append(posts, post)
+		authors = append(authors, authorID)
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.Errorf("Error publicando posts programados: %v", err)
+		return posts, err
+	}
+
+	for i, post := range posts {
+		var userID *uuid.UUID
+		if authors[i].Valid {
+			userID = &authors[i].UUID
+		}
+
+		s.logger.Infof("Post %s publicado automáticamente", post.ID)
+
+		// Crear log de actividad
+		postID := post.ID
+		s.statsService.CreateActivityLog(
+			userID,
+			"post_published",
+			"post",
+			&postID,
+			map[string]interface{}{
+				"title":        post.Title,
+				"slug":         post.Slug,
+				"published_at": post.PublishedAt.Format(time.RFC3339),
+				"scheduled":    true,
+			},
+			models.RequestInfo{},
+		)
+	}
+
+	return posts, nil
+}
+
+// Start publica periódicamente los posts programados hasta que el contexto se cancele
+func (s *PublisherService) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Publicar lotes hasta vaciar los posts vencidos
+			for {
+				posts, err := s.PublishDuePosts()
+				if err != nil || len(posts) < s.batchSize {
+					break
+				}
+			}
+		}
+	}
+}
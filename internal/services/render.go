@@ -0,0 +1,89 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/pkg/markdown"
+)
+
+// postRender agrupa las columnas con el renderizado cacheado de un post:
+// content_html, toc, word_count, reading_time y render_version
+type postRender struct {
+	html    sql.NullString
+	toc     []byte
+	words   int
+	minutes int
+	version int
+}
+
+// apply copia el renderizado cacheado al post. Si se guardó con una versión anterior del
+// renderizador (o nunca se renderizó) se recalcula a partir del contenido sin guardarlo;
+// RenderService lo guarda en segundo plano al arrancar.
+func (r *postRender) apply(post *models.Post) error {
+	if r.version != markdown.Version || !r.html.Valid {
+		doc, err := markdown.Render(post.Content)
+		if err != nil {
+			return err
+		}
+		setPostRender(post, doc)
+		return nil
+	}
+
+	post.ContentHTML = r.html.String
+	post.TOC = []markdown.Heading{}
+	if len(r.toc) > 0 {
+		if err := json.Unmarshal(r.toc, &post.TOC); err != nil {
+			return err
+		}
+	}
+	post.WordCount = r.words
+	post.ReadingTime = r.minutes
+	return nil
+}
+
+// renderPostContent renderiza el contenido de un post y retorna el documento junto con su
+// tabla de contenidos en JSON, listos para guardarse en las columnas del post
+func renderPostContent(content string) (*markdown.Document, []byte, error) {
+	doc, err := markdown.Render(content)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	toc, err := json.Marshal(doc.TOC)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return doc, toc, nil
+}
+
+// setPostRender copia un renderizado a los campos del post
+func setPostRender(post *models.Post, doc *markdown.Document) {
+	post.ContentHTML = doc.HTML
+	post.TOC = doc.TOC
+	post.WordCount = doc.WordCount
+	post.ReadingTime = doc.ReadingTime
+}
+
+// commentRender agrupa las columnas con el renderizado cacheado de un comentario
+type commentRender struct {
+	html    sql.NullString
+	version int
+}
+
+// apply copia el HTML cacheado al comentario o lo recalcula si es de una versión anterior
+func (r *commentRender) apply(comment *models.Comment) error {
+	if r.version == markdown.Version && r.html.Valid {
+		comment.ContentHTML = r.html.String
+		return nil
+	}
+
+	doc, err := markdown.Render(comment.Content)
+	if err != nil {
+		return err
+	}
+	comment.ContentHTML = doc.HTML
+	return nil
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/alan.bermudez/goasync/pkg/markdown"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// renderBackfillBatch es el número de filas que se renderizan por consulta durante el backfill
+const renderBackfillBatch = 100
+
+// RenderService vuelve a renderizar el contenido guardado con una versión anterior del
+// renderizador, para que las lecturas no tengan que hacerlo en cada petición
+type RenderService struct {
+	db     *sql.DB
+	logger *logrus.Logger
+}
+
+// NewRenderService crea una nueva instancia del servicio de renderizado
+func NewRenderService(db *sql.DB, logger *logrus.Logger) *RenderService {
+	return &RenderService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Backfill renderiza por lotes los posts y comentarios con render_version anterior a
+// markdown.Version (o sin renderizar) y guarda el resultado. Solo actualiza las filas que
+// siguen desactualizadas, así que una edición simultánea no se sobrescribe, y como solo cambia
+// columnas de renderizado el trigger update_updated_at_unless_render_only conserva updated_at,
+// que se usa para detectar ediciones concurrentes. Se detiene si el contexto se cancela.
+func (s *RenderService) Backfill(ctx context.Context) (posts, comments int64, err error) {
+	posts, err = s.backfillPosts(ctx)
+	if err != nil {
+		return posts, 0, err
+	}
+
+	comments, err = s.backfillComments(ctx)
+	return posts, comments, err
+}
+
+// Start ejecuta el backfill una vez en segundo plano y registra el resultado
+func (s *RenderService) Start(ctx context.Context) {
+	posts, comments, err := s.Backfill(ctx)
+	if err != nil && ctx.Err() == nil {
+		s.logger.Errorf("Error renderizando contenido desactualizado: %v", err)
+		return
+	}
+
+	if posts > 0 || comments > 0 {
+		s.logger.Infof("Renderizados de nuevo %d posts y %d comentarios", posts, comments)
+	}
+}
+
+// renderRow es una fila pendiente de renderizar
+type renderRow struct {
+	id      uuid.UUID
+	content string
+}
+
+// staleRows obtiene el siguiente lote de filas desactualizadas de una tabla, ordenadas por ID
+// a partir de after. Las filas cuyo contenido no puede renderizarse se saltan gracias al cursor.
+func (s *RenderService) staleRows(ctx context.Context, table string, after uuid.UUID) ([]renderRow, error) {
+	query := `
+		SELECT id, content FROM ` + table + `
+		WHERE (render_version < $1 OR content_html IS NULL) AND id > $2
+		ORDER BY id
+		LIMIT $3
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, markdown.Version, after, renderBackfillBatch)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batch []renderRow
+	for rows.Next() {
+		var row renderRow
+		if err := rows.Scan(&row.id, &row.content); err != nil {
+			return nil, err
+		}
+		batch = append(batch, row)
+	}
+
+	return batch, rows.Err()
+}
+
+// backfillPosts renderiza los posts desactualizados
+func (s *RenderService) backfillPosts(ctx context.Context) (int64, error) {
+	var total int64
+	after := uuid.Nil
+	for {
+		batch, err := s.staleRows(ctx, "posts", after)
+		if err != nil {
+			return total, err
+		}
+
+		for _, row := range batch {
+			doc, toc, err := renderPostContent(row.content)
+			if err != nil {
+				s.logger.Errorf("Error renderizando post %s: %v", row.id, err)
+				continue
+			}
+
+			result, err := s.db.ExecContext(ctx, `
+				UPDATE posts
+				SET content_html = $1, toc = $2, word_count = $3, reading_time = $4, render_version = $5
+				WHERE id = $6 AND content = $7 AND (render_version < $5 OR content_html IS NULL)
+			`, doc.HTML, toc, doc.WordCount, doc.ReadingTime, markdown.Version, row.id, row.content)
+			if err != nil {
+				return total, err
+			}
+			affected, _ := result.RowsAffected()
+			total += affected
+		}
+
+		if len(batch) < renderBackfillBatch {
+			return total, nil
+		}
+		after = batch[len(batch)-1].id
+	}
+}
+
+// backfillComments renderiza los comentarios desactualizados
+func (s *RenderService) backfillComments(ctx context.Context) (int64, error) {
+	var total int64
+	after := uuid.Nil
+	for {
+		batch, err := s.staleRows(ctx, "comments", after)
+		if err != nil {
+			return total, err
+		}
+
+		for _, row := range batch {
+			doc, err := markdown.Render(row.content)
+			if err != nil {
+				s.logger.Errorf("Error renderizando comentario %s: %v", row.id, err)
+				continue
+			}
+
+			result, err := s.db.ExecContext(ctx, `
+				UPDATE comments
+				SET content_html = $1, render_version = $2
+				WHERE id = $3 AND content = $4 AND (render_version < $2 OR content_html IS NULL)
+			`, doc.HTML, markdown.Version, row.id, row.content)
+			if err != nil {
+				return total, err
+			}
+			affected, _ := result.RowsAffected()
+			total += affected
+		}
+
+		if len(batch) < renderBackfillBatch {
+			return total, nil
+		}
+		after = batch[len(batch)-1].id
+	}
+}
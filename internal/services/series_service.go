@@ -0,0 +1,533 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/pkg/rbac"
+	"github.com/alan.bermudez/goasync/pkg/slug"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// maxSeriesSlugLength es la longitud de la columna series.slug
+const maxSeriesSlugLength = 255
+
+// SeriesService maneja la lógica de negocio de las series de posts y el orden de sus partes
+type SeriesService struct {
+	db          *sql.DB
+	postService *PostService
+	logger      *logrus.Logger
+}
+
+// NewSeriesService crea una nueva instancia del servicio de series
+func NewSeriesService(db *sql.DB, postService *PostService, logger *logrus.Logger) *SeriesService {
+	return &SeriesService{
+		db:          db,
+		postService: postService,
+		logger:      logger,
+	}
+}
+
+// seriesColumns son las columnas de una serie incluyendo el número de partes publicadas
+const seriesColumns = `
+	s.id, s.title, s.slug, COALESCE(s.description, ''), s.created_by,
+	(
+		SELECT COUNT(*) FROM series_posts sp
+		JOIN posts p ON p.id = sp.post_id
+		WHERE sp.series_id = s.id AND p.deleted_at IS NULL AND p.status = 'published'
+	) AS part_count,
+	s.created_at, s.updated_at
+`
+
+// GetAllSeries obtiene todas las series
+func (s *SeriesService) GetAllSeries() ([]models.Series, error) {
+	query := fmt.Sprintf(`SELECT %s FROM series s ORDER BY s.title`, seriesColumns)
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		s.logger.Errorf("Error obteniendo series: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	series := []models.Series{}
+	for rows.Next() {
+		item, err := scanSeries(rows)
+		if err != nil {
+			s.logger.Errorf("Error escaneando serie: %v", err)
+			continue
+		}
+		series = append(series, *item)
+	}
+
+	return series, nil
+}
+
+// GetSeriesByID obtiene una serie por su ID con sus partes ordenadas. viewerID es el usuario
+// que la consulta (uuid.Nil si es anónimo); solo su creador o un moderador ve las partes no
+// publicadas.
+func (s *SeriesService) GetSeriesByID(id, viewerID uuid.UUID) (*models.Series, error) {
+	return s.getSeries("s.id = $1", id, viewerID, "")
+}
+
+// GetSeriesBySlug obtiene una serie por su slug con sus partes ordenadas, con la misma
+// visibilidad que GetSeriesByID
+func (s *SeriesService) GetSeriesBySlug(slug string, viewerID uuid.UUID) (*models.Series, error) {
+	return s.getSeries("s.slug = $1", slug, viewerID, "")
+}
+
+// CreateSeries crea una nueva serie
+func (s *SeriesService) CreateSeries(req models.SeriesCreateRequest, createdBy uuid.UUID) (*models.Series, error) {
+	// Un slug explícito que ya existe es un conflicto; uno generado desde el título recibe
+	// un sufijo numérico
+	seriesSlug := slug.Truncate(slug.Make(req.Slug), maxSeriesSlugLength)
+	if req.Slug == "" {
+		var err error
+		seriesSlug, err = slug.Unique(slug.Make(req.Title), maxSeriesSlugLength, func(candidate string) (bool, error) {
+			var taken bool
+			err := s.db.QueryRow("SELECT EXISTS (SELECT 1 FROM series WHERE slug = $1)", candidate).Scan(&taken)
+			return taken, err
+		})
+		if err != nil {
+			s.logger.Errorf("Error generando slug de serie: %v", err)
+			return nil, err
+		}
+	}
+
+	query := fmt.Sprintf(`
+		WITH s AS (
+			INSERT INTO series (title, slug, description, created_by)
+			VALUES ($1, $2, $3, $4)
+			RETURNING *
+		)
+		SELECT %s FROM s
+	`, seriesColumns)
+
+	series, err := scanSeries(s.db.QueryRow(query, req.Title, seriesSlug, req.Description, createdBy))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("el slug de la serie ya existe")
+		}
+		s.logger.Errorf("Error creando serie: %v", err)
+		return nil, err
+	}
+
+	return series, nil
+}
+
+// UpdateSeries actualiza el título, slug o descripción de una serie. Solo su creador o un
+// moderador puede modificarla.
+func (s *SeriesService) UpdateSeries(id uuid.UUID, req models.SeriesUpdateRequest, actorID uuid.UUID, actorRole string) (*models.Series, error) {
+	existing, err := s.getSeries("s.id = $1", id, actorID, actorRole)
+	if err != nil {
+		return nil, err
+	}
+	if !canManageSeries(existing.CreatedBy, actorID, actorRole) {
+		return nil, fmt.Errorf("no tienes permiso para modificar esta serie")
+	}
+
+	if req.Title != "" {
+		existing.Title = req.Title
+	}
+	if req.Slug != "" {
+		existing.Slug = slug.Truncate(slug.Make(req.Slug), maxSeriesSlugLength)
+	}
+	if req.Description != nil {
+		existing.Description = *req.Description
+	}
+
+	query := fmt.Sprintf(`
+		WITH s AS (
+			UPDATE series SET title = $1, slug = $2, description = $3
+			WHERE id = $4
+			RETURNING *
+		)
+		SELECT %s FROM s
+	`, seriesColumns)
+
+	series, err := scanSeries(s.db.QueryRow(query, existing.Title, existing.Slug, existing.Description, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("serie no encontrada")
+		}
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("el slug de la serie ya existe")
+		}
+		s.logger.Errorf("Error actualizando serie: %v", err)
+		return nil, err
+	}
+	series.Parts = existing.Parts
+
+	return series, nil
+}
+
+// DeleteSeries elimina una serie. Los posts se conservan, solo se quitan de la serie.
+func (s *SeriesService) DeleteSeries(id uuid.UUID) error {
+	result, err := s.db.Exec("DELETE FROM series WHERE id = $1", id)
+	if err != nil {
+		s.logger.Errorf("Error eliminando serie: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("serie no encontrada")
+	}
+
+	return nil
+}
+
+// AddPost añade un post a una serie en la posición indicada, desplazando las partes
+// siguientes, o al final si la posición es 0. Hay que poder modificar tanto el post como la serie.
+func (s *SeriesService) AddPost(seriesID uuid.UUID, req models.SeriesAddPostRequest, actorID uuid.UUID, actorRole string) (*models.Series, error) {
+	if err := s.postService.checkOwnership(req.PostID, actorID, actorRole); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	createdBy, count, err := s.lockSeries(tx, seriesID)
+	if err != nil {
+		return nil, err
+	}
+	if !canManageSeries(createdBy, actorID, actorRole) {
+		return nil, fmt.Errorf("no tienes permiso para modificar esta serie")
+	}
+
+	position := req.Position
+	if position == 0 {
+		position = count + 1
+	}
+	if position < 1 || position > count+1 {
+		return nil, fmt.Errorf("posición inválida")
+	}
+
+	// La unicidad de la posición se verifica al confirmar, así que se puede desplazar antes de insertar
+	_, err = tx.Exec(`
+		UPDATE series_posts SET position = position + 1 WHERE series_id = $1 AND position >= $2
+	`, seriesID, position)
+	if err != nil {
+		s.logger.Errorf("Error desplazando partes de la serie: %v", err)
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO series_posts (series_id, post_id, position) VALUES ($1, $2, $3)
+	`, seriesID, req.PostID, position)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, fmt.Errorf("el post ya pertenece a una serie")
+		}
+		s.logger.Errorf("Error añadiendo post a la serie: %v", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.getSeries("s.id = $1", seriesID, actorID, actorRole)
+}
+
+// RemovePost quita un post de una serie y compacta las posiciones de las partes siguientes
+func (s *SeriesService) RemovePost(seriesID, postID uuid.UUID, actorID uuid.UUID, actorRole string) (*models.Series, error) {
+	if err := s.postService.checkOwnership(postID, actorID, actorRole); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, _, err := s.lockSeries(tx, seriesID); err != nil {
+		return nil, err
+	}
+
+	var position int
+	err = tx.QueryRow(`
+		DELETE FROM series_posts WHERE series_id = $1 AND post_id = $2 RETURNING position
+	`, seriesID, postID).Scan(&position)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("el post no pertenece a la serie")
+		}
+		s.logger.Errorf("Error quitando post de la serie: %v", err)
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE series_posts SET position = position - 1 WHERE series_id = $1 AND position > $2
+	`, seriesID, position)
+	if err != nil {
+		s.logger.Errorf("Error compactando partes de la serie: %v", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.getSeries("s.id = $1", seriesID, actorID, actorRole)
+}
+
+// Reorder reemplaza el orden de las partes de una serie en una sola transacción. postIDs
+// debe contener exactamente las partes actuales, en el nuevo orden.
+func (s *SeriesService) Reorder(seriesID uuid.UUID, postIDs []uuid.UUID, actorID uuid.UUID, actorRole string) (*models.Series, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	createdBy, count, err := s.lockSeries(tx, seriesID)
+	if err != nil {
+		return nil, err
+	}
+	if !canManageSeries(createdBy, actorID, actorRole) {
+		return nil, fmt.Errorf("no tienes permiso para modificar esta serie")
+	}
+
+	ids := make([]string, 0, len(postIDs))
+	unique := make(map[uuid.UUID]bool, len(postIDs))
+	for _, postID := range postIDs {
+		unique[postID] = true
+		ids = append(ids, postID.String())
+	}
+	if len(unique) != len(postIDs) || len(postIDs) != count {
+		return nil, fmt.Errorf("el nuevo orden debe incluir exactamente las partes actuales de la serie")
+	}
+
+	result, err := tx.Exec(`
+		UPDATE series_posts sp SET position = v.position
+		FROM unnest($2::uuid[]) WITH ORDINALITY AS v(post_id, position)
+		WHERE sp.series_id = $1 AND sp.post_id = v.post_id
+	`, seriesID, pq.Array(ids))
+	if err != nil {
+		s.logger.Errorf("Error reordenando serie: %v", err)
+		return nil, err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if int(updated) != count {
+		return nil, fmt.Errorf("el nuevo orden debe incluir exactamente las partes actuales de la serie")
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.Errorf("Error confirmando el orden de la serie: %v", err)
+		return nil, err
+	}
+
+	return s.getSeries("s.id = $1", seriesID, actorID, actorRole)
+}
+
+// GetNavigation obtiene la navegación de un post dentro de su serie. Retorna nil si el post no
+// pertenece a ninguna serie. Solo se cuentan las partes publicadas y el propio post.
+func (s *SeriesService) GetNavigation(postID uuid.UUID) (*models.SeriesNavigation, error) {
+	var nav models.SeriesNavigation
+	err := s.db.QueryRow(`
+		SELECT s.id, s.title, s.slug
+		FROM series s
+		JOIN series_posts sp ON sp.series_id = s.id
+		WHERE sp.post_id = $1
+	`, postID).Scan(&nav.ID, &nav.Title, &nav.Slug)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		s.logger.Errorf("Error obteniendo serie del post: %v", err)
+		return nil, err
+	}
+
+	parts, err := s.getParts(nav.ID, &postID)
+	if err != nil {
+		return nil, err
+	}
+
+	nav.Total = len(parts)
+	for i := range parts {
+		if parts[i].PostID != postID {
+			continue
+		}
+		nav.Part = i + 1
+		if i > 0 {
+			nav.Previous = &parts[i-1]
+		}
+		if i < len(parts)-1 {
+			nav.Next = &parts[i+1]
+		}
+		break
+	}
+
+	return &nav, nil
+}
+
+// getSeries obtiene una serie con sus partes según una condición sobre la tabla series. Para su
+// creador o un moderador incluye todas las partes (borradores, programadas y en la papelera);
+// para el resto solo las publicadas. Si viewerRole está vacío el rol se consulta cuando hace falta.
+func (s *SeriesService) getSeries(condition string, arg interface{}, viewerID uuid.UUID, viewerRole string) (*models.Series, error) {
+	query := fmt.Sprintf(`SELECT %s FROM series s WHERE %s`, seriesColumns, condition)
+
+	series, err := scanSeries(s.db.QueryRow(query, arg))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("serie no encontrada")
+		}
+		s.logger.Errorf("Error obteniendo serie: %v", err)
+		return nil, err
+	}
+
+	if s.canViewHiddenParts(series.CreatedBy, viewerID, viewerRole) {
+		series.Parts, err = s.getAllParts(series.ID)
+	} else {
+		series.Parts, err = s.getParts(series.ID, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return series, nil
+}
+
+// getParts obtiene las partes publicadas y no eliminadas de una serie ordenadas por posición,
+// renumeradas desde 1. Si visibleFor no es nil incluye también ese post.
+func (s *SeriesService) getParts(seriesID uuid.UUID, visibleFor *uuid.UUID) ([]models.SeriesPart, error) {
+	return s.queryParts(`
+		SELECT ROW_NUMBER() OVER (ORDER BY sp.position), p.id, p.title, p.slug, p.status
+		FROM series_posts sp
+		JOIN posts p ON p.id = sp.post_id
+		WHERE sp.series_id = $1 AND p.deleted_at IS NULL AND (p.status = 'published' OR p.id = $2)
+		ORDER BY sp.position
+	`, seriesID, visibleFor)
+}
+
+// getAllParts obtiene todas las partes de una serie, sea cual sea el estado de sus posts,
+// con sus posiciones reales
+func (s *SeriesService) getAllParts(seriesID uuid.UUID) ([]models.SeriesPart, error) {
+	return s.queryParts(`
+		SELECT sp.position, p.id, p.title, p.slug, p.status
+		FROM series_posts sp
+		JOIN posts p ON p.id = sp.post_id
+		WHERE sp.series_id = $1
+		ORDER BY sp.position
+	`, seriesID)
+}
+
+// queryParts ejecuta una consulta de partes de una serie y escanea el resultado
+func (s *SeriesService) queryParts(query string, args ...interface{}) ([]models.SeriesPart, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		s.logger.Errorf("Error obteniendo partes de la serie: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	parts := []models.SeriesPart{}
+	for rows.Next() {
+		var part models.SeriesPart
+		if err := rows.Scan(&part.Position, &part.PostID, &part.Title, &part.Slug, &part.Status); err != nil {
+			s.logger.Errorf("Error escaneando parte de la serie: %v", err)
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+
+	return parts, rows.Err()
+}
+
+// lockSeries bloquea la serie durante la transacción y retorna su creador y su número de partes
+func (s *SeriesService) lockSeries(tx *sql.Tx, seriesID uuid.UUID) (*uuid.UUID, int, error) {
+	var createdBy *uuid.UUID
+	err := tx.QueryRow("SELECT created_by FROM series WHERE id = $1 FOR UPDATE", seriesID).Scan(&createdBy)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, 0, fmt.Errorf("serie no encontrada")
+		}
+		s.logger.Errorf("Error bloqueando serie: %v", err)
+		return nil, 0, err
+	}
+
+	var count int
+	err = tx.QueryRow("SELECT COUNT(*) FROM series_posts WHERE series_id = $1", seriesID).Scan(&count)
+	if err != nil {
+		s.logger.Errorf("Error contando partes de la serie: %v", err)
+		return nil, 0, err
+	}
+
+	return createdBy, count, nil
+}
+
+// canViewHiddenParts indica si el usuario puede ver las partes no publicadas de una serie
+func (s *SeriesService) canViewHiddenParts(createdBy *uuid.UUID, viewerID uuid.UUID, viewerRole string) bool {
+	if viewerID == uuid.Nil {
+		return false
+	}
+	if createdBy != nil && *createdBy == viewerID {
+		return true
+	}
+
+	if viewerRole == "" {
+		err := s.db.QueryRow("SELECT role FROM users WHERE id = $1 AND is_active = true", viewerID).Scan(&viewerRole)
+		if err != nil {
+			if err != sql.ErrNoRows {
+				s.logger.Errorf("Error obteniendo rol del usuario: %v", err)
+			}
+			return false
+		}
+	}
+
+	return rbac.IsModerator(viewerRole)
+}
+
+// canManageSeries indica si el actor puede modificar una serie: su creador o un moderador
+func canManageSeries(createdBy *uuid.UUID, actorID uuid.UUID, actorRole string) bool {
+	return rbac.IsModerator(actorRole) || (createdBy != nil && *createdBy == actorID)
+}
+
+// scanSeries escanea una fila con las columnas de seriesColumns
+func scanSeries(row interface{ Scan(...interface{}) error }) (*models.Series, error) {
+	var series models.Series
+	err := row.Scan(
+		&series.ID, &series.Title, &series.Slug, &series.Description, &series.CreatedBy,
+		&series.PartCount, &series.CreatedAt, &series.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &series, nil
+}
+
+// isUniqueViolation indica si un error de Postgres es una violación de unicidad
+func isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505"
+}
+
+// isConstraintViolation indica si un error de Postgres es una violación de unicidad de la
+// restricción indicada
+func isConstraintViolation(err error, constraint string) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505" && pqErr.Constraint == constraint
+}
+
+// isForeignKeyViolation indica si un error de Postgres es una violación de clave foránea
+func isForeignKeyViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23503"
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/pkg/auth"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// SessionService maneja la lógica de negocio para sesiones de usuario
+type SessionService struct {
+	db     *sql.DB
+	ttl    time.Duration
+	logger *logrus.Logger
+}
+
+// NewSessionService crea una nueva instancia del servicio de sesiones
+func NewSessionService(db *sql.DB, ttl time.Duration, logger *logrus.Logger) *SessionService {
+	return &SessionService{
+		db:     db,
+		ttl:    ttl,
+		logger: logger,
+	}
+}
+
+// CreateSession crea una nueva sesión y retorna el token opaco en claro.
+// Solo el hash del token se guarda en la base de datos.
+func (s *SessionService) CreateSession(userID uuid.UUID, ipAddress, userAgent string) (string, *models.UserSession, error) {
+	token, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		s.logger.Errorf("Error generando token de sesión: %v", err)
+		return "", nil, err
+	}
+
+	query := `
+		INSERT INTO user_sessions (user_id, token_hash, expires_at, ip_address, user_agent)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, user_id, token_hash, expires_at, last_used_at, ip_address, user_agent, created_at
+	`
+
+	session, err := s.scanSession(s.db.QueryRow(query, userID, auth.HashToken(token),
+		time.Now().Add(s.ttl), ipAddress, userAgent))
+	if err != nil {
+		s.logger.Errorf("Error creando sesión: %v", err)
+		return "", nil, err
+	}
+
+	return token, session, nil
+}
+
+// ValidateSession valida un token de sesión y extiende su expiración (expiración deslizante)
+func (s *SessionService) ValidateSession(token string) (uuid.UUID, uuid.UUID, error) {
+	query := `
+		UPDATE user_sessions
+		SET expires_at = $1, last_used_at = CURRENT_TIMESTAMP
+		WHERE token_hash = $2 AND expires_at > CURRENT_TIMESTAMP
+		RETURNING id, user_id
+	`
+
+	var sessionID, userID uuid.UUID
+	err := s.db.QueryRow(query, time.Now().Add(s.ttl), auth.HashToken(token)).Scan(&sessionID, &userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, uuid.Nil, fmt.Errorf("sesión inválida o expirada")
+		}
+		s.logger.Errorf("Error validando sesión: %v", err)
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	return userID, sessionID, nil
+}
+
+// GetUserSessions obtiene las sesiones activas de un usuario
+func (s *SessionService) GetUserSessions(userID uuid.UUID) ([]models.UserSession, error) {
+	query := `
+		SELECT id, user_id, token_hash, expires_at, last_used_at, ip_address, user_agent, created_at
+		FROM user_sessions
+		WHERE user_id = $1 AND expires_at > CURRENT_TIMESTAMP
+		ORDER BY COALESCE(last_used_at, created_at) DESC
+	`
+
+	rows, err := s.db.Query(query, userID)
+	if err != nil {
+		s.logger.Errorf("Error obteniendo sesiones: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []models.UserSession
+	for rows.Next() {
+		session, err := s.scanSession(rows)
+		if err != nil {
+			s.logger.Errorf("Error escaneando sesión: %v", err)
+			continue
+		}
+		sessions = append(sessions, *session)
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession revoca una sesión específica de un usuario
+func (s *SessionService) RevokeSession(userID, sessionID uuid.UUID) error {
+	query := "DELETE FROM user_sessions WHERE id = $1 AND user_id = $2"
+
+	result, err := s.db.Exec(query, sessionID, userID)
+	if err != nil {
+		s.logger.Errorf("Error revocando sesión: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("sesión no encontrada")
+	}
+
+	return nil
+}
+
+// RevokeAllSessions revoca todas las sesiones de un usuario
+func (s *SessionService) RevokeAllSessions(userID uuid.UUID) (int64, error) {
+	return s.revokeSessions(s.db, userID, uuid.Nil)
+}
+
+// revokeSessions revoca las sesiones de un usuario salvo exceptID (uuid.Nil para revocarlas
+// todas), usando la conexión o transacción recibida
+func (s *SessionService) revokeSessions(q execer, userID, exceptID uuid.UUID) (int64, error) {
+	result, err := q.Exec("DELETE FROM user_sessions WHERE user_id = $1 AND id <> $2", userID, exceptID)
+	if err != nil {
+		s.logger.Errorf("Error revocando sesiones: %v", err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// PurgeExpired elimina las sesiones expiradas
+func (s *SessionService) PurgeExpired() (int64, error) {
+	result, err := s.db.Exec("DELETE FROM user_sessions WHERE expires_at <= CURRENT_TIMESTAMP")
+	if err != nil {
+		s.logger.Errorf("Error eliminando sesiones expiradas: %v", err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// StartSweeper elimina periódicamente las sesiones expiradas hasta que el contexto se cancele
+func (s *SessionService) StartSweeper(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.PurgeExpired()
+			if err != nil {
+				continue
+			}
+			if purged > 0 {
+				s.logger.Infof("Sesiones expiradas eliminadas: %d", purged)
+			}
+		}
+	}
+}
+
+// scanSession escanea una fila de user_sessions
+func (s *SessionService) scanSession(row interface{ Scan(...interface{}) error }) (*models.UserSession, error) {
+	var session models.UserSession
+	var ipAddress, userAgent sql.NullString
+
+	err := row.Scan(
+		&session.ID, &session.UserID, &session.TokenHash, &session.ExpiresAt,
+		&session.LastUsedAt, &ipAddress, &userAgent, &session.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	session.IPAddress = ipAddress.String
+	session.UserAgent = userAgent.String
+
+	return &session, nil
+}
@@ -2,10 +2,12 @@ package services
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/pkg/cursor"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
@@ -43,10 +45,10 @@ func (s *StatsService) GetDatabaseStats() (*models.DatabaseStats, error) {
 }
 
 // GetActivityLogs obtiene logs de actividad con filtros
-func (s *StatsService) GetActivityLogs(filter models.ActivityLogFilter) ([]models.ActivityLog, error) {
+func (s *StatsService) GetActivityLogs(filter models.ActivityLogFilter) (*models.ActivityLogListResponse, error) {
 	// Construir query base
 	baseQuery := `
-		SELECT al.id, al.user_id, al.action, al.resource_type, al.resource_id, 
+		SELECT al.id, al.user_id, al.impersonator_id, al.action, al.resource_type, al.resource_id, 
 		       al.details, al.ip_address, al.user_agent, al.created_at,
 		       u.username as user_username, u.first_name as user_first_name, u.last_name as user_last_name
 		FROM activity_logs al
@@ -88,6 +90,18 @@ func (s *StatsService) GetActivityLogs(filter models.ActivityLogFilter) ([]model
 		args = append(args, filter.EndDate)
 	}
 
+	// En modo cursor se continúa desde la clave (created_at, id) del último log visto
+	if filter.Cursor != nil {
+		arg := func(v interface{}) string {
+			argCount++
+			args = append(args, v)
+			return fmt.Sprintf("$%d", argCount)
+		}
+		if condition := filter.Cursor.Where("al.created_at", "al.id", arg); condition != "" {
+			whereConditions = append(whereConditions, condition)
+		}
+	}
+
 	// Construir WHERE clause
 	whereClause := ""
 	if len(whereConditions) > 0 {
@@ -98,18 +112,31 @@ func (s *StatsService) GetActivityLogs(filter models.ActivityLogFilter) ([]model
 	}
 
 	// Agregar paginación
-	argCount++
-	limitArg := fmt.Sprintf("$%d", argCount)
-	argCount++
-	offsetArg := fmt.Sprintf("$%d", argCount)
-	args = append(args, filter.PerPage, (filter.Page-1)*filter.PerPage)
-
-	query := fmt.Sprintf(`
-		%s
-		%s
-		ORDER BY al.created_at DESC
-		LIMIT %s OFFSET %s
-	`, baseQuery, whereClause, limitArg, offsetArg)
+	var query string
+	if filter.Cursor != nil {
+		argCount++
+		args = append(args, filter.Cursor.Fetch())
+
+		query = fmt.Sprintf(`
+			%s
+			%s
+			ORDER BY %s
+			LIMIT $%d
+		`, baseQuery, whereClause, filter.Cursor.OrderBy("al.created_at", "al.id"), argCount)
+	} else {
+		argCount++
+		limitArg := fmt.Sprintf("$%d", argCount)
+		argCount++
+		offsetArg := fmt.Sprintf("$%d", argCount)
+		args = append(args, filter.PerPage, (filter.Page-1)*filter.PerPage)
+
+		query = fmt.Sprintf(`
+			%s
+			%s
+			ORDER BY al.created_at DESC
+			LIMIT %s OFFSET %s
+		`, baseQuery, whereClause, limitArg, offsetArg)
+	}
 
 	rows, err := s.db.Query(query, args...)
 	if err != nil {
@@ -121,17 +148,19 @@ func (s *StatsService) GetActivityLogs(filter models.ActivityLogFilter) ([]model
 	var logs []models.ActivityLog
 	for rows.Next() {
 		var log models.ActivityLog
+		var details []byte
 		var userUsername, userFirstName, userLastName sql.NullString
 
 		err := rows.Scan(
-			&log.ID, &log.UserID, &log.Action, &log.ResourceType, &log.ResourceID,
-			&log.Details, &log.IPAddress, &log.UserAgent, &log.CreatedAt,
+			&log.ID, &log.UserID, &log.ImpersonatorID, &log.Action, &log.ResourceType, &log.ResourceID,
+			&details, &log.IPAddress, &log.UserAgent, &log.CreatedAt,
 			&userUsername, &userFirstName, &userLastName,
 		)
 		if err != nil {
 			s.logger.Errorf("Error escaneando log de actividad: %v", err)
 			continue
 		}
+		log.Details = decodeDetails(details)
 
 		// Construir usuario si existe
 		if userUsername.Valid {
@@ -146,17 +175,40 @@ func (s *StatsService) GetActivityLogs(filter models.ActivityLogFilter) ([]model
 		logs = append(logs, log)
 	}
 
-	return logs, nil
+	response := &models.ActivityLogListResponse{Logs: logs}
+	if filter.Cursor != nil {
+		response.Logs, response.NextCursor, response.PrevCursor = cursor.Trim(filter.Cursor, logs, func(l models.ActivityLog) cursor.Cursor {
+			return cursor.New(&l.CreatedAt, l.ID)
+		})
+	}
+
+	return response, nil
 }
 
 // CreateActivityLog crea un nuevo log de actividad
-func (s *StatsService) CreateActivityLog(userID *uuid.UUID, action, resourceType string, resourceID *uuid.UUID, details map[string]interface{}, ipAddress, userAgent string) error {
+func (s *StatsService) CreateActivityLog(userID *uuid.UUID, action, resourceType string, resourceID *uuid.UUID, details map[string]interface{}, info models.RequestInfo) error {
 	query := `
-		INSERT INTO activity_logs (user_id, action, resource_type, resource_id, details, ip_address, user_agent)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO activity_logs (user_id, impersonator_id, action, resource_type, resource_id, details, ip_address, user_agent)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, '')::inet, $8)
 	`
 
-	_, err := s.db.Exec(query, userID, action, resourceType, resourceID, details, ipAddress, userAgent)
+	// Las acciones realizadas durante una suplantación guardan también la suplantación usada
+	if info.ImpersonationID != nil {
+		merged := make(map[string]interface{}, len(details)+1)
+		for key, value := range details {
+			merged[key] = value
+		}
+		merged["impersonation_id"] = info.ImpersonationID.String()
+		details = merged
+	}
+
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		s.logger.Errorf("Error serializando detalles del log de actividad: %v", err)
+		return err
+	}
+
+	_, err = s.db.Exec(query, userID, info.ImpersonatorID, action, resourceType, resourceID, detailsJSON, info.IPAddress, info.UserAgent)
 	if err != nil {
 		s.logger.Errorf("Error creando log de actividad: %v", err)
 		return err
@@ -165,14 +217,16 @@ func (s *StatsService) CreateActivityLog(userID *uuid.UUID, action, resourceType
 	return nil
 }
 
-// GetPostStats obtiene estadísticas de posts
-func (s *StatsService) GetPostStats() ([]models.PostStats, error) {
+// GetPostStats obtiene estadísticas de posts con el total de visitas y la serie diaria de
+// los últimos days días
+func (s *StatsService) GetPostStats(days int) ([]models.PostStats, error) {
 	query := `
 		SELECT p.id as post_id, p.title, COUNT(c.id) as comment_count, 
-		       0 as view_count, p.published_at
+		       (SELECT COALESCE(SUM(pv.views), 0) FROM post_views pv WHERE pv.post_id = p.id) as view_count,
+		       p.published_at
 		FROM posts p
-		LEFT JOIN comments c ON p.id = c.post_id AND c.is_approved = true
-		WHERE p.status = 'published'
+		LEFT JOIN comments c ON p.id = c.post_id AND c.is_approved = true AND c.deleted_at IS NULL
+		WHERE p.status = 'published' AND p.deleted_at IS NULL
 		GROUP BY p.id, p.title, p.published_at
 		ORDER BY p.published_at DESC
 	`
@@ -195,13 +249,64 @@ func (s *StatsService) GetPostStats() ([]models.PostStats, error) {
 		stats = append(stats, stat)
 	}
 
+	if err := s.fillDailyViews(stats, days); err != nil {
+		return nil, err
+	}
+
 	return stats, nil
 }
 
+// fillDailyViews completa la serie diaria de visitas de cada post para los últimos days días
+func (s *StatsService) fillDailyViews(stats []models.PostStats, days int) error {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	start := today.AddDate(0, 0, -(days - 1))
+
+	rows, err := s.db.Query(`
+		SELECT pv.post_id, pv.day, pv.views
+		FROM post_views pv
+		JOIN posts p ON p.id = pv.post_id
+		WHERE p.status = 'published' AND p.deleted_at IS NULL AND pv.day >= $1
+	`, start.Format("2006-01-02"))
+	if err != nil {
+		s.logger.Errorf("Error obteniendo visitas diarias: %v", err)
+		return err
+	}
+	defer rows.Close()
+
+	views := make(map[uuid.UUID]map[string]int64)
+	for rows.Next() {
+		var postID uuid.UUID
+		var day time.Time
+		var count int64
+		if err := rows.Scan(&postID, &day, &count); err != nil {
+			s.logger.Errorf("Error escaneando visitas diarias: %v", err)
+			return err
+		}
+		if views[postID] == nil {
+			views[postID] = make(map[string]int64)
+		}
+		views[postID][day.Format("2006-01-02")] = count
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range stats {
+		series := make([]models.DailyViews, 0, days)
+		for day := start; !day.After(today); day = day.AddDate(0, 0, 1) {
+			date := day.Format("2006-01-02")
+			series = append(series, models.DailyViews{Date: date, Views: views[stats[i].PostID][date]})
+		}
+		stats[i].DailyViews = series
+	}
+
+	return nil
+}
+
 // GetRecentActivity obtiene actividad reciente
 func (s *StatsService) GetRecentActivity(limit int) ([]models.ActivityLog, error) {
 	query := `
-		SELECT al.id, al.user_id, al.action, al.resource_type, al.resource_id, 
+		SELECT al.id, al.user_id, al.impersonator_id, al.action, al.resource_type, al.resource_id, 
 		       al.details, al.ip_address, al.user_agent, al.created_at,
 		       u.username as user_username, u.first_name as user_first_name, u.last_name as user_last_name
 		FROM activity_logs al
@@ -220,17 +325,19 @@ func (s *StatsService) GetRecentActivity(limit int) ([]models.ActivityLog, error
 	var logs []models.ActivityLog
 	for rows.Next() {
 		var log models.ActivityLog
+		var details []byte
 		var userUsername, userFirstName, userLastName sql.NullString
 
 		err := rows.Scan(
-			&log.ID, &log.UserID, &log.Action, &log.ResourceType, &log.ResourceID,
-			&log.Details, &log.IPAddress, &log.UserAgent, &log.CreatedAt,
+			&log.ID, &log.UserID, &log.ImpersonatorID, &log.Action, &log.ResourceType, &log.ResourceID,
+			&details, &log.IPAddress, &log.UserAgent, &log.CreatedAt,
 			&userUsername, &userFirstName, &userLastName,
 		)
 		if err != nil {
 			s.logger.Errorf("Error escaneando log de actividad: %v", err)
 			continue
 		}
+		log.Details = decodeDetails(details)
 
 		// Construir usuario si existe
 		if userUsername.Valid {
@@ -251,7 +358,7 @@ func (s *StatsService) GetRecentActivity(limit int) ([]models.ActivityLog, error
 // GetUserActivity obtiene actividad de un usuario específico
 func (s *StatsService) GetUserActivity(userID uuid.UUID, limit int) ([]models.ActivityLog, error) {
 	query := `
-		SELECT al.id, al.user_id, al.action, al.resource_type, al.resource_id, 
+		SELECT al.id, al.user_id, al.impersonator_id, al.action, al.resource_type, al.resource_id, 
 		       al.details, al.ip_address, al.user_agent, al.created_at,
 		       u.username as user_username, u.first_name as user_first_name, u.last_name as user_last_name
 		FROM activity_logs al
@@ -271,17 +378,19 @@ func (s *StatsService) GetUserActivity(userID uuid.UUID, limit int) ([]models.Ac
 	var logs []models.ActivityLog
 	for rows.Next() {
 		var log models.ActivityLog
+		var details []byte
 		var userUsername, userFirstName, userLastName sql.NullString
 
 		err := rows.Scan(
-			&log.ID, &log.UserID, &log.Action, &log.ResourceType, &log.ResourceID,
-			&log.Details, &log.IPAddress, &log.UserAgent, &log.CreatedAt,
+			&log.ID, &log.UserID, &log.ImpersonatorID, &log.Action, &log.ResourceType, &log.ResourceID,
+			&details, &log.IPAddress, &log.UserAgent, &log.CreatedAt,
 			&userUsername, &userFirstName, &userLastName,
 		)
 		if err != nil {
 			s.logger.Errorf("Error escaneando log de actividad: %v", err)
 			continue
 		}
+		log.Details = decodeDetails(details)
 
 		// Construir usuario si existe
 		if userUsername.Valid {
@@ -350,3 +459,12 @@ func (s *StatsService) GetDailyStats(days int) (map[string]interface{}, error) {
 
 	return stats, nil
 }
+
+// decodeDetails convierte la columna JSONB details en un mapa
+func decodeDetails(raw []byte) map[string]interface{} {
+	details := make(map[string]interface{})
+	if len(raw) > 0 {
+		_ = json.Unmarshal(raw, &details)
+	}
+	return details
+}
@@ -3,12 +3,17 @@ package services
 import (
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/pkg/slug"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
 
+// maxTagSlugLength es la longitud de la columna tags.slug
+const maxTagSlugLength = 50
+
 // TagService maneja la lógica de negocio para tags
 type TagService struct {
 	db     *sql.DB
@@ -26,8 +31,9 @@ func NewTagService(db *sql.DB, logger *logrus.Logger) *TagService {
 // GetAllTags obtiene todos los tags
 func (s *TagService) GetAllTags() ([]models.Tag, error) {
 	query := `
-		SELECT id, name, slug, description, created_at
+		SELECT id, name, slug, description, created_at, updated_at
 		FROM tags 
+		WHERE deleted_at IS NULL
 		ORDER BY name
 	`
 
@@ -41,7 +47,7 @@ func (s *TagService) GetAllTags() ([]models.Tag, error) {
 	var tags []models.Tag
 	for rows.Next() {
 		var tag models.Tag
-		err := rows.Scan(&tag.ID, &tag.Name, &tag.Slug, &tag.Description, &tag.CreatedAt)
+		err := rows.Scan(&tag.ID, &tag.Name, &tag.Slug, &tag.Description, &tag.CreatedAt, &tag.UpdatedAt)
 		if err != nil {
 			s.logger.Errorf("Error escaneando tag: %v", err)
 			continue
@@ -55,13 +61,13 @@ func (s *TagService) GetAllTags() ([]models.Tag, error) {
 // GetTagByID obtiene un tag por su ID
 func (s *TagService) GetTagByID(id uuid.UUID) (*models.Tag, error) {
 	query := `
-		SELECT id, name, slug, description, created_at
+		SELECT id, name, slug, description, created_at, updated_at
 		FROM tags 
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	var tag models.Tag
-	err := s.db.QueryRow(query, id).Scan(&tag.ID, &tag.Name, &tag.Slug, &tag.Description, &tag.CreatedAt)
+	err := s.db.QueryRow(query, id).Scan(&tag.ID, &tag.Name, &tag.Slug, &tag.Description, &tag.CreatedAt, &tag.UpdatedAt)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -77,13 +83,13 @@ func (s *TagService) GetTagByID(id uuid.UUID) (*models.Tag, error) {
 // GetTagBySlug obtiene un tag por su slug
 func (s *TagService) GetTagBySlug(slug string) (*models.Tag, error) {
 	query := `
-		SELECT id, name, slug, description, created_at
+		SELECT id, name, slug, description, created_at, updated_at
 		FROM tags 
-		WHERE slug = $1
+		WHERE slug = $1 AND deleted_at IS NULL
 	`
 
 	var tag models.Tag
-	err := s.db.QueryRow(query, slug).Scan(&tag.ID, &tag.Name, &tag.Slug, &tag.Description, &tag.CreatedAt)
+	err := s.db.QueryRow(query, slug).Scan(&tag.ID, &tag.Name, &tag.Slug, &tag.Description, &tag.CreatedAt, &tag.UpdatedAt)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -106,10 +112,11 @@ func (s *TagService) GetTagWithPosts(id uuid.UUID) (*models.Tag, error) {
 	// Obtener posts del tag
 	postsQuery := `
 		SELECT p.id, p.title, p.slug, p.content, p.excerpt, p.author_id, p.category_id,
-		       p.status, p.published_at, p.created_at, p.updated_at
+		       p.status, p.published_at, p.scheduled_at, p.created_at, p.updated_at,
+		       p.featured_image_id, p.content_html, p.toc, p.word_count, p.reading_time, p.render_version
 		FROM posts p
 		JOIN post_tags pt ON p.id = pt.post_id
-		WHERE pt.tag_id = $1 AND p.status = 'published'
+		WHERE pt.tag_id = $1 AND p.status = 'published' AND p.deleted_at IS NULL
 		ORDER BY p.published_at DESC
 	`
 
@@ -123,15 +130,21 @@ func (s *TagService) GetTagWithPosts(id uuid.UUID) (*models.Tag, error) {
 	var posts []models.Post
 	for rows.Next() {
 		var post models.Post
+		var render postRender
 		err := rows.Scan(
 			&post.ID, &post.Title, &post.Slug, &post.Content, &post.Excerpt,
-			&post.AuthorID, &post.CategoryID, &post.Status, &post.PublishedAt,
+			&post.AuthorID, &post.CategoryID, &post.Status, &post.PublishedAt, &post.ScheduledAt,
 			&post.CreatedAt, &post.UpdatedAt,
+			&post.FeaturedImageID, &render.html, &render.toc, &render.words, &render.minutes, &render.version,
 		)
 		if err != nil {
 			s.logger.Errorf("Error escaneando post: %v", err)
 			continue
 		}
+		if err := render.apply(&post); err != nil {
+			s.logger.Errorf("Error renderizando post: %v", err)
+			continue
+		}
 		posts = append(posts, post)
 	}
 
@@ -142,10 +155,10 @@ func (s *TagService) GetTagWithPosts(id uuid.UUID) (*models.Tag, error) {
 // GetTagsByPostID obtiene todos los tags de un post
 func (s *TagService) GetTagsByPostID(postID uuid.UUID) ([]models.Tag, error) {
 	query := `
-		SELECT t.id, t.name, t.slug, t.description, t.created_at
+		SELECT t.id, t.name, t.slug, t.description, t.created_at, t.updated_at
 		FROM tags t
 		JOIN post_tags pt ON t.id = pt.tag_id
-		WHERE pt.post_id = $1
+		WHERE pt.post_id = $1 AND t.deleted_at IS NULL
 		ORDER BY t.name
 	`
 
@@ -159,7 +172,7 @@ func (s *TagService) GetTagsByPostID(postID uuid.UUID) ([]models.Tag, error) {
 	var tags []models.Tag
 	for rows.Next() {
 		var tag models.Tag
-		err := rows.Scan(&tag.ID, &tag.Name, &tag.Slug, &tag.Description, &tag.CreatedAt)
+		err := rows.Scan(&tag.ID, &tag.Name, &tag.Slug, &tag.Description, &tag.CreatedAt, &tag.UpdatedAt)
 		if err != nil {
 			s.logger.Errorf("Error escaneando tag: %v", err)
 			continue
@@ -170,41 +183,59 @@ func (s *TagService) GetTagsByPostID(postID uuid.UUID) ([]models.Tag, error) {
 	return tags, nil
 }
 
-// CreateTag crea un nuevo tag
+// CreateTag crea un nuevo tag. Si otra petición ocupa a la vez el slug elegido se reintenta
+// con uno nuevo.
 func (s *TagService) CreateTag(req models.TagCreateRequest) (*models.Tag, error) {
-	// Verificar si el slug ya existe
-	existingTag, _ := s.GetTagBySlug(req.Slug)
-	if existingTag != nil {
-		return nil, fmt.Errorf("el slug de tag ya existe")
+	var tag *models.Tag
+	err := retrySlugConflict(s.logger, "tags_slug_key", func() error {
+		var err error
+		tag, err = s.insertTag(s.db, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Generar slug si no se proporciona
-	slug := req.Slug
-	if slug == "" {
-		slug = req.Name
+	return tag, nil
+}
+
+// CreateTagTx crea un nuevo tag dentro de una transacción
+func (s *TagService) CreateTagTx(tx *sql.Tx, req models.TagCreateRequest) (*models.Tag, error) {
+	return s.insertTag(tx, req)
+}
+
+// insertTag inserta un tag usando la conexión o transacción recibida
+func (s *TagService) insertTag(q queryRower, req models.TagCreateRequest) (*models.Tag, error) {
+	// Un slug explícito debe estar libre; uno generado desde el nombre recibe un sufijo numérico
+	tagSlug, err := s.resolveSlug(req.Slug, req.Name, uuid.Nil)
+	if err != nil {
+		return nil, err
 	}
 
 	query := `
 		INSERT INTO tags (name, slug, description)
 		VALUES ($1, $2, $3)
-		RETURNING id, name, slug, description, created_at
+		RETURNING id, name, slug, description, created_at, updated_at
 	`
 
 	var tag models.Tag
-	err := s.db.QueryRow(query, req.Name, slug, req.Description).Scan(
-		&tag.ID, &tag.Name, &tag.Slug, &tag.Description, &tag.CreatedAt,
+	err = q.QueryRow(query, req.Name, tagSlug, req.Description).Scan(
+		&tag.ID, &tag.Name, &tag.Slug, &tag.Description, &tag.CreatedAt, &tag.UpdatedAt,
 	)
 
 	if err != nil {
-		s.logger.Errorf("Error creando tag: %v", err)
+		if !isConstraintViolation(err, "tags_slug_key") {
+			s.logger.Errorf("Error creando tag: %v", err)
+		}
 		return nil, err
 	}
 
 	return &tag, nil
 }
 
-// UpdateTag actualiza un tag existente
-func (s *TagService) UpdateTag(id uuid.UUID, req models.TagUpdateRequest) (*models.Tag, error) {
+// UpdateTag actualiza un tag existente. ifMatch condiciona la actualización a la versión del
+// tag (ver versionsParam).
+func (s *TagService) UpdateTag(id uuid.UUID, req models.TagUpdateRequest, ifMatch []time.Time) (*models.Tag, error) {
 	// Verificar que el tag existe
 	existingTag, err := s.GetTagByID(id)
 	if err != nil {
@@ -219,22 +250,35 @@ func (s *TagService) UpdateTag(id uuid.UUID, req models.TagUpdateRequest) (*mode
 		existingTag.Description = req.Description
 	}
 	if req.Slug != "" {
-		existingTag.Slug = req.Slug
+		existingTag.Slug, err = s.resolveSlug(req.Slug, "", id)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	query := `
 		UPDATE tags 
-		SET name = $1, slug = $2, description = $3
-		WHERE id = $4
-		RETURNING id, name, slug, description, created_at
+		SET name = $1, slug = $2, description = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4 AND deleted_at IS NULL AND ($5::timestamptz[] IS NULL OR updated_at = ANY($5::timestamptz[]))
+		RETURNING id, name, slug, description, created_at, updated_at
 	`
 
 	var tag models.Tag
-	err = s.db.QueryRow(query, existingTag.Name, existingTag.Slug, existingTag.Description, id).Scan(
-		&tag.ID, &tag.Name, &tag.Slug, &tag.Description, &tag.CreatedAt,
+	err = s.db.QueryRow(query, existingTag.Name, existingTag.Slug, existingTag.Description, id,
+		versionsParam(ifMatch)).Scan(
+		&tag.ID, &tag.Name, &tag.Slug, &tag.Description, &tag.CreatedAt, &tag.UpdatedAt,
 	)
 
 	if err != nil {
+		if err == sql.ErrNoRows {
+			if ifMatch != nil {
+				return nil, fmt.Errorf("el recurso fue modificado por otra petición")
+			}
+			return nil, fmt.Errorf("tag no encontrado")
+		}
+		if isConstraintViolation(err, "tags_slug_key") {
+			return nil, fmt.Errorf("el slug de tag ya existe")
+		}
 		s.logger.Errorf("Error actualizando tag: %v", err)
 		return nil, err
 	}
@@ -242,11 +286,45 @@ func (s *TagService) UpdateTag(id uuid.UUID, req models.TagUpdateRequest) (*mode
 	return &tag, nil
 }
 
-// DeleteTag elimina un tag
+// resolveSlug normaliza el slug explícito y verifica que no lo use otro tag o, si no se
+// proporciona, genera uno único a partir del nombre
+func (s *TagService) resolveSlug(explicit, name string, excludeID uuid.UUID) (string, error) {
+	exists := func(candidate string) (bool, error) {
+		var taken bool
+		err := s.db.QueryRow(
+			"SELECT EXISTS (SELECT 1 FROM tags WHERE slug = $1 AND id <> $2)", candidate, excludeID,
+		).Scan(&taken)
+		if err != nil {
+			s.logger.Errorf("Error verificando slug de tag: %v", err)
+		}
+		return taken, err
+	}
+
+	if explicit == "" {
+		return slug.Unique(slug.Make(name), maxTagSlugLength, exists)
+	}
+
+	tagSlug := slug.Truncate(slug.Make(explicit), maxTagSlugLength)
+	taken, err := exists(tagSlug)
+	if err != nil {
+		return "", err
+	}
+	if taken {
+		return "", fmt.Errorf("el slug de tag ya existe")
+	}
+
+	return tagSlug, nil
+}
+
+// DeleteTag mueve un tag a la papelera. Los posts en la papelera no impiden eliminarlo.
 func (s *TagService) DeleteTag(id uuid.UUID) error {
 	// Verificar si hay posts asociados
 	var count int
-	err := s.db.QueryRow("SELECT COUNT(*) FROM post_tags WHERE tag_id = $1", id).Scan(&count)
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM post_tags pt
+		JOIN posts p ON pt.post_id = p.id
+		WHERE pt.tag_id = $1 AND p.deleted_at IS NULL
+	`, id).Scan(&count)
 	if err != nil {
 		s.logger.Errorf("Error verificando posts del tag: %v", err)
 		return err
@@ -256,7 +334,7 @@ func (s *TagService) DeleteTag(id uuid.UUID) error {
 		return fmt.Errorf("no se puede eliminar el tag porque tiene posts asociados")
 	}
 
-	query := "DELETE FROM tags WHERE id = $1"
+	query := "UPDATE tags SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL"
 
 	result, err := s.db.Exec(query, id)
 	if err != nil {
@@ -276,14 +354,86 @@ func (s *TagService) DeleteTag(id uuid.UUID) error {
 	return nil
 }
 
+// GetDeletedTags obtiene los tags de la papelera, del eliminado más recientemente al más antiguo
+func (s *TagService) GetDeletedTags() ([]models.Tag, error) {
+	query := `
+		SELECT id, name, slug, description, created_at, updated_at, deleted_at
+		FROM tags
+		WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+	`
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		s.logger.Errorf("Error obteniendo tags de la papelera: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []models.Tag{}
+	for rows.Next() {
+		var tag models.Tag
+		err := rows.Scan(&tag.ID, &tag.Name, &tag.Slug, &tag.Description, &tag.CreatedAt, &tag.UpdatedAt, &tag.DeletedAt)
+		if err != nil {
+			s.logger.Errorf("Error escaneando tag de la papelera: %v", err)
+			continue
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, nil
+}
+
+// RestoreTag saca un tag de la papelera
+func (s *TagService) RestoreTag(id uuid.UUID) (*models.Tag, error) {
+	query := `
+		UPDATE tags SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND deleted_at IS NOT NULL
+		RETURNING id, name, slug, description, created_at, updated_at
+	`
+
+	var tag models.Tag
+	err := s.db.QueryRow(query, id).Scan(&tag.ID, &tag.Name, &tag.Slug, &tag.Description, &tag.CreatedAt, &tag.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("tag no encontrado en la papelera")
+		}
+		s.logger.Errorf("Error restaurando tag: %v", err)
+		return nil, err
+	}
+
+	return &tag, nil
+}
+
+// PurgeTag elimina definitivamente un tag de la papelera junto con sus asociaciones a posts
+func (s *TagService) PurgeTag(id uuid.UUID) error {
+	result, err := s.db.Exec("DELETE FROM tags WHERE id = $1 AND deleted_at IS NOT NULL", id)
+	if err != nil {
+		s.logger.Errorf("Error purgando tag: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("tag no encontrado en la papelera")
+	}
+
+	return nil
+}
+
 // GetPopularTags obtiene los tags más populares
 func (s *TagService) GetPopularTags(limit int) ([]models.Tag, error) {
 	query := `
-		SELECT t.id, t.name, t.slug, t.description, t.created_at, COUNT(pt.post_id) as post_count
+		SELECT t.id, t.name, t.slug, t.description, t.created_at, t.updated_at, COUNT(pt.post_id) as post_count
 		FROM tags t
 		LEFT JOIN post_tags pt ON t.id = pt.tag_id
-		LEFT JOIN posts p ON pt.post_id = p.id AND p.status = 'published'
-		GROUP BY t.id, t.name, t.slug, t.description, t.created_at
+		LEFT JOIN posts p ON pt.post_id = p.id AND p.status = 'published' AND p.deleted_at IS NULL
+		WHERE t.deleted_at IS NULL
+		GROUP BY t.id, t.name, t.slug, t.description, t.created_at, t.updated_at
 		ORDER BY post_count DESC, t.name
 		LIMIT $1
 	`
@@ -299,7 +449,7 @@ func (s *TagService) GetPopularTags(limit int) ([]models.Tag, error) {
 	for rows.Next() {
 		var tag models.Tag
 		var postCount int
-		err := rows.Scan(&tag.ID, &tag.Name, &tag.Slug, &tag.Description, &tag.CreatedAt, &postCount)
+		err := rows.Scan(&tag.ID, &tag.Name, &tag.Slug, &tag.Description, &tag.CreatedAt, &tag.UpdatedAt, &postCount)
 		if err != nil {
 			s.logger.Errorf("Error escaneando tag popular: %v", err)
 			continue
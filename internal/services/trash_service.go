@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TrashService purga definitivamente el contenido que lleva en la papelera más tiempo que la retención
+type TrashService struct {
+	db        *sql.DB
+	retention time.Duration
+	logger    *logrus.Logger
+}
+
+// NewTrashService crea una nueva instancia del servicio de papelera
+func NewTrashService(db *sql.DB, retention time.Duration, logger *logrus.Logger) *TrashService {
+	return &TrashService{
+		db:        db,
+		retention: retention,
+		logger:    logger,
+	}
+}
+
+// PurgeExpired elimina los comentarios, posts, tags y categorías que llevan en la papelera
+// más tiempo que la retención y retorna cuántas filas se eliminaron. Las respuestas de un
+// comentario purgado pasan a su padre para que el borrado en cascada no las arrastre.
+func (s *TrashService) PurgeExpired() (int64, error) {
+	if s.retention <= 0 {
+		return 0, nil
+	}
+
+	cutoff := time.Now().Add(-s.retention)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Subir las respuestas un nivel hasta que ninguna cuelgue de un comentario a purgar
+	for {
+		result, err := tx.Exec(`
+			UPDATE comments c SET parent_id = d.parent_id
+			FROM comments d
+			WHERE c.parent_id = d.id AND d.deleted_at < $1
+			  AND NOT (c.deleted_at IS NOT NULL AND c.deleted_at < $1)
+		`, cutoff)
+		if err != nil {
+			s.logger.Errorf("Error reasignando respuestas de comentarios purgados: %v", err)
+			return 0, err
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			break
+		}
+	}
+
+	var total int64
+	for _, table := range []string{"comments", "posts", "tags", "categories"} {
+		result, err := tx.Exec("DELETE FROM "+table+" WHERE deleted_at < $1", cutoff)
+		if err != nil {
+			s.logger.Errorf("Error purgando %s de la papelera: %v", table, err)
+			return 0, err
+		}
+		affected, _ := result.RowsAffected()
+		total += affected
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	if total > 0 {
+		s.logger.Infof("Papelera: %d elementos purgados", total)
+	}
+
+	return total, nil
+}
+
+// StartSweeper purga periódicamente la papelera hasta que el contexto se cancele
+func (s *TrashService) StartSweeper(ctx context.Context, interval time.Duration) {
+	if interval <= 0 || s.retention <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.PurgeExpired()
+		}
+	}
+}
@@ -0,0 +1,475 @@
+package services
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/pkg/auth"
+	"github.com/alan.bermudez/goasync/pkg/rbac"
+	"github.com/alan.bermudez/goasync/pkg/totp"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// recoveryCodeCount es la cantidad de códigos de recuperación generados por usuario
+const recoveryCodeCount = 10
+
+// TwoFactorService maneja la lógica de negocio para la autenticación de dos factores (TOTP)
+type TwoFactorService struct {
+	db     *sql.DB
+	issuer string
+	cipher *totp.Cipher
+	logger *logrus.Logger
+}
+
+// NewTwoFactorService crea una nueva instancia del servicio de dos factores. Los secretos TOTP
+// se guardan cifrados con cipher.
+func NewTwoFactorService(db *sql.DB, issuer string, cipher *totp.Cipher, logger *logrus.Logger) *TwoFactorService {
+	return &TwoFactorService{
+		db:     db,
+		issuer: issuer,
+		cipher: cipher,
+		logger: logger,
+	}
+}
+
+// GetStatus obtiene el estado de 2FA de un usuario
+func (s *TwoFactorService) GetStatus(user *models.User) (*models.TwoFactorStatus, error) {
+	var status models.TwoFactorStatus
+
+	query := `
+		SELECT u.totp_enabled,
+		       (SELECT COUNT(*) FROM user_recovery_codes rc WHERE rc.user_id = u.id AND rc.used_at IS NULL)
+		FROM users u
+		WHERE u.id = $1
+	`
+
+	err := s.db.QueryRow(query, user.ID).Scan(&status.Enabled, &status.RecoveryCodesRemaining)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("usuario no encontrado")
+		}
+		s.logger.Errorf("Error obteniendo estado de 2FA: %v", err)
+		return nil, err
+	}
+
+	status.Required, err = s.IsRequiredForRole(user.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+// IsEnabled indica si un usuario tiene 2FA activado
+func (s *TwoFactorService) IsEnabled(userID uuid.UUID) (bool, error) {
+	var enabled bool
+	err := s.db.QueryRow("SELECT totp_enabled FROM users WHERE id = $1", userID).Scan(&enabled)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, fmt.Errorf("usuario no encontrado")
+		}
+		s.logger.Errorf("Error verificando 2FA: %v", err)
+		return false, err
+	}
+
+	return enabled, nil
+}
+
+// Enroll retorna el secreto TOTP pendiente de confirmar, generándolo si no existe. Un secreto
+// pendiente se reutiliza para que un nuevo inicio de sesión o inscripción no invalide el que el
+// usuario ya pudo registrar en su aplicación. El secreto no se activa hasta que el usuario
+// envía un código válido con Activate.
+func (s *TwoFactorService) Enroll(user *models.User) (*models.TwoFactorEnrollment, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		s.logger.Errorf("Error generando secreto TOTP: %v", err)
+		return nil, err
+	}
+
+	encrypted, err := s.cipher.Encrypt(secret)
+	if err != nil {
+		s.logger.Errorf("Error cifrando secreto TOTP: %v", err)
+		return nil, err
+	}
+
+	query := `
+		UPDATE users
+		SET totp_secret = COALESCE(totp_secret, $1), totp_last_step = NULL, updated_at = $2
+		WHERE id = $3 AND totp_enabled = false
+		RETURNING totp_secret
+	`
+
+	var stored string
+	err = s.db.QueryRow(query, encrypted, time.Now(), user.ID).Scan(&stored)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("la autenticación de dos factores ya está activada")
+		}
+		s.logger.Errorf("Error guardando secreto TOTP: %v", err)
+		return nil, err
+	}
+
+	secret, err = s.cipher.Decrypt(stored)
+	if err != nil {
+		s.logger.Errorf("Error descifrando secreto TOTP: %v", err)
+		return nil, err
+	}
+
+	return &models.TwoFactorEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+// Activate confirma la inscripción con un código válido y retorna los códigos de recuperación
+func (s *TwoFactorService) Activate(userID uuid.UUID, code string) ([]string, error) {
+	secret, enabled, err := s.getSecret(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if enabled {
+		return nil, fmt.Errorf("la autenticación de dos factores ya está activada")
+	}
+	if !secret.Valid {
+		return nil, fmt.Errorf("no hay una inscripción de dos factores pendiente")
+	}
+
+	step, ok := totp.Validate(secret.String, code, time.Now())
+	if !ok {
+		return nil, fmt.Errorf("código de verificación inválido")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		s.logger.Errorf("Error iniciando transacción: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE users SET totp_enabled = true, totp_last_step = $1, updated_at = $2
+		WHERE id = $3
+	`, step, time.Now(), userID)
+	if err != nil {
+		s.logger.Errorf("Error activando 2FA: %v", err)
+		return nil, err
+	}
+
+	codes, err := s.replaceRecoveryCodes(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.Errorf("Error confirmando transacción: %v", err)
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Verify valida un código TOTP o un código de recuperación de un usuario con 2FA activado.
+// Los códigos TOTP ya usados y los códigos de recuperación consumidos son rechazados.
+func (s *TwoFactorService) Verify(userID uuid.UUID, req models.TwoFactorCodeRequest) error {
+	if req.RecoveryCode != "" {
+		return s.useRecoveryCode(userID, req.RecoveryCode)
+	}
+
+	secret, enabled, err := s.getSecret(userID)
+	if err != nil {
+		return err
+	}
+
+	if !enabled || !secret.Valid {
+		return fmt.Errorf("la autenticación de dos factores no está activada")
+	}
+
+	step, ok := totp.Validate(secret.String, req.Code, time.Now())
+	if !ok {
+		return fmt.Errorf("código de verificación inválido")
+	}
+
+	// Registrar el intervalo usado de forma atómica para impedir la reutilización del código
+	result, err := s.db.Exec(`
+		UPDATE users SET totp_last_step = $1
+		WHERE id = $2 AND (totp_last_step IS NULL OR totp_last_step < $1)
+	`, step, userID)
+	if err != nil {
+		s.logger.Errorf("Error registrando uso de código TOTP: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("código de verificación inválido")
+	}
+
+	return nil
+}
+
+// Disable desactiva 2FA tras verificar un código. No se permite si el rol del usuario lo exige.
+func (s *TwoFactorService) Disable(user *models.User, req models.TwoFactorCodeRequest) error {
+	required, err := s.IsRequiredForRole(user.Role)
+	if err != nil {
+		return err
+	}
+	if required {
+		return fmt.Errorf("la autenticación de dos factores es obligatoria para tu rol")
+	}
+
+	if err := s.Verify(user.ID, req); err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		s.logger.Errorf("Error iniciando transacción: %v", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE users SET totp_enabled = false, totp_secret = NULL, totp_last_step = NULL, updated_at = $1
+		WHERE id = $2
+	`, time.Now(), user.ID)
+	if err != nil {
+		s.logger.Errorf("Error desactivando 2FA: %v", err)
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM user_recovery_codes WHERE user_id = $1", user.ID); err != nil {
+		s.logger.Errorf("Error eliminando códigos de recuperación: %v", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.Errorf("Error confirmando transacción: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// RegenerateRecoveryCodes reemplaza los códigos de recuperación tras verificar un código
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID uuid.UUID, req models.TwoFactorCodeRequest) ([]string, error) {
+	if err := s.Verify(userID, req); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		s.logger.Errorf("Error iniciando transacción: %v", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	codes, err := s.replaceRecoveryCodes(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.Errorf("Error confirmando transacción: %v", err)
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// IsRequiredForRole indica si un rol exige 2FA
+func (s *TwoFactorService) IsRequiredForRole(role string) (bool, error) {
+	var required bool
+	err := s.db.QueryRow("SELECT require_two_factor FROM role_settings WHERE role = $1", role).Scan(&required)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		s.logger.Errorf("Error obteniendo configuración del rol: %v", err)
+		return false, err
+	}
+
+	return required, nil
+}
+
+// GetRoleRequirements obtiene qué roles exigen 2FA
+func (s *TwoFactorService) GetRoleRequirements() (map[string]bool, error) {
+	rows, err := s.db.Query("SELECT role, require_two_factor FROM role_settings ORDER BY role")
+	if err != nil {
+		s.logger.Errorf("Error obteniendo configuración de roles: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	requirements := make(map[string]bool)
+	for _, role := range rbac.Roles {
+		requirements[role] = false
+	}
+
+	for rows.Next() {
+		var role string
+		var required bool
+		if err := rows.Scan(&role, &required); err != nil {
+			s.logger.Errorf("Error escaneando configuración de rol: %v", err)
+			continue
+		}
+		requirements[role] = required
+	}
+
+	return requirements, nil
+}
+
+// SetRoleRequirement exige o deja de exigir 2FA a un rol
+func (s *TwoFactorService) SetRoleRequirement(role string, required bool) error {
+	if !rbac.IsValidRole(role) {
+		return fmt.Errorf("rol inválido")
+	}
+
+	query := `
+		INSERT INTO role_settings (role, require_two_factor, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (role) DO UPDATE SET require_two_factor = $2, updated_at = $3
+	`
+
+	if _, err := s.db.Exec(query, role, required, time.Now()); err != nil {
+		s.logger.Errorf("Error actualizando configuración del rol: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// getSecret obtiene el secreto TOTP descifrado de un usuario y si tiene 2FA activado. Un
+// secreto guardado en claro por una versión anterior se cifra al leerlo.
+func (s *TwoFactorService) getSecret(userID uuid.UUID) (sql.NullString, bool, error) {
+	var secret sql.NullString
+	var enabled bool
+
+	err := s.db.QueryRow("SELECT totp_secret, totp_enabled FROM users WHERE id = $1", userID).Scan(&secret, &enabled)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return secret, false, fmt.Errorf("usuario no encontrado")
+		}
+		s.logger.Errorf("Error obteniendo secreto TOTP: %v", err)
+		return secret, false, err
+	}
+
+	if !secret.Valid {
+		return secret, enabled, nil
+	}
+
+	if !totp.IsEncrypted(secret.String) {
+		s.encryptLegacySecret(userID, secret.String)
+		return secret, enabled, nil
+	}
+
+	secret.String, err = s.cipher.Decrypt(secret.String)
+	if err != nil {
+		s.logger.Errorf("Error descifrando secreto TOTP: %v", err)
+		return secret, false, err
+	}
+
+	return secret, enabled, nil
+}
+
+// encryptLegacySecret cifra un secreto guardado en claro. Solo lo reemplaza si no cambió
+// mientras tanto; un fallo se registra y el secreto sigue siendo válido.
+func (s *TwoFactorService) encryptLegacySecret(userID uuid.UUID, plain string) {
+	encrypted, err := s.cipher.Encrypt(plain)
+	if err != nil {
+		s.logger.Errorf("Error cifrando secreto TOTP: %v", err)
+		return
+	}
+
+	_, err = s.db.Exec("UPDATE users SET totp_secret = $1 WHERE id = $2 AND totp_secret = $3", encrypted, userID, plain)
+	if err != nil {
+		s.logger.Errorf("Error cifrando secreto TOTP guardado en claro: %v", err)
+	}
+}
+
+// useRecoveryCode consume un código de recuperación de forma atómica
+func (s *TwoFactorService) useRecoveryCode(userID uuid.UUID, code string) error {
+	query := `
+		UPDATE user_recovery_codes
+		SET used_at = CURRENT_TIMESTAMP
+		WHERE id = (
+			SELECT id FROM user_recovery_codes
+			WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+			LIMIT 1
+		) AND used_at IS NULL
+	`
+
+	result, err := s.db.Exec(query, userID, auth.HashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		s.logger.Errorf("Error usando código de recuperación: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("código de verificación inválido")
+	}
+
+	return nil
+}
+
+// replaceRecoveryCodes elimina los códigos de recuperación de un usuario y genera nuevos.
+// Solo el hash de cada código se guarda en la base de datos.
+func (s *TwoFactorService) replaceRecoveryCodes(tx *sql.Tx, userID uuid.UUID) ([]string, error) {
+	if _, err := tx.Exec("DELETE FROM user_recovery_codes WHERE user_id = $1", userID); err != nil {
+		s.logger.Errorf("Error eliminando códigos de recuperación: %v", err)
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			s.logger.Errorf("Error generando código de recuperación: %v", err)
+			return nil, err
+		}
+
+		_, err = tx.Exec("INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)",
+			userID, auth.HashToken(normalizeRecoveryCode(code)))
+		if err != nil {
+			s.logger.Errorf("Error guardando código de recuperación: %v", err)
+			return nil, err
+		}
+
+		codes = append(codes, code)
+	}
+
+	return codes, nil
+}
+
+// generateRecoveryCode genera un código de recuperación con el formato xxxxx-xxxxx
+func generateRecoveryCode() (string, error) {
+	buf := make([]byte, 7)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	code := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// normalizeRecoveryCode normaliza un código de recuperación ignorando mayúsculas, guiones y espacios
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
@@ -3,9 +3,12 @@ package services
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/alan.bermudez/goasync/internal/models"
+	"github.com/alan.bermudez/goasync/pkg/password"
+	"github.com/alan.bermudez/goasync/pkg/rbac"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
@@ -13,13 +16,15 @@ import (
 // UserService maneja la lógica de negocio para usuarios
 type UserService struct {
 	db     *sql.DB
+	hasher *password.Hasher
 	logger *logrus.Logger
 }
 
 // NewUserService crea una nueva instancia del servicio de usuarios
-func NewUserService(db *sql.DB, logger *logrus.Logger) *UserService {
+func NewUserService(db *sql.DB, hasher *password.Hasher, logger *logrus.Logger) *UserService {
 	return &UserService{
 		db:     db,
+		hasher: hasher,
 		logger: logger,
 	}
 }
@@ -39,7 +44,7 @@ func (s *UserService) GetAllUsers(page, perPage int) ([]models.User, int, error)
 	// Obtener usuarios
 	query := `
 		SELECT id, username, email, password_hash, first_name, last_name, 
-		       is_active, created_at, updated_at
+		       is_active, role, is_verified, created_at, updated_at
 		FROM users 
 		ORDER BY created_at DESC 
 		LIMIT $1 OFFSET $2
@@ -57,7 +62,7 @@ func (s *UserService) GetAllUsers(page, perPage int) ([]models.User, int, error)
 		var user models.User
 		err := rows.Scan(
 			&user.ID, &user.Username, &user.Email, &user.PasswordHash,
-			&user.FirstName, &user.LastName, &user.IsActive,
+			&user.FirstName, &user.LastName, &user.IsActive, &user.Role, &user.IsVerified,
 			&user.CreatedAt, &user.UpdatedAt,
 		)
 		if err != nil {
@@ -74,7 +79,7 @@ func (s *UserService) GetAllUsers(page, perPage int) ([]models.User, int, error)
 func (s *UserService) GetUserByID(id uuid.UUID) (*models.User, error) {
 	query := `
 		SELECT id, username, email, password_hash, first_name, last_name, 
-		       is_active, created_at, updated_at
+		       is_active, role, is_verified, created_at, updated_at
 		FROM users 
 		WHERE id = $1
 	`
@@ -82,7 +87,7 @@ func (s *UserService) GetUserByID(id uuid.UUID) (*models.User, error) {
 	var user models.User
 	err := s.db.QueryRow(query, id).Scan(
 		&user.ID, &user.Username, &user.Email, &user.PasswordHash,
-		&user.FirstName, &user.LastName, &user.IsActive,
+		&user.FirstName, &user.LastName, &user.IsActive, &user.Role, &user.IsVerified,
 		&user.CreatedAt, &user.UpdatedAt,
 	)
 
@@ -101,7 +106,7 @@ func (s *UserService) GetUserByID(id uuid.UUID) (*models.User, error) {
 func (s *UserService) GetUserByUsername(username string) (*models.User, error) {
 	query := `
 		SELECT id, username, email, password_hash, first_name, last_name, 
-		       is_active, created_at, updated_at
+		       is_active, role, is_verified, created_at, updated_at
 		FROM users 
 		WHERE username = $1
 	`
@@ -109,7 +114,7 @@ func (s *UserService) GetUserByUsername(username string) (*models.User, error) {
 	var user models.User
 	err := s.db.QueryRow(query, username).Scan(
 		&user.ID, &user.Username, &user.Email, &user.PasswordHash,
-		&user.FirstName, &user.LastName, &user.IsActive,
+		&user.FirstName, &user.LastName, &user.IsActive, &user.Role, &user.IsVerified,
 		&user.CreatedAt, &user.UpdatedAt,
 	)
 
@@ -128,7 +133,7 @@ func (s *UserService) GetUserByUsername(username string) (*models.User, error) {
 func (s *UserService) GetUserByEmail(email string) (*models.User, error) {
 	query := `
 		SELECT id, username, email, password_hash, first_name, last_name, 
-		       is_active, created_at, updated_at
+		       is_active, role, is_verified, created_at, updated_at
 		FROM users 
 		WHERE email = $1
 	`
@@ -136,7 +141,7 @@ func (s *UserService) GetUserByEmail(email string) (*models.User, error) {
 	var user models.User
 	err := s.db.QueryRow(query, email).Scan(
 		&user.ID, &user.Username, &user.Email, &user.PasswordHash,
-		&user.FirstName, &user.LastName, &user.IsActive,
+		&user.FirstName, &user.LastName, &user.IsActive, &user.Role, &user.IsVerified,
 		&user.CreatedAt, &user.UpdatedAt,
 	)
 
@@ -151,6 +156,14 @@ func (s *UserService) GetUserByEmail(email string) (*models.User, error) {
 	return &user, nil
 }
 
+// GetUserByLogin obtiene un usuario por email si el login contiene "@", o por username
+func (s *UserService) GetUserByLogin(login string) (*models.User, error) {
+	if strings.Contains(login, "@") {
+		return s.GetUserByEmail(login)
+	}
+	return s.GetUserByUsername(login)
+}
+
 // GetUserWithProfile obtiene un usuario con su perfil
 func (s *UserService) GetUserWithProfile(id uuid.UUID) (*models.User, error) {
 	user, err := s.GetUserByID(id)
@@ -244,32 +257,74 @@ func (s *UserService) GetAllUserStats() ([]models.UserStats, error) {
 
 // CreateUser crea un nuevo usuario
 func (s *UserService) CreateUser(req models.UserCreateRequest) (*models.User, error) {
+	if err := s.checkAvailable(req); err != nil {
+		return nil, err
+	}
+
+	return s.insertUser(s.db, req, rbac.DefaultRole)
+}
+
+// CreateUserTx crea un nuevo usuario con el rol indicado dentro de una transacción
+func (s *UserService) CreateUserTx(tx *sql.Tx, req models.UserCreateRequest, role string) (*models.User, error) {
+	if err := s.checkAvailable(req); err != nil {
+		return nil, err
+	}
+
+	return s.insertUser(tx, req, role)
+}
+
+// checkAvailable verifica que el username y el email no estén registrados
+func (s *UserService) checkAvailable(req models.UserCreateRequest) error {
 	// Verificar si el username ya existe
 	existingUser, _ := s.GetUserByUsername(req.Username)
 	if existingUser != nil {
-		return nil, fmt.Errorf("el nombre de usuario ya existe")
+		return fmt.Errorf("el nombre de usuario ya existe")
 	}
 
 	// Verificar si el email ya existe
 	existingUser, _ = s.GetUserByEmail(req.Email)
 	if existingUser != nil {
-		return nil, fmt.Errorf("el email ya existe")
+		return fmt.Errorf("el email ya existe")
 	}
 
-	// Hash de la contraseña (aquí deberías usar bcrypt)
-	passwordHash := req.Password // En producción, usar bcrypt
+	return nil
+}
+
+// queryRower es implementado por *sql.DB y *sql.Tx
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// execer es implementado por *sql.DB y *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// querier es implementado por *sql.DB y *sql.Tx
+type querier interface {
+	queryRower
+	execer
+}
+
+// insertUser inserta un usuario con el rol indicado usando la conexión o transacción recibida
+func (s *UserService) insertUser(q queryRower, req models.UserCreateRequest, role string) (*models.User, error) {
+	// Hash de la contraseña
+	passwordHash, err := s.hasher.Hash(req.Password)
+	if err != nil {
+		return nil, err
+	}
 
 	query := `
-		INSERT INTO users (username, email, password_hash, first_name, last_name)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO users (username, email, password_hash, first_name, last_name, role)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id, username, email, password_hash, first_name, last_name, 
-		          is_active, created_at, updated_at
+		          is_active, role, is_verified, created_at, updated_at
 	`
 
 	var user models.User
-	err := s.db.QueryRow(query, req.Username, req.Email, passwordHash, req.FirstName, req.LastName).Scan(
+	err = q.QueryRow(query, req.Username, req.Email, passwordHash, req.FirstName, req.LastName, role).Scan(
 		&user.ID, &user.Username, &user.Email, &user.PasswordHash,
-		&user.FirstName, &user.LastName, &user.IsActive,
+		&user.FirstName, &user.LastName, &user.IsActive, &user.Role, &user.IsVerified,
 		&user.CreatedAt, &user.UpdatedAt,
 	)
 
@@ -305,14 +360,14 @@ func (s *UserService) UpdateUser(id uuid.UUID, req models.UserUpdateRequest) (*m
 		SET first_name = $1, last_name = $2, is_active = $3, updated_at = $4
 		WHERE id = $5
 		RETURNING id, username, email, password_hash, first_name, last_name, 
-		          is_active, created_at, updated_at
+		          is_active, role, is_verified, created_at, updated_at
 	`
 
 	var user models.User
 	err = s.db.QueryRow(query, existingUser.FirstName, existingUser.LastName,
 		existingUser.IsActive, time.Now(), id).Scan(
 		&user.ID, &user.Username, &user.Email, &user.PasswordHash,
-		&user.FirstName, &user.LastName, &user.IsActive,
+		&user.FirstName, &user.LastName, &user.IsActive, &user.Role, &user.IsVerified,
 		&user.CreatedAt, &user.UpdatedAt,
 	)
 
@@ -345,3 +400,162 @@ func (s *UserService) DeleteUser(id uuid.UUID) error {
 
 	return nil
 }
+
+// VerifyPassword verifica la contraseña de un usuario y actualiza su hash si
+// fue generado con parámetros distintos a los actuales
+func (s *UserService) VerifyPassword(user *models.User, plain string) bool {
+	if !s.hasher.Verify(user.PasswordHash, plain) {
+		return false
+	}
+
+	if s.hasher.NeedsRehash(user.PasswordHash) {
+		if err := s.setPassword(s.db, user.ID, plain, false); err != nil {
+			s.logger.Errorf("Error actualizando hash de contraseña: %v", err)
+		}
+	}
+
+	return true
+}
+
+// VerifyDummyPassword consume el mismo tiempo que VerifyPassword sin usuario, para que un
+// email o nombre de usuario inexistente no se distinga por el tiempo de respuesta
+func (s *UserService) VerifyDummyPassword(plain string) {
+	s.hasher.VerifyDummy(plain)
+}
+
+// GetTokenVersion obtiene la versión actual de los tokens de refresco de un usuario
+func (s *UserService) GetTokenVersion(id uuid.UUID) (int, error) {
+	var version int
+	err := s.db.QueryRow("SELECT token_version FROM users WHERE id = $1", id).Scan(&version)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("usuario no encontrado")
+		}
+		s.logger.Errorf("Error obteniendo versión de tokens: %v", err)
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// changePassword cambia la contraseña de un usuario verificando la contraseña actual, usando
+// la conexión o transacción recibida. Invalida los tokens de refresco del usuario.
+func (s *UserService) changePassword(q execer, id uuid.UUID, req models.PasswordChangeRequest) error {
+	user, err := s.GetUserByID(id)
+	if err != nil {
+		return err
+	}
+
+	if !s.hasher.Verify(user.PasswordHash, req.OldPassword) {
+		return fmt.Errorf("la contraseña actual es incorrecta")
+	}
+
+	if len(req.NewPassword) < 6 {
+		return fmt.Errorf("la nueva contraseña debe tener al menos 6 caracteres")
+	}
+
+	return s.setPassword(q, id, req.NewPassword, true)
+}
+
+// setPassword genera y guarda el hash de una nueva contraseña usando la conexión o
+// transacción recibida. Con revokeTokens incrementa token_version, lo que invalida los
+// tokens de refresco emitidos hasta ahora; el rehash transparente no lo hace.
+func (s *UserService) setPassword(q execer, id uuid.UUID, plain string, revokeTokens bool) error {
+	passwordHash, err := s.hasher.Hash(plain)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE users
+		SET password_hash = $1, updated_at = $2,
+		    token_version = token_version + CASE WHEN $4 THEN 1 ELSE 0 END
+		WHERE id = $3
+	`
+
+	result, err := q.Exec(query, passwordHash, time.Now(), id, revokeTokens)
+	if err != nil {
+		s.logger.Errorf("Error actualizando contraseña: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("usuario no encontrado")
+	}
+
+	return nil
+}
+
+// GetUserRole obtiene el rol de un usuario activo
+func (s *UserService) GetUserRole(id uuid.UUID) (string, error) {
+	var role string
+	err := s.db.QueryRow("SELECT role FROM users WHERE id = $1 AND is_active = true", id).Scan(&role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("usuario no encontrado")
+		}
+		s.logger.Errorf("Error obteniendo rol de usuario: %v", err)
+		return "", err
+	}
+
+	return role, nil
+}
+
+// UpdateUserRole cambia el rol de un usuario
+func (s *UserService) UpdateUserRole(id uuid.UUID, role string) (*models.User, error) {
+	if !rbac.IsValidRole(role) {
+		return nil, fmt.Errorf("rol inválido")
+	}
+
+	query := `
+		UPDATE users
+		SET role = $1, updated_at = $2
+		WHERE id = $3
+		RETURNING id, username, email, password_hash, first_name, last_name, 
+		          is_active, role, is_verified, created_at, updated_at
+	`
+
+	var user models.User
+	err := s.db.QueryRow(query, role, time.Now(), id).Scan(
+		&user.ID, &user.Username, &user.Email, &user.PasswordHash,
+		&user.FirstName, &user.LastName, &user.IsActive, &user.Role, &user.IsVerified,
+		&user.CreatedAt, &user.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("usuario no encontrado")
+		}
+		s.logger.Errorf("Error actualizando rol de usuario: %v", err)
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// MarkEmailVerified marca el email de un usuario como verificado
+func (s *UserService) MarkEmailVerified(id uuid.UUID) error {
+	query := "UPDATE users SET is_verified = true, updated_at = $1 WHERE id = $2"
+
+	result, err := s.db.Exec(query, time.Now(), id)
+	if err != nil {
+		s.logger.Errorf("Error verificando email: %v", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("usuario no encontrado")
+	}
+
+	return nil
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// maxTrackedVisitors limita la memoria usada por la deduplicación de visitas. Al alcanzarlo
+// las visitas nuevas se cuentan sin deduplicar hasta la siguiente limpieza.
+const maxTrackedVisitors = 100000
+
+// botSignatures son fragmentos de User-Agent de bots, rastreadores y clientes automáticos
+var botSignatures = []string{
+	"bot", "crawler", "spider", "slurp", "crawl", "archiver", "facebookexternalhit",
+	"embedly", "preview", "monitor", "headless", "lighthouse", "pingdom", "uptime",
+	"curl", "wget", "python-requests", "python-urllib", "go-http-client", "java/",
+	"okhttp", "httpclient", "axios", "node-fetch", "libwww", "scrapy", "postman",
+}
+
+// viewKey identifica el contador de visitas de un post en un día
+type viewKey struct {
+	postID uuid.UUID
+	day    string
+}
+
+// ViewService cuenta las visitas a posts en memoria y las vuelca por lotes a la tabla
+// post_views, de modo que registrar una visita nunca escribe en la base de datos
+type ViewService struct {
+	db          *sql.DB
+	dedupWindow time.Duration
+	logger      *logrus.Logger
+
+	mu      sync.Mutex
+	pending map[viewKey]int64
+	seen    map[uint64]time.Time
+}
+
+// NewViewService crea una nueva instancia del contador de visitas. dedupWindow es el tiempo
+// durante el que se ignoran las visitas repetidas de un mismo visitante, 0 para no deduplicar.
+func NewViewService(db *sql.DB, dedupWindow time.Duration, logger *logrus.Logger) *ViewService {
+	return &ViewService{
+		db:          db,
+		dedupWindow: dedupWindow,
+		logger:      logger,
+		pending:     make(map[viewKey]int64),
+		seen:        make(map[uint64]time.Time),
+	}
+}
+
+// Record registra una visita a un post. Ignora bots y, si la deduplicación está activa,
+// las visitas repetidas del mismo visitante (IP y User-Agent) dentro de la ventana.
+func (s *ViewService) Record(postID uuid.UUID, ip, userAgent string) {
+	if IsBot(userAgent) {
+		return
+	}
+
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.dedupWindow > 0 {
+		visitor := visitorHash(postID, ip, userAgent)
+		if last, ok := s.seen[visitor]; ok && now.Sub(last) < s.dedupWindow {
+			return
+		}
+		if len(s.seen) < maxTrackedVisitors {
+			s.seen[visitor] = now
+		}
+	}
+
+	s.pending[viewKey{postID: postID, day: now.UTC().Format("2006-01-02")}]++
+}
+
+// Flush vuelca las visitas acumuladas a post_views en una sola sentencia. Si la escritura
+// falla las visitas se devuelven al buffer para el siguiente intento.
+func (s *ViewService) Flush() error {
+	s.mu.Lock()
+	batch := s.pending
+	s.pending = make(map[viewKey]int64)
+	s.purgeSeen(time.Now())
+	s.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	postIDs := make([]string, 0, len(batch))
+	days := make([]string, 0, len(batch))
+	views := make([]int64, 0, len(batch))
+	for key, count := range batch {
+		postIDs = append(postIDs, key.postID.String())
+		days = append(days, key.day)
+		views = append(views, count)
+	}
+
+	// El JOIN con posts descarta las visitas de posts eliminados desde que se registraron
+	_, err := s.db.Exec(`
+		INSERT INTO post_views (post_id, day, views)
+		SELECT v.post_id, v.day, v.views
+		FROM unnest($1::uuid[], $2::date[], $3::bigint[]) AS v(post_id, day, views)
+		JOIN posts p ON p.id = v.post_id
+		ON CONFLICT (post_id, day) DO UPDATE SET views = post_views.views + EXCLUDED.views
+	`, pq.Array(postIDs), pq.Array(days), pq.Array(views))
+	if err != nil {
+		s.logger.Errorf("Error guardando visitas de posts: %v", err)
+
+		s.mu.Lock()
+		for key, count := range batch {
+			s.pending[key] += count
+		}
+		s.mu.Unlock()
+		return err
+	}
+
+	s.logger.Debugf("Guardadas visitas de %d posts", len(batch))
+	return nil
+}
+
+// Start vuelca periódicamente las visitas hasta que el contexto se cancele, momento en el
+// que realiza un último volcado
+func (s *ViewService) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.Flush()
+			return
+		case <-ticker.C:
+			s.Flush()
+		}
+	}
+}
+
+// purgeSeen elimina los visitantes cuya ventana de deduplicación ya terminó
+func (s *ViewService) purgeSeen(now time.Time) {
+	for visitor, last := range s.seen {
+		if now.Sub(last) >= s.dedupWindow {
+			delete(s.seen, visitor)
+		}
+	}
+}
+
+// IsBot indica si un User-Agent pertenece a un bot o cliente automático. Un User-Agent
+// vacío también se considera automático.
+func IsBot(userAgent string) bool {
+	ua := strings.ToLower(strings.TrimSpace(userAgent))
+	if ua == "" {
+		return true
+	}
+
+	for _, signature := range botSignatures {
+		if strings.Contains(ua, signature) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// visitorHash resume post, IP y User-Agent para no guardar datos del visitante en memoria
+func visitorHash(postID uuid.UUID, ip, userAgent string) uint64 {
+	h := fnv.New64a()
+	h.Write(postID[:])
+	h.Write([]byte(ip))
+	h.Write([]byte{0})
+	h.Write([]byte(userAgent))
+	return h.Sum64()
+}
@@ -1,8 +1,14 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -10,6 +16,7 @@ import (
 
 	"github.com/alan.bermudez/goasync/internal/config"
 	"github.com/alan.bermudez/goasync/internal/handlers"
+	"github.com/alan.bermudez/goasync/internal/services"
 	"github.com/alan.bermudez/goasync/pkg/logger"
 )
 
@@ -26,6 +33,17 @@ func main() {
 	logger.Init(cfg.Log.Level)
 	log := logger.GetLogger()
 
+	// Verificar la configuración de seguridad antes de arrancar
+	if err := cfg.Validate(); err != nil {
+		log.Fatal("Configuración inválida: ", err)
+	}
+	if cfg.Auth.JWTSecret == config.DefaultJWTSecret {
+		log.Warn("JWT_SECRET usa el valor por defecto; define uno propio antes de desplegar")
+	}
+	if cfg.Auth.TOTPEncryptionKey == config.DefaultTOTPEncryptionKey {
+		log.Warn("TOTP_ENCRYPTION_KEY usa el valor por defecto; define una propia antes de desplegar")
+	}
+
 	// Conectar a la base de datos
 	db, err := sql.Open("postgres", cfg.Database.URL())
 	if err != nil {
@@ -51,12 +69,52 @@ func main() {
 	// Middleware de recuperación
 	router.Use(gin.Recovery())
 
+	// Contexto de los procesos en segundo plano
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var wg sync.WaitGroup
+
 	// Configurar rutas
-	handlers.SetupRoutes(router, db, log)
+	handlers.SetupRoutes(ctx, &wg, router, db, cfg, log)
+
+	// Iniciar el publicador de posts programados
+	publisher := services.NewPublisherService(db, services.NewStatsService(db, log), cfg.Content.PublishBatchSize, log)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		publisher.Start(ctx, cfg.Content.PublishInterval)
+	}()
+
+	// SIGINT o SIGTERM inician el apagado ordenado
+	signalCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	// Iniciar el servidor
-	log.Printf("Servidor iniciando en el puerto %s", cfg.Server.Port)
-	if err := router.Run(":" + cfg.Server.Port); err != nil {
-		log.Fatal("Error al iniciar el servidor:", err)
+	server := &http.Server{
+		Addr:    ":" + cfg.Server.Port,
+		Handler: router,
+	}
+	go func() {
+		log.Printf("Servidor iniciando en el puerto %s", cfg.Server.Port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Error al iniciar el servidor:", err)
+		}
+	}()
+
+	<-signalCtx.Done()
+	stop()
+	log.Info("Apagando el servidor...")
+
+	// Dejar de aceptar conexiones y esperar a las peticiones en curso
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancelShutdown()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Errorf("Error apagando el servidor: %v", err)
 	}
+
+	// Detener los procesos en segundo plano; el contador de visitas hace un último volcado
+	cancel()
+	wg.Wait()
+
+	log.Info("Servidor detenido")
 }
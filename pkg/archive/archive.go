@@ -0,0 +1,67 @@
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Writer recibe archivos por su ruta relativa con "/" como separador
+type Writer interface {
+	WriteFile(name string, data []byte, modTime time.Time) error
+}
+
+// TarGz escribe los archivos como un tar.gz sobre un io.Writer a medida que se reciben, sin
+// guardarlos en memoria ni en disco
+type TarGz struct {
+	gz  *gzip.Writer
+	tar *tar.Writer
+}
+
+// NewTarGz crea un TarGz que escribe en w. Close debe llamarse para completar el archivo.
+func NewTarGz(w io.Writer) *TarGz {
+	gz := gzip.NewWriter(w)
+	return &TarGz{gz: gz, tar: tar.NewWriter(gz)}
+}
+
+// WriteFile añade un archivo al tar.gz
+func (a *TarGz) WriteFile(name string, data []byte, modTime time.Time) error {
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0o644,
+		Size:     int64(len(data)),
+		ModTime:  modTime,
+	}
+	if err := a.tar.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := a.tar.Write(data)
+	return err
+}
+
+// Close completa el tar y el gzip; no cierra el io.Writer subyacente
+func (a *TarGz) Close() error {
+	if err := a.tar.Close(); err != nil {
+		return err
+	}
+	return a.gz.Close()
+}
+
+// Dir escribe los archivos en un directorio, creando los subdirectorios necesarios
+type Dir string
+
+// WriteFile escribe un archivo bajo el directorio
+func (d Dir) WriteFile(name string, data []byte, modTime time.Time) error {
+	path := filepath.Join(string(d), filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return err
+	}
+	return os.Chtimes(path, modTime, modTime)
+}
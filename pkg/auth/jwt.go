@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Tipos de token emitidos por el TokenManager
+const (
+	TokenTypeAccess    = "access"
+	TokenTypeRefresh   = "refresh"
+	TokenTypeTwoFactor = "2fa"
+)
+
+// ErrInvalidToken se retorna cuando un token no es válido o ha expirado
+var ErrInvalidToken = errors.New("token inválido o expirado")
+
+// Claims representa los claims de los tokens JWT de la API
+type Claims struct {
+	TokenType string `json:"typ"`
+	// Method indica el mecanismo de inicio de sesión a completar en los tokens de desafío 2FA
+	Method string `json:"mtd,omitempty"`
+	// Version es la versión de tokens del usuario al emitir un token de refresco; los tokens
+	// de una versión anterior se rechazan al renovar
+	Version int `json:"ver,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// UserID retorna el ID del usuario contenido en el subject del token
+func (c *Claims) UserID() (uuid.UUID, error) {
+	return uuid.Parse(c.Subject)
+}
+
+// TokenPair representa un par de tokens de acceso y refresco
+type TokenPair struct {
+	AccessToken      string    `json:"access_token"`
+	RefreshToken     string    `json:"refresh_token"`
+	TokenType        string    `json:"token_type"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// TokenManager firma y valida tokens JWT con HMAC-SHA256
+type TokenManager struct {
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+// NewTokenManager crea una nueva instancia del gestor de tokens
+func NewTokenManager(secret string, accessTTL, refreshTTL time.Duration) *TokenManager {
+	return &TokenManager{
+		secret:     []byte(secret),
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}
+}
+
+// GeneratePair genera un token de acceso y uno de refresco para un usuario. version es la
+// versión de tokens del usuario, que se incluye en el token de refresco.
+func (m *TokenManager) GeneratePair(userID uuid.UUID, version int) (*TokenPair, error) {
+	now := time.Now()
+
+	accessExpiresAt := now.Add(m.accessTTL)
+	accessToken, err := m.sign(userID, TokenTypeAccess, 0, now, accessExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshExpiresAt := now.Add(m.refreshTTL)
+	refreshToken, err := m.sign(userID, TokenTypeRefresh, version, now, refreshExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		TokenType:        "Bearer",
+		ExpiresAt:        accessExpiresAt,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
+
+// GenerateChallenge genera un token de desafío de segundo factor de corta duración.
+// Solo sirve para completar el inicio de sesión, no para acceder a la API.
+func (m *TokenManager) GenerateChallenge(userID uuid.UUID, method string, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+
+	token, err := m.signClaims(Claims{
+		TokenType: TokenTypeTwoFactor,
+		Method:    method,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   userID.String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, expiresAt, nil
+}
+
+// Parse valida un token y verifica que sea del tipo esperado
+func (m *TokenManager) Parse(tokenString, expectedType string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		return m.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	if claims.TokenType != expectedType {
+		return nil, ErrInvalidToken
+	}
+
+	if _, err := claims.UserID(); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// sign firma un token con los claims indicados
+func (m *TokenManager) sign(userID uuid.UUID, tokenType string, version int, issuedAt, expiresAt time.Time) (string, error) {
+	return m.signClaims(Claims{
+		TokenType: tokenType,
+		Version:   version,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   userID.String(),
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	})
+}
+
+// signClaims firma un token con HMAC-SHA256
+func (m *TokenManager) signClaims(claims Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString(m.secret)
+	if err != nil {
+		return "", fmt.Errorf("error firmando token: %w", err)
+	}
+
+	return signed, nil
+}
@@ -0,0 +1,169 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Límites del tamaño de página en modo cursor
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// ErrInvalid se retorna cuando un cursor no se puede decodificar
+var ErrInvalid = errors.New("cursor inválido")
+
+// Cursor es una posición opaca en un listado ordenado de forma descendente por
+// (Time, ID). Time es nil cuando la clave de orden es NULL (ej: posts sin publicar).
+type Cursor struct {
+	Time *time.Time `json:"t,omitempty"`
+	ID   uuid.UUID  `json:"id"`
+}
+
+// Page describe la página pedida: After avanza hacia elementos más antiguos y
+// Before retrocede hacia elementos más recientes. Solo uno de los dos puede estar presente.
+type Page struct {
+	After  *Cursor
+	Before *Cursor
+	Limit  int
+}
+
+// New crea un cursor a partir de la clave de orden de un elemento
+func New(t *time.Time, id uuid.UUID) Cursor {
+	return Cursor{Time: t, ID: id}
+}
+
+// Encode serializa el cursor como una cadena opaca segura para URLs
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Decode deserializa un cursor generado por Encode
+func Decode(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == uuid.Nil {
+		return nil, ErrInvalid
+	}
+
+	return &c, nil
+}
+
+// Parse construye la página a partir de los parámetros after, before y limit
+func Parse(after, before string, limit int) (*Page, error) {
+	if after != "" && before != "" {
+		return nil, errors.New("no se pueden usar after y before a la vez")
+	}
+
+	page := &Page{Limit: limit}
+	if page.Limit < 1 || page.Limit > MaxLimit {
+		page.Limit = DefaultLimit
+	}
+
+	var err error
+	if after != "" {
+		page.After, err = Decode(after)
+	} else if before != "" {
+		page.Before, err = Decode(before)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// Backward indica si la página se recorre hacia atrás
+func (p *Page) Backward() bool {
+	return p.Before != nil
+}
+
+// Where retorna la condición SQL que selecciona los elementos posteriores (o anteriores si
+// la página va hacia atrás) al cursor. arg registra un parámetro y retorna su marcador ($N).
+// Retorna una cadena vacía en la primera página.
+func (p *Page) Where(timeCol, idCol string, arg func(interface{}) string) string {
+	c := p.After
+	if p.Backward() {
+		c = p.Before
+	}
+	if c == nil {
+		return ""
+	}
+
+	// El orden es "timeCol DESC NULLS LAST, idCol DESC"
+	if c.Time == nil {
+		id := arg(c.ID)
+		if p.Backward() {
+			return fmt.Sprintf("(%s IS NOT NULL OR %s > %s)", timeCol, idCol, id)
+		}
+		return fmt.Sprintf("(%s IS NULL AND %s < %s)", timeCol, idCol, id)
+	}
+
+	t, id := arg(*c.Time), arg(c.ID)
+	if p.Backward() {
+		return fmt.Sprintf("(%s > %s OR (%s = %s AND %s > %s))", timeCol, t, timeCol, t, idCol, id)
+	}
+	return fmt.Sprintf("(%s < %s OR (%s = %s AND %s < %s) OR %s IS NULL)", timeCol, t, timeCol, t, idCol, id, timeCol)
+}
+
+// OrderBy retorna el orden SQL de la página. Hacia atrás se invierte y Trim restaura el orden.
+func (p *Page) OrderBy(timeCol, idCol string) string {
+	if p.Backward() {
+		return fmt.Sprintf("%s ASC NULLS FIRST, %s ASC", timeCol, idCol)
+	}
+	return fmt.Sprintf("%s DESC NULLS LAST, %s DESC", timeCol, idCol)
+}
+
+// Fetch retorna cuántas filas pedir: una más que el límite para saber si hay más elementos
+func (p *Page) Fetch() int {
+	return p.Limit + 1
+}
+
+// Trim recorta los elementos obtenidos con Fetch, los devuelve en orden descendente y
+// calcula los cursores de la página siguiente y anterior (vacíos si no existen)
+func Trim[T any](p *Page, items []T, key func(T) Cursor) ([]T, string, string) {
+	hasMore := len(items) > p.Limit
+	if hasMore {
+		items = items[:p.Limit]
+	}
+
+	if p.Backward() {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+
+	if len(items) == 0 {
+		return items, "", ""
+	}
+
+	var next, prev string
+	first, last := key(items[0]).Encode(), key(items[len(items)-1]).Encode()
+	if p.Backward() {
+		// Se llegó retrocediendo desde un cursor, así que siempre hay elementos después
+		next = last
+		if hasMore {
+			prev = first
+		}
+	} else {
+		if hasMore {
+			next = last
+		}
+		if p.After != nil {
+			prev = first
+		}
+	}
+
+	return items, next, prev
+}
@@ -0,0 +1,264 @@
+package diff
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Tipos de operación de un diff
+const (
+	OpEqual  = "equal"
+	OpInsert = "insert"
+	OpDelete = "delete"
+)
+
+// Modos de segmentación del texto
+const (
+	ModeLine = "line"
+	ModeWord = "word"
+)
+
+// Op representa un fragmento del diff: texto sin cambios, insertado o eliminado
+type Op struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Stats resume la cantidad de segmentos insertados y eliminados
+type Stats struct {
+	Insertions int `json:"insertions"`
+	Deletions  int `json:"deletions"`
+}
+
+// IsValidMode indica si un modo de segmentación existe
+func IsValidMode(mode string) bool {
+	return mode == ModeLine || mode == ModeWord
+}
+
+// Lines calcula el diff entre dos textos línea por línea
+func Lines(a, b string) []Op {
+	return Compute(SplitLines(a), SplitLines(b))
+}
+
+// Words calcula el diff entre dos textos palabra por palabra. Los espacios se
+// conservan como segmentos propios para que el texto pueda reconstruirse.
+func Words(a, b string) []Op {
+	return Compute(SplitWords(a), SplitWords(b))
+}
+
+// Text calcula el diff entre dos textos según el modo indicado
+func Text(a, b, mode string) []Op {
+	if mode == ModeWord {
+		return Words(a, b)
+	}
+	return Lines(a, b)
+}
+
+// SplitLines divide un texto en líneas conservando el salto de línea final de cada una
+func SplitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	var lines []string
+	for s != "" {
+		i := strings.IndexByte(s, '\n')
+		if i < 0 {
+			lines = append(lines, s)
+			break
+		}
+		lines = append(lines, s[:i+1])
+		s = s[i+1:]
+	}
+
+	return lines
+}
+
+// SplitWords divide un texto en palabras y bloques de espacios
+func SplitWords(s string) []string {
+	var words []string
+
+	start := 0
+	for i, r := range s {
+		if i == start {
+			continue
+		}
+		prev := []rune(s[start:i])
+		if unicode.IsSpace(prev[len(prev)-1]) != unicode.IsSpace(r) {
+			words = append(words, s[start:i])
+			start = i
+		}
+	}
+	if start < len(s) {
+		words = append(words, s[start:])
+	}
+
+	return words
+}
+
+// MaxEdits limita la cantidad de segmentos insertados o eliminados que se alinean con precisión.
+// Si dos textos difieren en más segmentos, la zona cambiada se reporta como un reemplazo completo.
+const MaxEdits = 1000
+
+// Compute calcula el diff mínimo entre dos secuencias con el algoritmo de Myers.
+// Los segmentos consecutivos del mismo tipo se agrupan en una sola operación.
+func Compute(a, b []string) []Op {
+	// Recortar el prefijo y el sufijo comunes, que suelen ser la mayor parte del texto
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var segments []Op
+	for _, text := range a[:prefix] {
+		segments = append(segments, Op{Type: OpEqual, Text: text})
+	}
+	segments = append(segments, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, text := range a[len(a)-suffix:] {
+		segments = append(segments, Op{Type: OpEqual, Text: text})
+	}
+
+	// Agrupar segmentos consecutivos del mismo tipo
+	var ops []Op
+	for _, op := range segments {
+		if len(ops) > 0 && ops[len(ops)-1].Type == op.Type {
+			ops[len(ops)-1].Text += op.Text
+			continue
+		}
+		ops = append(ops, op)
+	}
+
+	return ops
+}
+
+// myers calcula la secuencia de segmentos del diff mínimo entre a y b
+func myers(a, b []string) []Op {
+	n, m := len(a), len(b)
+	max := n + m
+	if max > MaxEdits {
+		max = MaxEdits
+	}
+	offset := max + 1
+
+	v := make([]int, 2*max+3)
+
+	// trace[d] guarda los valores de v para las diagonales -d..d antes del paso d
+	var trace [][]int
+
+	// Avanzar por las diagonales hasta alcanzar el final de ambas secuencias
+	found := false
+	for d := 0; d <= max && !found; d++ {
+		snapshot := make([]int, 2*d+1)
+		copy(snapshot, v[offset-d:offset+d+1])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	if !found {
+		return replaceAll(a, b)
+	}
+
+	// Reconstruir el camino desde el final
+	var reversed []Op
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		snapshot := trace[d]
+		at := func(k int) int { return snapshot[k+d] }
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		prevX, prevY := 0, 0
+		if d > 0 {
+			prevX = at(prevK)
+			prevY = prevX - prevK
+		}
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, Op{Type: OpEqual, Text: a[x]})
+		}
+
+		if d == 0 {
+			break
+		}
+
+		if x == prevX {
+			y--
+			reversed = append(reversed, Op{Type: OpInsert, Text: b[y]})
+		} else {
+			x--
+			reversed = append(reversed, Op{Type: OpDelete, Text: a[x]})
+		}
+	}
+
+	ops := make([]Op, 0, len(reversed))
+	for i := len(reversed) - 1; i >= 0; i-- {
+		ops = append(ops, reversed[i])
+	}
+
+	return ops
+}
+
+// replaceAll representa el cambio de a por b como una eliminación seguida de una inserción
+func replaceAll(a, b []string) []Op {
+	var ops []Op
+	for _, text := range a {
+		ops = append(ops, Op{Type: OpDelete, Text: text})
+	}
+	for _, text := range b {
+		ops = append(ops, Op{Type: OpInsert, Text: text})
+	}
+	return ops
+}
+
+// Summarize cuenta los segmentos insertados y eliminados de un diff según el modo
+func Summarize(ops []Op, mode string) Stats {
+	var stats Stats
+	for _, op := range ops {
+		count := countSegments(op.Text, mode)
+		switch op.Type {
+		case OpInsert:
+			stats.Insertions += count
+		case OpDelete:
+			stats.Deletions += count
+		}
+	}
+	return stats
+}
+
+// countSegments cuenta las líneas o palabras de un fragmento
+func countSegments(text, mode string) int {
+	if mode == ModeWord {
+		return len(strings.Fields(text))
+	}
+	return len(SplitLines(text))
+}
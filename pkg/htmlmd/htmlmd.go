@@ -0,0 +1,381 @@
+package htmlmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	// shortcodeWrappers son los shortcodes de WordPress que solo envuelven contenido
+	// convertible ([caption]<img> Texto[/caption], [embed]url[/embed])
+	shortcodeWrappers = regexp.MustCompile(`\[/?(caption|embed)[^\]]*\]`)
+	paragraphBreak    = regexp.MustCompile(`\n[ \t\r]*\n\s*`)
+	whitespace        = regexp.MustCompile(`[ \t\r\n]+`)
+	extraBlankLines   = regexp.MustCompile(`\n{3,}`)
+	markdownSpecial   = strings.NewReplacer(
+		`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`, `<`, `\<`,
+	)
+)
+
+// Convert convierte HTML, como el contenido de un post de WordPress, en Markdown. Los saltos
+// de línea dobles fuera de etiquetas de bloque se tratan como párrafos, igual que hace
+// WordPress al mostrar el contenido. Las etiquetas sin equivalente conservan solo su texto.
+func Convert(source string) string {
+	source = shortcodeWrappers.ReplaceAllString(source, "")
+
+	body := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(source), body)
+	if err != nil {
+		return source
+	}
+
+	c := &converter{}
+	for _, n := range nodes {
+		c.node(n)
+	}
+	return c.String()
+}
+
+// converter acumula el Markdown de una secuencia de nodos
+type converter struct {
+	b strings.Builder
+
+	// lineBreak indica un <br> pendiente; solo se escribe si le sigue más contenido en línea
+	lineBreak bool
+}
+
+// write escribe contenido en línea, precedido del salto de línea pendiente
+func (c *converter) write(s string) {
+	if s == "" {
+		return
+	}
+	if c.lineBreak {
+		c.lineBreak = false
+		if strings.TrimSpace(s) == "" {
+			c.lineBreak = true
+			return
+		}
+		c.b.WriteString("\\\n")
+		s = strings.TrimLeft(s, " ")
+	}
+	c.b.WriteString(s)
+}
+
+// String retorna el Markdown sin líneas en blanco repetidas
+func (c *converter) String() string {
+	return strings.TrimSpace(extraBlankLines.ReplaceAllString(c.b.String(), "\n\n"))
+}
+
+// node escribe el Markdown de un nodo
+func (c *converter) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		c.text(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		// Comentarios (incluidos los bloques de Gutenberg) y doctype
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Noscript, atom.Head:
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Header, atom.Footer, atom.Main,
+		atom.Aside, atom.Figure, atom.Center, atom.Dl, atom.Dd, atom.Dt:
+		c.block(children(n))
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level := int(n.Data[1] - '0')
+		c.block(strings.Repeat("#", level) + " " + oneLine(children(n)))
+	case atom.Br:
+		c.lineBreak = !c.atLineStart()
+	case atom.Hr:
+		c.block("---")
+	case atom.Strong, atom.B:
+		c.wrap("**", n)
+	case atom.Em, atom.I, atom.Cite:
+		c.wrap("*", n)
+	case atom.Code, atom.Kbd, atom.Tt:
+		c.write(inlineCode(textContent(n)))
+	case atom.A:
+		c.link(n)
+	case atom.Img:
+		if src := attr(n, "src"); src != "" {
+			c.write("![" + oneLine(markdownSpecial.Replace(attr(n, "alt"))) + "](" + destination(src) + ")")
+		}
+	case atom.Ul, atom.Ol:
+		c.block(list(n))
+	case atom.Blockquote:
+		c.block(prefixLines(children(n), "> "))
+	case atom.Pre:
+		c.block(codeBlock(n))
+	case atom.Table:
+		c.block(table(n))
+	case atom.Figcaption:
+		if caption := oneLine(children(n)); caption != "" {
+			c.block("*" + caption + "*")
+		}
+	case atom.Iframe, atom.Video, atom.Audio, atom.Embed, atom.Source:
+		if src := attr(n, "src"); src != "" {
+			c.block("<" + src + ">")
+		}
+	default:
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			c.node(child)
+		}
+	}
+}
+
+// text escribe texto escapado, colapsando los espacios y conservando los párrafos
+func (c *converter) text(data string) {
+	for i, part := range paragraphBreak.Split(data, -1) {
+		if i > 0 {
+			c.lineBreak = false
+			c.b.WriteString("\n\n")
+		}
+		part = whitespace.ReplaceAllString(part, " ")
+		if c.atLineStart() {
+			part = strings.TrimLeft(part, " ")
+		}
+		c.write(markdownSpecial.Replace(part))
+	}
+}
+
+// block escribe un bloque separado por líneas en blanco
+func (c *converter) block(markdown string) {
+	markdown = strings.TrimSpace(markdown)
+	if markdown == "" {
+		return
+	}
+	c.lineBreak = false
+
+	current := strings.TrimRight(c.b.String(), " \n")
+	c.b.Reset()
+	c.b.WriteString(current)
+	if current != "" {
+		c.b.WriteString("\n\n")
+	}
+	c.b.WriteString(markdown)
+	c.b.WriteString("\n\n")
+}
+
+// wrap escribe el contenido del nodo entre delimitadores de énfasis
+func (c *converter) wrap(delimiter string, n *html.Node) {
+	content := children(n)
+	trimmed := strings.TrimSpace(content)
+	if trimmed == "" {
+		c.write(content)
+		return
+	}
+
+	// Los espacios quedan fuera de los delimitadores: "** x**" no es énfasis en Markdown
+	if strings.HasPrefix(content, " ") && !c.atLineStart() {
+		c.write(" ")
+	}
+	c.write(delimiter + trimmed + delimiter)
+	if strings.HasSuffix(content, " ") {
+		c.write(" ")
+	}
+}
+
+// link escribe un enlace; sin destino se escribe solo el texto
+func (c *converter) link(n *html.Node) {
+	text := oneLine(children(n))
+	href := attr(n, "href")
+	if href == "" {
+		c.write(text)
+		return
+	}
+	if text == "" {
+		text = markdownSpecial.Replace(href)
+	}
+	c.write("[" + text + "](" + destination(href) + ")")
+}
+
+// atLineStart indica si lo siguiente se escribe al principio de una línea
+func (c *converter) atLineStart() bool {
+	s := c.b.String()
+	return !c.lineBreak && (s == "" || strings.HasSuffix(s, "\n"))
+}
+
+// children retorna el Markdown de los hijos de un nodo
+func children(n *html.Node) string {
+	sub := &converter{}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		sub.node(child)
+	}
+	return sub.b.String()
+}
+
+// list convierte una lista; las líneas de cada elemento se indentan bajo su marcador
+func list(n *html.Node) string {
+	var items []string
+	number := 1
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode || li.DataAtom != atom.Li {
+			continue
+		}
+
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = fmt.Sprintf("%d. ", number)
+			number++
+		}
+
+		body := strings.TrimSpace(extraBlankLines.ReplaceAllString(children(li), "\n\n"))
+		items = append(items, marker+indentLines(body, strings.Repeat(" ", len(marker))))
+	}
+	return strings.Join(items, "\n")
+}
+
+// codeBlock convierte un bloque <pre> en un bloque de código cercado, con el lenguaje de la
+// clase language-* si la tiene
+func codeBlock(n *html.Node) string {
+	language := ""
+	for _, node := range []*html.Node{n, n.FirstChild} {
+		if node == nil || node.Type != html.ElementNode {
+			continue
+		}
+		for _, class := range strings.Fields(attr(node, "class")) {
+			if strings.HasPrefix(class, "language-") {
+				language = strings.TrimPrefix(class, "language-")
+			}
+		}
+	}
+
+	code := strings.Trim(textContent(n), "\n")
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	return fence + language + "\n" + code + "\n" + fence
+}
+
+// table convierte una tabla en una tabla Markdown; la primera fila hace de cabecera
+func table(n *html.Node) string {
+	var rows [][]string
+	columns := 0
+
+	var collect func(*html.Node)
+	collect = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			if child.DataAtom != atom.Tr {
+				collect(child)
+				continue
+			}
+
+			var row []string
+			for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+				if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+					row = append(row, strings.ReplaceAll(oneLine(children(cell)), "|", `\|`))
+				}
+			}
+			if len(row) > columns {
+				columns = len(row)
+			}
+			rows = append(rows, row)
+		}
+	}
+	collect(n)
+
+	if len(rows) == 0 || columns == 0 {
+		return ""
+	}
+
+	var lines []string
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		lines = append(lines, "| "+strings.Join(row, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", columns))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// textContent retorna el texto de un nodo sin convertir
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.DataAtom == atom.Br {
+			b.WriteString("\n")
+			continue
+		}
+		b.WriteString(textContent(child))
+	}
+	return b.String()
+}
+
+// inlineCode escribe código en línea con tantas comillas invertidas como haga falta
+func inlineCode(code string) string {
+	code = whitespace.ReplaceAllString(code, " ")
+	if strings.TrimSpace(code) == "" {
+		return code
+	}
+
+	fence := "`"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+		code = " " + code + " "
+	}
+	return fence + code + fence
+}
+
+// oneLine une las líneas de un fragmento en una sola
+func oneLine(markdown string) string {
+	markdown = strings.ReplaceAll(markdown, "\\\n", " ")
+	return strings.TrimSpace(whitespace.ReplaceAllString(markdown, " "))
+}
+
+// prefixLines añade prefix a cada línea, incluidas las vacías (citas)
+func prefixLines(markdown, prefix string) string {
+	lines := strings.Split(strings.TrimSpace(markdown), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(prefix+line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// indentLines indenta todas las líneas salvo la primera y las vacías
+func indentLines(markdown, indent string) string {
+	lines := strings.Split(markdown, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = indent + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// destination escapa un destino de enlace o imagen
+func destination(url string) string {
+	url = strings.TrimSpace(url)
+	if strings.ContainsAny(url, " ()<>") {
+		return "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(url) + ">"
+	}
+	return url
+}
+
+// attr retorna el valor de un atributo o una cadena vacía
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
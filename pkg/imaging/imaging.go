@@ -0,0 +1,70 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif" // registra el decodificador de GIF
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // registra el decodificador de WebP
+)
+
+// JPEGQuality es la calidad usada al codificar variantes JPEG
+const JPEGQuality = 85
+
+// Decode decodifica una imagen JPEG, PNG, GIF o WebP
+func Decode(data []byte) (image.Image, string, error) {
+	return image.Decode(bytes.NewReader(data))
+}
+
+// DecodeConfig lee solo las dimensiones y el formato de una imagen
+func DecodeConfig(data []byte) (image.Config, string, error) {
+	return image.DecodeConfig(bytes.NewReader(data))
+}
+
+// Fit reduce la imagen para que quepa en un cuadrado de maxSize píxeles conservando la
+// proporción. Retorna false si la imagen ya cabe, porque las variantes nunca amplían.
+func Fit(img image.Image, maxSize int) (image.Image, bool) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if maxSize <= 0 || (width <= maxSize && height <= maxSize) {
+		return img, false
+	}
+
+	if width >= height {
+		height = max(1, height*maxSize/width)
+		width = maxSize
+	} else {
+		width = max(1, width*maxSize/height)
+		height = maxSize
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+	return dst, true
+}
+
+// Encode codifica una variante en el formato de la imagen original. Las variantes de GIF y
+// WebP se codifican como PNG porque no se conserva la animación y no hay codificador WebP.
+// Retorna los bytes y su tipo de contenido.
+func Encode(img image.Image, format string) ([]byte, string, error) {
+	var buf bytes.Buffer
+
+	switch format {
+	case "jpeg":
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: JPEGQuality}); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/jpeg", nil
+	case "png", "gif", "webp":
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/png", nil
+	}
+
+	return nil, "", fmt.Errorf("formato de imagen no soportado: %s", format)
+}
@@ -0,0 +1,13 @@
+package mailer
+
+// Message representa un correo electrónico de texto plano
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer envía correos electrónicos
+type Mailer interface {
+	Send(msg Message) error
+}
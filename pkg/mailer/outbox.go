@@ -0,0 +1,50 @@
+package mailer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// OutboxMailer escribe los correos en un archivo o en stdout en lugar de enviarlos.
+// Se usa en desarrollo y pruebas.
+type OutboxMailer struct {
+	mu   sync.Mutex
+	w    io.Writer
+	from string
+}
+
+// NewOutboxMailer crea un mailer que escribe en w
+func NewOutboxMailer(w io.Writer, from string) *OutboxMailer {
+	return &OutboxMailer{
+		w:    w,
+		from: from,
+	}
+}
+
+// NewFileOutboxMailer crea un mailer que agrega los correos al archivo indicado.
+// Si path está vacío escribe en stdout.
+func NewFileOutboxMailer(path, from string) (*OutboxMailer, error) {
+	if path == "" {
+		return NewOutboxMailer(os.Stdout, from), nil
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo outbox %s: %w", path, err)
+	}
+
+	return NewOutboxMailer(file, from), nil
+}
+
+// Send escribe el mensaje en el outbox
+func (m *OutboxMailer) Send(msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := fmt.Fprintf(m.w, "=== %s ===\nFrom: %s\nTo: %s\nSubject: %s\n\n%s\n\n",
+		time.Now().Format(time.RFC3339), m.from, msg.To, msg.Subject, msg.Body)
+	return err
+}
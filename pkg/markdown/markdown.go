@@ -0,0 +1,181 @@
+package markdown
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/alan.bermudez/goasync/pkg/slug"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// Version identifica la versión del renderizado. Se guarda junto al HTML cacheado y debe
+// incrementarse al cambiar las extensiones o la política de sanitización para que el
+// contenido guardado con una versión anterior se vuelva a renderizar.
+const Version = 1
+
+// WordsPerMinute es la velocidad de lectura usada para estimar el tiempo de lectura
+const WordsPerMinute = 200
+
+// Heading representa una entrada de la tabla de contenidos
+type Heading struct {
+	Level int    `json:"level"`
+	ID    string `json:"id"`
+	Text  string `json:"text"`
+}
+
+// Document es el resultado de renderizar un texto Markdown
+type Document struct {
+	HTML        string
+	TOC         []Heading
+	WordCount   int
+	ReadingTime int // minutos
+}
+
+// converter admite CommonMark (incluidos los bloques de código cercados) más tablas y notas
+// al pie. El HTML crudo del Markdown se omite; aun así el resultado pasa por el sanitizador.
+var converter = goldmark.New(
+	goldmark.WithExtensions(
+		extension.NewTable(extension.WithTableCellAlignMethod(extension.TableCellAlignAttribute)),
+		extension.Footnote,
+	),
+	goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+)
+
+// policy permite el HTML habitual del contenido de usuarios más las clases de lenguaje de los
+// bloques de código y los atributos que generan las notas al pie
+var policy = newPolicy()
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	// Los enlaces internos (anclas y notas al pie) no necesitan nofollow
+	p.RequireNoFollowOnLinks(false)
+	p.RequireNoFollowOnFullyQualifiedLinks(true)
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#.-]+$`)).OnElements("code")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^footnote-(ref|backref)$`)).OnElements("a")
+	p.AllowAttrs("role").Matching(regexp.MustCompile(`^doc-(noteref|backlink)$`)).OnElements("a")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^footnotes$`)).OnElements("div")
+	p.AllowAttrs("role").Matching(regexp.MustCompile(`^doc-endnotes$`)).OnElements("div")
+	return p
+}
+
+// Render convierte Markdown en HTML sanitizado y calcula la tabla de contenidos, el número de
+// palabras y el tiempo de lectura estimado. Los encabezados reciben un id a partir de su texto
+// (ej: "## Instalación rápida" produce id="instalacion-rapida").
+func Render(source string) (*Document, error) {
+	src := []byte(source)
+	ctx := parser.NewContext(parser.WithIDs(newHeadingIDs()))
+	root := converter.Parser().Parse(text.NewReader(src), parser.WithContext(ctx))
+
+	var buf bytes.Buffer
+	if err := converter.Renderer().Render(&buf, src, root); err != nil {
+		return nil, err
+	}
+
+	doc := &Document{
+		HTML: policy.Sanitize(buf.String()),
+		TOC:  []Heading{},
+	}
+
+	var plain strings.Builder
+	ast.Walk(root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch node := n.(type) {
+		case *ast.Heading:
+			id, _ := node.AttributeString("id")
+			idBytes, _ := id.([]byte)
+			doc.TOC = append(doc.TOC, Heading{
+				Level: node.Level,
+				ID:    string(idBytes),
+				Text:  strings.TrimSpace(inlineText(node, src)),
+			})
+		case *ast.Text:
+			plain.Write(node.Segment.Value(src))
+			if node.SoftLineBreak() || node.HardLineBreak() {
+				plain.WriteByte(' ')
+			}
+		case *ast.String:
+			plain.Write(node.Value)
+		case *ast.FencedCodeBlock, *ast.CodeBlock:
+			lines := n.Lines()
+			for i := 0; i < lines.Len(); i++ {
+				segment := lines.At(i)
+				plain.Write(segment.Value(src))
+			}
+		}
+
+		if n.Type() == ast.TypeBlock {
+			plain.WriteByte(' ')
+		}
+		return ast.WalkContinue, nil
+	})
+
+	doc.WordCount = len(strings.Fields(plain.String()))
+	doc.ReadingTime = ReadingTime(doc.WordCount)
+
+	return doc, nil
+}
+
+// ReadingTime estima los minutos de lectura de un texto con el número de palabras dado,
+// redondeando hacia arriba. Un texto vacío tarda 0 minutos.
+func ReadingTime(words int) int {
+	return (words + WordsPerMinute - 1) / WordsPerMinute
+}
+
+// inlineText concatena el texto de los nodos inline de un bloque, sin marcas de formato
+func inlineText(n ast.Node, src []byte) string {
+	var b strings.Builder
+	ast.Walk(n, func(child ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := child.(type) {
+		case *ast.Text:
+			b.Write(node.Segment.Value(src))
+			if node.SoftLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			b.Write(node.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+	return b.String()
+}
+
+// headingIDs genera los ids de los encabezados con el mismo slugger que los posts, añadiendo
+// un sufijo numérico a los repetidos (ej: "uso", "uso-2")
+type headingIDs struct {
+	used map[string]bool
+}
+
+func newHeadingIDs() *headingIDs {
+	return &headingIDs{used: map[string]bool{}}
+}
+
+// Generate implementa parser.IDs
+func (ids *headingIDs) Generate(value []byte, kind ast.NodeKind) []byte {
+	base := slug.Make(string(value))
+	if base == slug.Fallback && kind == ast.KindHeading {
+		base = "heading"
+	}
+
+	id, _ := slug.Unique(base, 0, func(candidate string) (bool, error) {
+		return ids.used[candidate], nil
+	})
+	ids.used[id] = true
+	return []byte(id)
+}
+
+// Put implementa parser.IDs
+func (ids *headingIDs) Put(value []byte) {
+	ids.used[string(value)] = true
+}
@@ -0,0 +1,229 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/alan.bermudez/goasync/pkg/auth"
+	"github.com/alan.bermudez/goasync/pkg/rbac"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Claves bajo las cuales se guarda la identidad autenticada en el contexto
+const (
+	UserIDKey       = "user_id"
+	SessionIDKey    = "session_id"
+	APIKeyIDKey     = "api_key_id"
+	APIKeyScopesKey = "api_key_scopes"
+
+	ImpersonatorIDKey  = "impersonator_id"
+	ImpersonationIDKey = "impersonation_id"
+)
+
+// SessionCookieName es el nombre de la cookie que transporta el token de sesión
+const SessionCookieName = "session_token"
+
+// SessionValidator valida tokens de sesión opacos
+type SessionValidator interface {
+	ValidateSession(token string) (userID uuid.UUID, sessionID uuid.UUID, err error)
+}
+
+// APIKeyValidator valida API keys y retorna el usuario y los scopes asociados
+type APIKeyValidator interface {
+	ValidateAPIKey(key string) (userID uuid.UUID, keyID uuid.UUID, scopes []string, err error)
+}
+
+// ImpersonationValidator valida tokens de suplantación y retorna el usuario suplantado y el administrador
+type ImpersonationValidator interface {
+	ValidateImpersonation(token string) (userID uuid.UUID, adminID uuid.UUID, impersonationID uuid.UUID, err error)
+}
+
+// AuthOptions contiene los mecanismos de autenticación aceptados por el middleware
+type AuthOptions struct {
+	Tokens   *auth.TokenManager
+	Sessions SessionValidator
+	APIKeys  APIKeyValidator
+
+	Impersonations ImpersonationValidator
+
+	// OnAPIKeyUse se invoca en cada petición autenticada con una API key
+	OnAPIKeyUse func(c *gin.Context, userID, keyID uuid.UUID)
+}
+
+// Auth middleware que exige una credencial válida. Acepta:
+//   - Authorization: Bearer <access_token> (JWT)
+//   - Authorization: Session <token> o la cookie session_token (sesión de servidor)
+//   - Authorization: ApiKey <key> (clientes máquina a máquina)
+//   - Authorization: Impersonation <token> (administrador actuando como otro usuario)
+func Auth(opts AuthOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scheme, credential := credentialsFromRequest(c)
+
+		switch {
+		case strings.EqualFold(scheme, "Bearer") && opts.Tokens != nil:
+			claims, err := opts.Tokens.Parse(credential, auth.TokenTypeAccess)
+			if err != nil {
+				abortUnauthorized(c, "Token de autenticación inválido o expirado")
+				return
+			}
+			userID, _ := claims.UserID()
+			c.Set(UserIDKey, userID)
+
+		case strings.EqualFold(scheme, "Session") && opts.Sessions != nil:
+			userID, sessionID, err := opts.Sessions.ValidateSession(credential)
+			if err != nil {
+				abortUnauthorized(c, "Sesión inválida o expirada")
+				return
+			}
+			c.Set(UserIDKey, userID)
+			c.Set(SessionIDKey, sessionID)
+
+		case strings.EqualFold(scheme, "ApiKey") && opts.APIKeys != nil:
+			userID, keyID, scopes, err := opts.APIKeys.ValidateAPIKey(credential)
+			if err != nil {
+				abortUnauthorized(c, "API key inválida, revocada o expirada")
+				return
+			}
+			c.Set(UserIDKey, userID)
+			c.Set(APIKeyIDKey, keyID)
+			c.Set(APIKeyScopesKey, scopes)
+			if opts.OnAPIKeyUse != nil {
+				opts.OnAPIKeyUse(c, userID, keyID)
+			}
+
+		case strings.EqualFold(scheme, "Impersonation") && opts.Impersonations != nil:
+			userID, adminID, impersonationID, err := opts.Impersonations.ValidateImpersonation(credential)
+			if err != nil {
+				abortUnauthorized(c, "Suplantación inválida, finalizada o expirada")
+				return
+			}
+			c.Set(UserIDKey, userID)
+			c.Set(ImpersonatorIDKey, adminID)
+			c.Set(ImpersonationIDKey, impersonationID)
+
+		default:
+			abortUnauthorized(c, "Token de autenticación requerido")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// OptionalAuth middleware que autentica la petición solo si trae credenciales. Sin ellas la
+// petición continúa como anónima; unas credenciales inválidas se rechazan igual que en Auth.
+func OptionalAuth(opts AuthOptions) gin.HandlerFunc {
+	authenticate := Auth(opts)
+	return func(c *gin.Context) {
+		if scheme, _ := credentialsFromRequest(c); scheme == "" {
+			c.Next()
+			return
+		}
+		authenticate(c)
+	}
+}
+
+// GetUserID obtiene el ID del usuario autenticado desde el contexto
+func GetUserID(c *gin.Context) (uuid.UUID, bool) {
+	return getUUID(c, UserIDKey)
+}
+
+// GetSessionID obtiene el ID de la sesión de servidor usada en la petición, si existe
+func GetSessionID(c *gin.Context) (uuid.UUID, bool) {
+	return getUUID(c, SessionIDKey)
+}
+
+// GetAPIKeyID obtiene el ID de la API key usada en la petición, si existe
+func GetAPIKeyID(c *gin.Context) (uuid.UUID, bool) {
+	return getUUID(c, APIKeyIDKey)
+}
+
+// GetAPIKeyScopes obtiene los scopes de la API key usada en la petición, si existe
+func GetAPIKeyScopes(c *gin.Context) ([]string, bool) {
+	value, exists := c.Get(APIKeyScopesKey)
+	if !exists {
+		return nil, false
+	}
+
+	scopes, ok := value.([]string)
+	return scopes, ok
+}
+
+// GetImpersonatorID obtiene el ID del administrador que suplanta al usuario autenticado, si existe
+func GetImpersonatorID(c *gin.Context) (uuid.UUID, bool) {
+	return getUUID(c, ImpersonatorIDKey)
+}
+
+// GetImpersonationID obtiene el ID de la suplantación usada en la petición, si existe
+func GetImpersonationID(c *gin.Context) (uuid.UUID, bool) {
+	return getUUID(c, ImpersonationIDKey)
+}
+
+// DenyImpersonation middleware que rechaza la petición si se realiza suplantando a un usuario.
+// Se usa en las rutas que modifican credenciales. Debe usarse después de Auth.
+func DenyImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := GetImpersonatorID(c); ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":  "Acción no permitida durante una suplantación",
+				"reason": rbac.ReasonImpersonation,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// DenyAPIKey middleware que rechaza la petición si se autentica con una API key. Se usa en las
+// rutas de seguridad de la cuenta (sesión, verificación de email, 2FA), que no tienen scope y
+// solo puede usar el propio usuario. Debe usarse después de Auth.
+func DenyAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := GetAPIKeyID(c); ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":  "Acción no permitida con una API key",
+				"reason": rbac.ReasonAPIKey,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// credentialsFromRequest extrae el esquema y la credencial del header Authorization o de la cookie de sesión
+func credentialsFromRequest(c *gin.Context) (string, string) {
+	if header := c.GetHeader("Authorization"); header != "" {
+		scheme, credential, found := strings.Cut(header, " ")
+		if !found {
+			return "", ""
+		}
+		return scheme, strings.TrimSpace(credential)
+	}
+
+	if cookie, err := c.Cookie(SessionCookieName); err == nil && cookie != "" {
+		return "Session", cookie
+	}
+
+	return "", ""
+}
+
+// getUUID obtiene un UUID guardado en el contexto
+func getUUID(c *gin.Context, key string) (uuid.UUID, bool) {
+	value, exists := c.Get(key)
+	if !exists {
+		return uuid.Nil, false
+	}
+
+	id, ok := value.(uuid.UUID)
+	return id, ok
+}
+
+// abortUnauthorized corta la petición con un 401
+func abortUnauthorized(c *gin.Context, message string) {
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"error": message,
+	})
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/alan.bermudez/goasync/pkg/rbac"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// UserRoleKey es la clave bajo la cual se guarda el rol del usuario autenticado en el contexto
+const UserRoleKey = "user_role"
+
+// RoleResolver obtiene el rol actual de un usuario
+type RoleResolver interface {
+	GetUserRole(userID uuid.UUID) (string, error)
+}
+
+// RequirePermission middleware que exige que el rol del usuario autenticado tenga el
+// permiso (resource, action) según la política. Si la petición usa una API key, además
+// exige el scope correspondiente. Debe usarse después de Auth.
+func RequirePermission(policy rbac.Policy, roles RoleResolver, resource, action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := GetUserID(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error":  "Usuario no autenticado",
+				"reason": rbac.ReasonUnauthenticated,
+			})
+			return
+		}
+
+		role, ok := GetUserRole(c)
+		if !ok {
+			var err error
+			role, err = roles.GetUserRole(userID)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+					"error":  "Usuario no autenticado",
+					"reason": rbac.ReasonUnauthenticated,
+				})
+				return
+			}
+			c.Set(UserRoleKey, role)
+		}
+
+		if !policy.Allows(role, resource, action) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":          "Permisos insuficientes",
+				"reason":         rbac.ReasonInsufficientRole,
+				"resource":       resource,
+				"action":         action,
+				"required_roles": policy.RolesFor(resource, action),
+			})
+			return
+		}
+
+		if scopes, ok := GetAPIKeyScopes(c); ok && !rbac.HasScope(scopes, resource, action) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":          "La API key no tiene el scope requerido",
+				"reason":         rbac.ReasonInsufficientScope,
+				"resource":       resource,
+				"action":         action,
+				"required_scope": rbac.ScopeFor(resource, action),
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// GetUserRole obtiene el rol del usuario autenticado desde el contexto
+func GetUserRole(c *gin.Context) (string, bool) {
+	value, exists := c.Get(UserRoleKey)
+	if !exists {
+		return "", false
+	}
+
+	role, ok := value.(string)
+	return role, ok
+}
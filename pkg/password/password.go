@@ -0,0 +1,90 @@
+package password
+
+import (
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MaxLength es la longitud máxima en bytes que bcrypt procesa
+const MaxLength = 72
+
+// dummyPassword es la contraseña del hash usado por VerifyDummy
+const dummyPassword = "goasync-dummy-password"
+
+// ErrTooLong se retorna cuando la contraseña supera la longitud máxima
+var ErrTooLong = errors.New("la contraseña es demasiado larga")
+
+// Hasher genera y verifica hashes de contraseñas con bcrypt.
+// El hash resultante incluye el algoritmo y el costo ($2a$<costo>$...),
+// por lo que es posible detectar hashes generados con parámetros anteriores.
+type Hasher struct {
+	cost int
+
+	dummyOnce sync.Once
+	dummyHash []byte
+}
+
+// NewHasher crea un nuevo hasher con el costo indicado
+func NewHasher(cost int) *Hasher {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = bcrypt.DefaultCost
+	}
+	return &Hasher{cost: cost}
+}
+
+// Hash genera el hash de una contraseña
+func (h *Hasher) Hash(plain string) (string, error) {
+	if len(plain) > MaxLength {
+		return "", ErrTooLong
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(plain), h.cost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// Verify compara una contraseña con el hash almacenado
+func (h *Hasher) Verify(storedHash, plain string) bool {
+	if isBcrypt(storedHash) {
+		return bcrypt.CompareHashAndPassword([]byte(storedHash), []byte(plain)) == nil
+	}
+
+	// Contraseñas heredadas almacenadas sin hash
+	return subtle.ConstantTimeCompare([]byte(storedHash), []byte(plain)) == 1
+}
+
+// VerifyDummy compara la contraseña con un hash fijo del costo actual y descarta el resultado.
+// Se usa cuando el usuario no existe para que la respuesta tarde lo mismo que con una
+// contraseña incorrecta y no revele qué cuentas existen.
+func (h *Hasher) VerifyDummy(plain string) {
+	h.dummyOnce.Do(func() {
+		h.dummyHash, _ = bcrypt.GenerateFromPassword([]byte(dummyPassword), h.cost)
+	})
+	_ = bcrypt.CompareHashAndPassword(h.dummyHash, []byte(plain))
+}
+
+// NeedsRehash indica si el hash fue generado con un algoritmo o costo distinto al actual
+func (h *Hasher) NeedsRehash(storedHash string) bool {
+	if !isBcrypt(storedHash) {
+		return true
+	}
+
+	cost, err := bcrypt.Cost([]byte(storedHash))
+	if err != nil {
+		return true
+	}
+
+	return cost != h.cost
+}
+
+// isBcrypt indica si el hash tiene formato bcrypt
+func isBcrypt(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
@@ -0,0 +1,105 @@
+package rbac
+
+// Roles disponibles en el sistema
+const (
+	RoleAdmin     = "admin"
+	RoleEditor    = "editor"
+	RoleAuthor    = "author"
+	RoleCommenter = "commenter"
+	RoleReader    = "reader"
+)
+
+// DefaultRole es el rol asignado a los usuarios registrados sin invitación
+const DefaultRole = RoleCommenter
+
+// Roles lista todos los roles válidos
+var Roles = []string{RoleAdmin, RoleEditor, RoleAuthor, RoleCommenter, RoleReader}
+
+// Razones de rechazo legibles por máquinas
+const (
+	ReasonUnauthenticated   = "unauthenticated"
+	ReasonInsufficientRole  = "insufficient_role"
+	ReasonNotOwner          = "not_owner"
+	ReasonInsufficientScope = "insufficient_scope"
+	ReasonImpersonation     = "impersonation"
+	ReasonAPIKey            = "api_key"
+)
+
+// IsValidRole indica si un rol existe
+func IsValidRole(role string) bool {
+	for _, r := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// IsModerator indica si el rol puede gestionar contenido de otros usuarios
+func IsModerator(role string) bool {
+	return role == RoleAdmin || role == RoleEditor
+}
+
+// Permission identifica una acción sobre un recurso
+type Permission struct {
+	Resource string
+	Action   string
+}
+
+// Policy asocia cada permiso con los roles que lo tienen
+type Policy map[Permission][]string
+
+// Allows indica si un rol tiene el permiso (resource, action)
+func (p Policy) Allows(role, resource, action string) bool {
+	for _, r := range p[Permission{Resource: resource, Action: action}] {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// RolesFor retorna los roles que tienen el permiso (resource, action)
+func (p Policy) RolesFor(resource, action string) []string {
+	return p[Permission{Resource: resource, Action: action}]
+}
+
+// Niveles de acceso de los scopes de API keys
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+// ScopeResources lista los recursos que pueden incluirse en un scope
+var ScopeResources = []string{"users", "posts", "series", "media", "categories", "tags", "comments", "stats", "api_keys", "roles", "invites", "export"}
+
+// ScopeFor retorna el scope requerido para una acción sobre un recurso,
+// por ejemplo "posts:write" o "stats:read"
+func ScopeFor(resource, action string) string {
+	if action == ScopeRead {
+		return resource + ":" + ScopeRead
+	}
+	return resource + ":" + ScopeWrite
+}
+
+// IsValidScope indica si un scope tiene la forma recurso:read o recurso:write
+func IsValidScope(scope string) bool {
+	for _, resource := range ScopeResources {
+		if scope == resource+":"+ScopeRead || scope == resource+":"+ScopeWrite {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScope indica si los scopes otorgan la acción sobre el recurso.
+// El scope de escritura incluye el de lectura.
+func HasScope(scopes []string, resource, action string) bool {
+	required := ScopeFor(resource, action)
+	for _, scope := range scopes {
+		if scope == required || scope == resource+":"+ScopeWrite {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package slug
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Fallback es el slug usado cuando el texto no contiene caracteres válidos, igual que
+// la función generate_unique_slug de la base de datos
+const Fallback = "untitled"
+
+// transliterations cubre las letras que no se descomponen en letra base más diacrítico
+var transliterations = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'ł': "l", 'đ': "d", 'ð': "d", 'þ': "th", 'ı': "i",
+}
+
+// Make convierte un texto en un slug: translitera acentos (ó → o, ñ → n), pasa a minúsculas
+// y reemplaza espacios y puntuación por un único guion. Ej: "Introducción a Go: ¿Por qué?"
+// produce "introduccion-a-go-por-que".
+func Make(text string) string {
+	var b strings.Builder
+	pendingDash := false
+
+	for _, r := range norm.NFD.String(strings.ToLower(text)) {
+		if unicode.Is(unicode.Mn, r) {
+			// Diacrítico separado por la normalización NFD
+			continue
+		}
+
+		var part string
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			part = string(r)
+		case transliterations[r] != "":
+			part = transliterations[r]
+		default:
+			pendingDash = b.Len() > 0
+			continue
+		}
+
+		if pendingDash {
+			b.WriteByte('-')
+			pendingDash = false
+		}
+		b.WriteString(part)
+	}
+
+	if b.Len() == 0 {
+		return Fallback
+	}
+	return b.String()
+}
+
+// Truncate recorta un slug a maxLen bytes sin dejar un guion al final
+func Truncate(slug string, maxLen int) string {
+	if maxLen <= 0 || len(slug) <= maxLen {
+		return slug
+	}
+	return strings.TrimRight(slug[:maxLen], "-")
+}
+
+// Unique retorna el primer slug libre entre base, base-2, base-3... según exists, recortando
+// la base para que el resultado no supere maxLen bytes (0 para no limitar)
+func Unique(base string, maxLen int, exists func(candidate string) (bool, error)) (string, error) {
+	for n := 1; ; n++ {
+		suffix := ""
+		if n > 1 {
+			suffix = "-" + strconv.Itoa(n)
+		}
+
+		candidate := base
+		if maxLen > 0 {
+			candidate = Truncate(base, maxLen-len(suffix))
+		}
+		candidate += suffix
+
+		taken, err := exists(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound indica que no existe un objeto con esa clave
+var ErrNotFound = errors.New("objeto no encontrado")
+
+// Storage guarda archivos identificados por una clave relativa (ej: "2024/05/<id>.jpg")
+type Storage interface {
+	// Put guarda el contenido de r bajo la clave, reemplazando el objeto existente
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+
+	// Open abre el objeto para leerlo. Retorna ErrNotFound si no existe.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete elimina el objeto. Eliminar un objeto inexistente no es un error.
+	Delete(ctx context.Context, key string) error
+
+	// URL retorna la URL pública del objeto
+	URL(key string) string
+}
@@ -0,0 +1,79 @@
+package totp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// encryptedPrefix marca los secretos cifrados. Un secreto base32 nunca contiene ':', así que
+// los secretos guardados en claro antes de cifrarlos se distinguen sin ambigüedad.
+const encryptedPrefix = "v1:"
+
+// Cipher cifra los secretos TOTP para guardarlos en la base de datos (AES-256-GCM)
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher crea un cifrador con una clave derivada de key con SHA-256
+func NewCipher(key string) (*Cipher, error) {
+	if key == "" {
+		return nil, fmt.Errorf("la clave de cifrado TOTP no puede estar vacía")
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cipher{aead: aead}, nil
+}
+
+// Encrypt cifra un secreto con un nonce aleatorio
+func (c *Cipher) Encrypt(secret string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, []byte(secret), nil)
+	return encryptedPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt descifra un secreto guardado con Encrypt. Un secreto sin cifrar se retorna tal cual.
+func (c *Cipher) Decrypt(stored string) (string, error) {
+	if !IsEncrypted(stored) {
+		return stored, nil
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(stored, encryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("secreto TOTP cifrado inválido: %w", err)
+	}
+	if len(sealed) < c.aead.NonceSize() {
+		return "", fmt.Errorf("secreto TOTP cifrado inválido")
+	}
+
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	secret, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("no se pudo descifrar el secreto TOTP: %w", err)
+	}
+
+	return string(secret), nil
+}
+
+// IsEncrypted indica si un secreto guardado está cifrado
+func IsEncrypted(stored string) bool {
+	return strings.HasPrefix(stored, encryptedPrefix)
+}
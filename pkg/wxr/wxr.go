@@ -0,0 +1,228 @@
+package wxr
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// dateLayout es el formato de las fechas de WordPress ("2019-03-01 10:00:00")
+const dateLayout = "2006-01-02 15:04:05"
+
+// Export representa un archivo WXR (WordPress eXtended RSS) exportado desde WordPress.
+// Los elementos se reconocen por su nombre local, así que se aceptan las versiones 1.0, 1.1 y
+// 1.2 del formato.
+type Export struct {
+	Title       string     `xml:"channel>title"`
+	Link        string     `xml:"channel>link"`
+	BaseSiteURL string     `xml:"channel>base_site_url"`
+	Authors     []Author   `xml:"channel>author"`
+	Categories  []Category `xml:"channel>category"`
+	Tags        []Tag      `xml:"channel>tag"`
+	Items       []Item     `xml:"channel>item"`
+}
+
+// Author representa un usuario de WordPress que escribió algún post
+type Author struct {
+	ID          int    `xml:"author_id"`
+	Login       string `xml:"author_login"`
+	Email       string `xml:"author_email"`
+	DisplayName string `xml:"author_display_name"`
+	FirstName   string `xml:"author_first_name"`
+	LastName    string `xml:"author_last_name"`
+}
+
+// Category representa una categoría de WordPress. La jerarquía (Parent) se conserva solo
+// como información: las categorías de GoAsync no están anidadas.
+type Category struct {
+	ID          int    `xml:"term_id"`
+	Slug        string `xml:"category_nicename"`
+	Parent      string `xml:"category_parent"`
+	Name        string `xml:"cat_name"`
+	Description string `xml:"category_description"`
+}
+
+// Tag representa una etiqueta de WordPress
+type Tag struct {
+	ID          int    `xml:"term_id"`
+	Slug        string `xml:"tag_slug"`
+	Name        string `xml:"tag_name"`
+	Description string `xml:"tag_description"`
+}
+
+// Item representa una entrada del export: posts, páginas, adjuntos, menús... según PostType
+type Item struct {
+	Title       string     `xml:"title"`
+	Link        string     `xml:"link"`
+	Creator     string     `xml:"creator"`
+	ID          int        `xml:"post_id"`
+	PostDate    string     `xml:"post_date"`
+	PostDateGMT string     `xml:"post_date_gmt"`
+	ModifiedGMT string     `xml:"post_modified_gmt"`
+	Name        string     `xml:"post_name"`
+	Status      string     `xml:"status"`
+	PostType    string     `xml:"post_type"`
+	Terms       []ItemTerm `xml:"category"`
+	Comments    []Comment  `xml:"comment"`
+	Encoded     []Encoded  `xml:"encoded"`
+}
+
+// Encoded representa los elementos content:encoded y excerpt:encoded, que comparten nombre
+// local y solo se distinguen por el espacio de nombres. Usar Item.Content e Item.Excerpt.
+type Encoded struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+// ItemTerm representa una categoría o etiqueta asignada a un item. Domain es "category",
+// "post_tag" u otra taxonomía.
+type ItemTerm struct {
+	Domain   string `xml:"domain,attr"`
+	Nicename string `xml:"nicename,attr"`
+	Name     string `xml:",chardata"`
+}
+
+// Comment representa un comentario de un item. Approved es "1", "0", "spam" o "trash".
+type Comment struct {
+	ID          int    `xml:"comment_id"`
+	Author      string `xml:"comment_author"`
+	AuthorEmail string `xml:"comment_author_email"`
+	AuthorURL   string `xml:"comment_author_url"`
+	DateGMT     string `xml:"comment_date_gmt"`
+	Date        string `xml:"comment_date"`
+	Content     string `xml:"comment_content"`
+	Approved    string `xml:"comment_approved"`
+	Type        string `xml:"comment_type"`
+	Parent      int    `xml:"comment_parent"`
+	UserID      int    `xml:"comment_user_id"`
+}
+
+// Parse lee un export WXR completo
+func Parse(r io.Reader) (*Export, error) {
+	var export Export
+
+	decoder := xml.NewDecoder(r)
+	// Los exports antiguos declaran a veces codificaciones como ISO-8859-1 aunque el
+	// contenido suele ser UTF-8; se lee tal cual
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	if err := decoder.Decode(&export); err != nil {
+		return nil, fmt.Errorf("error leyendo el export WXR: %w", err)
+	}
+
+	return &export, nil
+}
+
+// Source identifica el sitio de origen del export para el mapeo de IDs: la URL base del sitio
+// sin esquema ni barra final (ej: "blog.example.com")
+func (e *Export) Source() string {
+	site := e.BaseSiteURL
+	if site == "" {
+		site = e.Link
+	}
+
+	if parsed, err := url.Parse(site); err == nil && parsed.Host != "" {
+		site = parsed.Host + parsed.Path
+	}
+	return strings.TrimRight(site, "/")
+}
+
+// Content retorna el contenido HTML del item (content:encoded)
+func (i *Item) Content() string {
+	for _, field := range i.Encoded {
+		if !strings.Contains(field.XMLName.Space, "excerpt") {
+			return field.Value
+		}
+	}
+	return ""
+}
+
+// Excerpt retorna el extracto del item (excerpt:encoded)
+func (i *Item) Excerpt() string {
+	for _, field := range i.Encoded {
+		if strings.Contains(field.XMLName.Space, "excerpt") {
+			return field.Value
+		}
+	}
+	return ""
+}
+
+// Slug retorna el slug original del item decodificado. WordPress guarda los slugs con
+// caracteres no ASCII codificados como URL (ej: "caf%c3%a9").
+func (i *Item) Slug() string {
+	return DecodeSlug(i.Name)
+}
+
+// Published retorna la fecha de publicación del item. Se usa la fecha GMT y, si falta (los
+// borradores la exportan como "0000-00-00 00:00:00"), la fecha local interpretada como UTC.
+func (i *Item) Published() (time.Time, bool) {
+	return parseDate(i.PostDateGMT, i.PostDate)
+}
+
+// Modified retorna la fecha de la última modificación del item
+func (i *Item) Modified() (time.Time, bool) {
+	return parseDate(i.ModifiedGMT, "")
+}
+
+// CategoryTerms retorna las categorías asignadas al item
+func (i *Item) CategoryTerms() []ItemTerm {
+	return i.termsIn("category")
+}
+
+// TagTerms retorna las etiquetas asignadas al item
+func (i *Item) TagTerms() []ItemTerm {
+	return i.termsIn("post_tag")
+}
+
+func (i *Item) termsIn(domain string) []ItemTerm {
+	var terms []ItemTerm
+	for _, term := range i.Terms {
+		if term.Domain == domain {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// SortedComments retorna los comentarios ordenados por ID, de modo que cada comentario
+// aparece después de su padre
+func (i *Item) SortedComments() []Comment {
+	comments := append([]Comment(nil), i.Comments...)
+	sort.SliceStable(comments, func(a, b int) bool {
+		return comments[a].ID < comments[b].ID
+	})
+	return comments
+}
+
+// Created retorna la fecha del comentario
+func (c *Comment) Created() (time.Time, bool) {
+	return parseDate(c.DateGMT, c.Date)
+}
+
+// DecodeSlug decodifica un slug codificado como URL; si no es válido lo retorna tal cual
+func DecodeSlug(raw string) string {
+	decoded, err := url.PathUnescape(raw)
+	if err != nil {
+		return raw
+	}
+	return decoded
+}
+
+// parseDate interpreta la primera fecha válida entre gmt y local
+func parseDate(gmt, local string) (time.Time, bool) {
+	for _, value := range []string{gmt, local} {
+		value = strings.TrimSpace(value)
+		if value == "" || strings.HasPrefix(value, "0000-00-00") {
+			continue
+		}
+		if t, err := time.ParseInLocation(dateLayout, value, time.UTC); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}